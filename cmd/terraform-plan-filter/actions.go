@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/actions"
+	"github.com/marc-poljak/terraform-plan-filter/internal/formatter"
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+// publishGitHubActions publishes the summary of a plan to the GitHub Actions run
+func publishGitHubActions(result *model.ResourceCollection, config Config) {
+	summary, err := formatter.FormatMarkdown(result, formatterOptions(config))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error formatting step summary: %v\n", err)
+		return
+	}
+
+	outputs := actions.Outputs(result.SummaryAdds, result.SummaryChanges, result.SummaryDestroys)
+	publish(summary, actions.Annotations("", result), outputs)
+}
+
+// publishStacksGitHubActions publishes the summary of many stacks to the GitHub Actions run
+func publishStacksGitHubActions(report *model.StackReport, config Config) {
+	summary, err := formatter.FormatStacksMarkdown(report, formatterOptions(config))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error formatting step summary: %v\n", err)
+		return
+	}
+
+	var annotations []actions.Annotation
+	for _, stack := range report.Stacks {
+		if stack.Err != nil {
+			annotations = append(annotations, actions.Annotation{
+				Level:   actions.LevelError,
+				Title:   "Plan not parsed in " + stack.Name,
+				Message: stack.Err.Error(),
+			})
+			continue
+		}
+		annotations = append(annotations, actions.Annotations(stack.Name, stack.Resources)...)
+	}
+
+	adds, changes, destroys := report.Totals()
+	publish(summary, annotations, actions.Outputs(adds, changes, destroys))
+}
+
+// publish writes to the files and workflow commands of the run. A failure is reported
+// without failing the command, since the plan summary itself was written.
+func publish(summary string, annotations []actions.Annotation, outputs []actions.Output) {
+	reporter := &actions.Reporter{Getenv: os.Getenv, Commands: os.Stderr}
+	if err := reporter.Publish(summary, annotations, outputs); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+	}
+}
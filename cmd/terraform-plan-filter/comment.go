@@ -0,0 +1,150 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/forge"
+)
+
+// commentConfig holds the flags of the comment subcommand
+type commentConfig struct {
+	forge  string
+	repo   string
+	number int
+	apiURL string
+	id     string
+}
+
+// runComment runs the comment subcommand, which posts a Markdown summary to a pull or merge
+// request and updates its previous comment instead of adding a new one on every run:
+//
+//	terraform-plan-filter -markdown < plan.json | terraform-plan-filter comment [options] [summary.md]
+func runComment(args []string) {
+	var config commentConfig
+
+	flags := flag.NewFlagSet("comment", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: terraform-plan-filter comment [options] [summary.md]\n\nReads the summary from stdin when no file is given.\n\nOptions:\n")
+		flags.PrintDefaults()
+	}
+	flags.StringVar(&config.forge, "forge", "", "Where to comment: github or gitlab (default: detected from the CI environment)")
+	flags.StringVar(&config.repo, "repo", "", "GitHub repository owner/name or GitLab project ID or path (default: from the CI environment)")
+	flags.IntVar(&config.number, "pr", 0, "Pull request number or merge request IID (default: from the CI environment)")
+	flags.StringVar(&config.apiURL, "api-url", "", "API URL for GitHub Enterprise or self-managed GitLab (default: from the CI environment)")
+	flags.StringVar(&config.id, "id", "", "Identifies the comment when several summaries are posted to the same request")
+	if err := flags.Parse(args); err != nil {
+		os.Exit(1)
+	}
+
+	if flags.NArg() > 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	body, err := readCommentBody(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	client, err := newForgeClient(config, os.Getenv)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	created, err := forge.Upsert(client, forge.Marker(config.id), body)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	if created {
+		fmt.Fprintln(os.Stderr, "Created comment")
+	} else {
+		fmt.Fprintln(os.Stderr, "Updated comment")
+	}
+}
+
+// readCommentBody reads the summary from a file, or from stdin if no file is given
+func readCommentBody(path string) (string, error) {
+	var body []byte
+	var err error
+	if path == "" {
+		body, err = io.ReadAll(os.Stdin)
+	} else {
+		body, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("error reading summary: %v", err)
+	}
+	if strings.TrimSpace(string(body)) == "" {
+		return "", fmt.Errorf("summary is empty")
+	}
+	return string(body), nil
+}
+
+// newForgeClient creates the client for the pull or merge request, filling in what the flags
+// leave out from the environment of GitHub Actions or GitLab CI
+func newForgeClient(config commentConfig, getenv func(string) string) (forge.Client, error) {
+	name := config.forge
+	if name == "" {
+		switch {
+		case getenv("GITHUB_ACTIONS") == "true":
+			name = "github"
+		case getenv("GITLAB_CI") == "true":
+			name = "gitlab"
+		default:
+			return nil, fmt.Errorf("cannot detect the forge outside GitHub Actions or GitLab CI, use -forge")
+		}
+	}
+
+	switch name {
+	case "github":
+		token := firstNonEmpty(getenv("GITHUB_TOKEN"), getenv("GH_TOKEN"))
+		if token == "" {
+			return nil, fmt.Errorf("GITHUB_TOKEN or GH_TOKEN must be set")
+		}
+		repo := firstNonEmpty(config.repo, getenv("GITHUB_REPOSITORY"))
+		number := config.number
+		if number == 0 {
+			number = forge.PullRequestFromRef(getenv("GITHUB_REF"))
+		}
+		if repo == "" || number == 0 {
+			return nil, fmt.Errorf("repository and pull request unknown, use -repo and -pr")
+		}
+		return forge.NewGitHub(firstNonEmpty(config.apiURL, getenv("GITHUB_API_URL"), forge.DefaultGitHubURL), repo, number, token), nil
+
+	case "gitlab":
+		token := getenv("GITLAB_TOKEN")
+		if token == "" {
+			return nil, fmt.Errorf("GITLAB_TOKEN must be set")
+		}
+		project := firstNonEmpty(config.repo, getenv("CI_PROJECT_ID"))
+		number := config.number
+		if number == 0 {
+			number, _ = strconv.Atoi(getenv("CI_MERGE_REQUEST_IID"))
+		}
+		if project == "" || number == 0 {
+			return nil, fmt.Errorf("project and merge request unknown, use -repo and -pr")
+		}
+		return forge.NewGitLab(firstNonEmpty(config.apiURL, getenv("CI_API_V4_URL"), forge.DefaultGitLabURL), project, number, token), nil
+
+	default:
+		return nil, fmt.Errorf("invalid forge %q, must be github or gitlab", name)
+	}
+}
+
+// firstNonEmpty returns the first of the values that is not empty
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
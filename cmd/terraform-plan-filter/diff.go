@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/formatter"
+	"github.com/marc-poljak/terraform-plan-filter/internal/ignore"
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+	"github.com/marc-poljak/terraform-plan-filter/internal/parser"
+	"github.com/marc-poljak/terraform-plan-filter/internal/plandiff"
+)
+
+// runDiff runs the diff subcommand, which compares the changes of two JSON plans:
+//
+//	terraform-plan-filter diff [options] old.json new.json
+func runDiff(args []string) {
+	var config Config
+
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: terraform-plan-filter diff [options] old-plan.json new-plan.json\n\nOptions:\n")
+		flags.PrintDefaults()
+	}
+	flags.BoolVar(&config.noColor, "no-color", false, "Disable colored output")
+	flags.BoolVar(&config.jsonOut, "json", false, "Output in JSON format")
+	flags.BoolVar(&config.htmlOut, "html", false, "Output in HTML format")
+	flags.StringVar(&config.outputFile, "output", "", "Output file (default: stdout)")
+	flags.StringVar(&config.ignoreFile, "ignore-file", "", "Ignore file (default: "+ignore.FileName+" in the working directory or a parent)")
+	flags.BoolVar(&config.noIgnore, "no-ignore", false, "Do not apply any ignore file")
+	flags.StringVar(&config.configFile, "config", "", "Project config file (default: .tfplanfilter.yml in the working directory or a parent)")
+	if err := flags.Parse(args); err != nil {
+		os.Exit(1)
+	}
+
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(1)
+	}
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		config.noColor = true
+	}
+
+	// Both plans are parsed with the same ignore rules so ignored resources don't show up as differences
+	projectConfig, err := loadProjectConfig(config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	parseOpts, err := setupParseOptions(config, projectConfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	oldPlan, err := parsePlanFile(flags.Arg(0), parseOpts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	newPlan, err := parsePlanFile(flags.Arg(1), parseOpts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	diff := plandiff.Compare(oldPlan, newPlan)
+	diff.OldSource = flags.Arg(0)
+	diff.NewSource = flags.Arg(1)
+
+	var output string
+	switch {
+	case config.jsonOut:
+		output, err = formatter.FormatDiffJSON(diff)
+	case config.htmlOut:
+		output, err = formatter.FormatDiffHTML(diff)
+	default:
+		output, err = formatter.FormatDiffText(diff, formatter.Options{UseColors: !config.noColor})
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error formatting output: %v\n", err)
+		os.Exit(1)
+	}
+
+	outputWriter, err := setupOutputDestination(config.outputFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	if outputWriter != os.Stdout {
+		defer safeClose(outputWriter, "output file")
+	}
+
+	if err := writeOutput(output, outputWriter); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
+
+// parsePlanFile opens and parses a JSON plan file
+func parsePlanFile(path string, opts parser.Options) (*model.ResourceCollection, error) {
+	inputFile, err := setupInputSource(path)
+	if err != nil {
+		return nil, err
+	}
+	defer safeClose(inputFile, "plan file")
+
+	return parseTerraformPlan(inputFile, opts)
+}
@@ -5,23 +5,152 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
+	"github.com/marc-poljak/terraform-plan-filter/internal/actions"
+	"github.com/marc-poljak/terraform-plan-filter/internal/baseline"
+	projectconfig "github.com/marc-poljak/terraform-plan-filter/internal/config"
+	"github.com/marc-poljak/terraform-plan-filter/internal/cost"
+	"github.com/marc-poljak/terraform-plan-filter/internal/filter"
 	"github.com/marc-poljak/terraform-plan-filter/internal/formatter"
+	"github.com/marc-poljak/terraform-plan-filter/internal/ignore"
 	"github.com/marc-poljak/terraform-plan-filter/internal/model"
 	"github.com/marc-poljak/terraform-plan-filter/internal/parser"
+	"github.com/marc-poljak/terraform-plan-filter/internal/policy"
+	"github.com/marc-poljak/terraform-plan-filter/internal/query"
+	"github.com/marc-poljak/terraform-plan-filter/internal/risk"
+	"github.com/marc-poljak/terraform-plan-filter/internal/security"
+	"github.com/marc-poljak/terraform-plan-filter/internal/source"
+	"github.com/marc-poljak/terraform-plan-filter/internal/stack"
+	"github.com/marc-poljak/terraform-plan-filter/internal/stateful"
 	"github.com/marc-poljak/terraform-plan-filter/internal/util"
+	"github.com/marc-poljak/terraform-plan-filter/internal/webhook"
 )
 
 // version is set during build using -ldflags
 var version = "dev"
 
 func main() {
+	// Dispatch subcommands before parsing the flags of the default command
+	if len(os.Args) > 1 && os.Args[1] == "diff" {
+		runDiff(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "comment" {
+		runComment(os.Args[2:])
+		return
+	}
+
 	// Parse command-line flags and set up configuration
 	config := parseCommandLineFlags()
 
+	// Build the resource filter from the flags
+	resourceFilter, err := buildFilter(config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	if config.sortBy != "type" && config.sortBy != "risk" {
+		fmt.Fprintf(os.Stderr, "invalid -sort value %q (expected type or risk)\n", config.sortBy)
+		os.Exit(1)
+	}
+
+	if !isOutputFormat(config.format) {
+		fmt.Fprintf(os.Stderr, "invalid -format value %q (expected %s)\n", config.format, strings.Join(outputFormats, ", "))
+		os.Exit(1)
+	}
+
+	if config.templateFile != "" {
+		if config.template, err = loadTemplate(config); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if config.webhook != "" {
+		if config.webhookFormat, err = resolveWebhookFormat(config); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Build the policy from the flags
+	resourcePolicy, err := buildPolicy(config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	// Load the pricing table for cost estimation
+	pricing, err := loadPricing(config.pricing)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	// Load the baseline of acknowledged changes
+	baselineFile, err := loadBaseline(config.baseline)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	// Load the project configuration
+	projectConfig, err := loadProjectConfig(config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	// Set up risk scoring with the weights from the project configuration
+	scorer, err := risk.NewScorer(projectConfig.RiskWeights)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid risk_weights in config: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Load the ignore rules
+	parseOpts, err := setupParseOptions(config, projectConfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	statefulConfig := projectConfig.StatefulResources
+	analyzer := &planAnalyzer{
+		filter:    resourceFilter,
+		policy:    resourcePolicy,
+		pricing:   pricing,
+		scorer:    scorer,
+		catalogue: stateful.NewCatalogue(!statefulConfig.NoDefaults, statefulConfig.Types, statefulConfig.Exclude),
+	}
+
+	// Expand the plan arguments and combine several plans into one report grouped by stack
+	inputs, err := stack.Resolve(config.planFiles)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	if len(inputs) > 1 {
+		runStacks(inputs, analyzer, baselineFile, parseOpts, config)
+		return
+	}
+
+	// Acknowledge the changes the baseline lists for the plan
+	analyzer.baseline, err = baselineFile.Plan()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
 	// Process input file
-	inputFile, err := setupInputSource(config.planFile)
+	planFile := ""
+	if len(inputs) == 1 {
+		planFile = inputs[0].Path
+	}
+	inputFile, err := setupInputSource(planFile)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
 		os.Exit(1)
@@ -31,12 +160,19 @@ func main() {
 	}
 
 	// Parse the Terraform plan
-	result, err := parseTerraformPlan(inputFile)
+	result, err := parseTerraformPlan(inputFile, parseOpts)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
 		os.Exit(1)
 	}
 
+	result = analyzer.analyze(result)
+
+	// Find the resource blocks the SARIF results point at
+	if config.format == "sarif" {
+		source.Locate(result, sourceDir(config, planFile))
+	}
+
 	// Set up output file
 	outputWriter, err := setupOutputDestination(config.outputFile)
 	if err != nil {
@@ -53,20 +189,136 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Write the Markdown note for the merge request if requested
+	if config.noteFile != "" {
+		if err := writeNote(config.noteFile, func() (string, error) { return formatter.FormatMarkdown(result, formatterOptions(config)) }); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Send the summary to the chat webhook if requested
+	if config.webhook != "" {
+		err := sendWebhook(config, func(opts formatter.Options) (string, error) {
+			if config.webhookFormat == webhook.FormatTeams {
+				return formatter.FormatTeams(result, opts)
+			}
+			return formatter.FormatSlack(result, opts)
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Publish the summary, annotations and step outputs when running in GitHub Actions
+	if !config.noGitHubActions && actions.Detect(os.Getenv) {
+		publishGitHubActions(result, config)
+	}
+
 	// Print debug information if verbose
 	if config.verbose {
 		util.PrintDebugInfo(result, config.verbose)
 	}
+
+	// Report policy violations on stderr unless the built-in text report already lists them,
+	// and fail with the matching exit code
+	if len(result.Violations) > 0 && (config.format != "text" || config.template != nil || outputWriter != os.Stdout) {
+		fmt.Fprint(os.Stderr, formatter.FormatViolationsText(result.Violations, formatter.Options{UseColors: !config.noColor}))
+	}
+	if code := policy.ExitCode(result.Violations); code != 0 {
+		os.Exit(code)
+	}
+}
+
+// planAnalyzer runs the checks on a parsed plan and applies the filters
+type planAnalyzer struct {
+	filter    *filter.Filter
+	policy    *policy.Policy
+	pricing   *cost.PricingFile
+	baseline  baseline.Baseline
+	scorer    *risk.Scorer
+	catalogue *stateful.Catalogue
+}
+
+// analyze annotates a parsed plan with dangers, risk, cost, security findings and policy
+// violations, and returns the resources selected by the filters
+func (a *planAnalyzer) analyze(result *model.ResourceCollection) *model.ResourceCollection {
+	// Set aside the changes acknowledged in the baseline so only new changes are reported
+	if a.baseline != nil {
+		a.baseline.Apply(result)
+	}
+
+	// Flag stateful resources that will be destroyed or replaced
+	a.catalogue.Mark(result)
+
+	// Score the risk of each resource and the plan
+	a.scorer.Apply(result)
+
+	// Estimate the monthly cost impact if a pricing file was given
+	if a.pricing != nil {
+		result.Costs = a.pricing.Estimate(result)
+	}
+
+	// Look for security-sensitive changes in the attribute values
+	result.SecurityFindings = security.Scan(result, security.DefaultDetectors())
+
+	// Check the full plan against the policy before any filtering
+	result.Violations = a.policy.Evaluate(result)
+
+	// Keep only the resources selected by the filters
+	return a.filter.Apply(result)
 }
 
 // Config holds the command-line configuration options
 type Config struct {
-	noColor    bool
-	jsonOut    bool
-	htmlOut    bool
-	planFile   string
-	outputFile string
-	verbose    bool
+	noColor         bool
+	jsonOut         bool
+	htmlOut         bool
+	format          string
+	noteFile        string
+	markdown        bool
+	planFiles       listFlag
+	outputFile      string
+	verbose         bool
+	attributeDiffs  bool
+	include         string
+	exclude         string
+	actions         string
+	types           string
+	modules         string
+	providers       string
+	where           string
+	changed         string
+	ignoreFile      string
+	noIgnore        bool
+	configFile      string
+	failOn          listFlag
+	policyFile      string
+	sortBy          string
+	pricing         string
+	baseline        string
+	noGitHubActions bool
+	webhook         string
+	webhookFormat   string
+	reportURL       string
+	sourceDir       string
+	templateFile    string
+	template        *formatter.Template
+}
+
+// listFlag is a flag that can be given multiple times
+type listFlag []string
+
+// String returns the flag values joined by spaces
+func (l *listFlag) String() string {
+	return strings.Join(*l, " ")
+}
+
+// Set appends a flag value
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
 }
 
 // parseCommandLineFlags parses command-line flags and returns a Config
@@ -75,11 +327,37 @@ func parseCommandLineFlags() Config {
 	var showVersion bool
 
 	flag.BoolVar(&config.noColor, "no-color", false, "Disable colored output")
-	flag.BoolVar(&config.jsonOut, "json", false, "Output in JSON format")
-	flag.BoolVar(&config.htmlOut, "html", false, "Output in HTML format")
-	flag.StringVar(&config.planFile, "plan", "", "Terraform JSON plan file (default: stdin)")
+	flag.StringVar(&config.format, "format", "text", "Output format: "+strings.Join(outputFormats, ", "))
+	flag.BoolVar(&config.jsonOut, "json", false, "Output in JSON format (same as -format json)")
+	flag.BoolVar(&config.htmlOut, "html", false, "Output in HTML format (same as -format html)")
+	flag.BoolVar(&config.markdown, "markdown", false, "Output in Markdown format for pull-request comments (same as -format markdown)")
+	flag.StringVar(&config.noteFile, "note", "", "Also write a Markdown summary for a merge-request note to this file")
+	flag.StringVar(&config.webhook, "webhook", "", "Also send a summary to this Slack or Microsoft Teams incoming webhook URL")
+	flag.StringVar(&config.webhookFormat, "webhook-format", "", "Payload for -webhook: slack or teams (default: detected from the URL)")
+	flag.StringVar(&config.templateFile, "template", "", "Render the output through this Go template instead of the built-in text or HTML output")
+	flag.StringVar(&config.sourceDir, "source-dir", "", "Directory of the Terraform configuration for SARIF locations (default: the directory of the plan file)")
+	flag.StringVar(&config.reportURL, "report-url", "", "Link to the full report in Slack and Teams summaries (default: the CI job, if known)")
+	flag.BoolVar(&config.attributeDiffs, "show-attributes", false, "Show the changed attribute values of each resource in Markdown output")
+	flag.Var(&config.planFiles, "plan", "Terraform JSON plan file, directory or glob, optionally as label=path (repeatable, default: stdin)")
 	flag.StringVar(&config.outputFile, "output", "", "Output file (default: stdout)")
 	flag.BoolVar(&config.verbose, "verbose", false, "Show verbose output")
+	flag.StringVar(&config.sortBy, "sort", "type", "Order resources by: type (grouped by resource type) or risk (highest risk first)")
+	flag.StringVar(&config.include, "include", "", "Only show resources matching these address globs (comma-separated)")
+	flag.StringVar(&config.exclude, "exclude", "", "Hide resources matching these address globs (comma-separated)")
+	flag.StringVar(&config.actions, "action", "", "Only show these actions: create,update,destroy,replace (comma-separated)")
+	flag.StringVar(&config.types, "type", "", "Only show these resource types, globs allowed (comma-separated)")
+	flag.StringVar(&config.modules, "module", "", "Only show resources in these modules, use 'root' for the root module (comma-separated)")
+	flag.StringVar(&config.providers, "provider", "", "Only show resources from these providers, e.g. aws (comma-separated)")
+	flag.StringVar(&config.changed, "changed-attr", "", "Only show resources that change one of these attribute paths, e.g. tags.Owner (comma-separated)")
+	flag.StringVar(&config.where, "where", "", "Only show resources matching a query expression, e.g. 'action == \"destroy\" && type =~ \"aws_db_.*\"'")
+	flag.StringVar(&config.ignoreFile, "ignore-file", "", "Ignore file (default: "+ignore.FileName+" in the working directory or a parent)")
+	flag.BoolVar(&config.noIgnore, "no-ignore", false, "Do not apply any ignore file")
+	flag.StringVar(&config.configFile, "config", "", "Project config file (default: .tfplanfilter.yml in the working directory or a parent)")
+	flag.StringVar(&config.pricing, "pricing", "", "Pricing file for estimating the monthly cost impact (YAML or JSON)")
+	flag.StringVar(&config.baseline, "baseline", "", "Saved JSON output whose changes are acknowledged, so only new changes are reported")
+	flag.StringVar(&config.policyFile, "policy", "", "Policy file with rules, severities and exemptions (YAML or JSON)")
+	flag.Var(&config.failOn, "fail-on", "Fail on policy rule: destroy[:patterns], replace[:patterns], max-changes:N, where:EXPR or tags:KEYS (repeatable)")
+	flag.BoolVar(&config.noGitHubActions, "no-github-actions", false, "Do not publish a step summary, annotations and outputs when running in GitHub Actions")
 	flag.BoolVar(&showVersion, "version", false, "Show version information")
 	flag.Parse()
 
@@ -89,6 +367,16 @@ func parseCommandLineFlags() Config {
 		os.Exit(0)
 	}
 
+	// The format flags are shortcuts for -format
+	switch {
+	case config.jsonOut:
+		config.format = "json"
+	case config.htmlOut:
+		config.format = "html"
+	case config.markdown:
+		config.format = "markdown"
+	}
+
 	// Force no-color if environment variable is set
 	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
 		config.noColor = true
@@ -97,6 +385,74 @@ func parseCommandLineFlags() Config {
 	return config
 }
 
+// buildFilter creates the resource filter from the command-line configuration
+func buildFilter(config Config) (*filter.Filter, error) {
+	resourceFilter := &filter.Filter{
+		Include:   filter.SplitList(config.include),
+		Exclude:   filter.SplitList(config.exclude),
+		Types:     filter.SplitList(config.types),
+		Modules:   filter.SplitList(config.modules),
+		Providers: filter.SplitList(config.providers),
+		Changed:   filter.SplitList(config.changed),
+	}
+
+	if err := resourceFilter.ParseActions(filter.SplitList(config.actions)); err != nil {
+		return nil, fmt.Errorf("invalid -action value: %v", err)
+	}
+
+	if config.where != "" {
+		expr, err := query.Parse(config.where)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -where expression: %v", err)
+		}
+		resourceFilter.Where = expr
+	}
+
+	return resourceFilter, nil
+}
+
+// buildPolicy creates the policy from the -policy file and the -fail-on rules
+func buildPolicy(config Config) (*policy.Policy, error) {
+	resourcePolicy := &policy.Policy{}
+	if config.policyFile != "" {
+		filePolicy, err := policy.LoadFile(config.policyFile)
+		if err != nil {
+			return nil, err
+		}
+		resourcePolicy = filePolicy
+	}
+
+	var rules []policy.Rule
+	for _, spec := range config.failOn {
+		rule, err := policy.ParseRule(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -fail-on rule %q: %v", spec, err)
+		}
+		rules = append(rules, rule)
+	}
+	if err := resourcePolicy.Merge(rules); err != nil {
+		return nil, err
+	}
+
+	return resourcePolicy, nil
+}
+
+// loadPricing loads the pricing file given by flag, nil when cost estimation is off
+func loadPricing(path string) (*cost.PricingFile, error) {
+	if path == "" {
+		return nil, nil
+	}
+	return cost.LoadFile(path)
+}
+
+// loadBaseline loads the baseline given by flag, nil when every change is reported
+func loadBaseline(path string) (*baseline.File, error) {
+	if path == "" {
+		return nil, nil
+	}
+	return baseline.LoadFile(path)
+}
+
 // setupInputSource sets up the input source based on configuration
 func setupInputSource(planFile string) (*os.File, error) {
 	if planFile == "" {
@@ -117,9 +473,51 @@ func setupInputSource(planFile string) (*os.File, error) {
 	return inputFile, nil
 }
 
+// loadProjectConfig loads the config file given by flag or discovered from the working directory
+func loadProjectConfig(config Config) (*projectconfig.Config, error) {
+	configFile := config.configFile
+	if configFile == "" {
+		configFile = projectconfig.Discover(".")
+		if configFile == "" {
+			return &projectconfig.Config{}, nil
+		}
+	}
+
+	return projectconfig.LoadFile(configFile)
+}
+
+// setupParseOptions loads the ignore file given by flag or discovered from the working directory
+// and applies the attribute ignores from the project configuration
+func setupParseOptions(config Config, projectConfig *projectconfig.Config) (parser.Options, error) {
+	var opts parser.Options
+	if len(projectConfig.IgnoreAttributes) > 0 {
+		opts.Suppress = projectConfig.IgnoreAttributes
+	}
+
+	if config.noIgnore {
+		return opts, nil
+	}
+
+	ignoreFile := config.ignoreFile
+	if ignoreFile == "" {
+		ignoreFile = ignore.Discover(".")
+		if ignoreFile == "" {
+			return opts, nil
+		}
+	}
+
+	rules, err := ignore.LoadFile(ignoreFile)
+	if err != nil {
+		return opts, err
+	}
+	opts.Ignore = rules
+
+	return opts, nil
+}
+
 // parseTerraformPlan parses the Terraform plan and handles errors
-func parseTerraformPlan(inputFile *os.File) (*model.ResourceCollection, error) {
-	result, err := parser.ParseTerraformPlan(inputFile)
+func parseTerraformPlan(inputFile *os.File, opts parser.Options) (*model.ResourceCollection, error) {
+	result, err := parser.ParseTerraformPlanWithOptions(inputFile, opts)
 	if err != nil {
 		if strings.Contains(err.Error(), "input appears to be text format") {
 			return nil, fmt.Errorf("error: %v\n\nThis tool now only supports JSON-formatted Terraform plans.\nPlease use the following commands:\n  terraform plan -out=tfplan\n  terraform show -json tfplan | terraform-plan-filter", err)
@@ -146,20 +544,38 @@ func setupOutputDestination(outputFile string) (*os.File, error) {
 // generateAndWriteOutput generates the formatted output and writes it
 func generateAndWriteOutput(result *model.ResourceCollection, outputWriter *os.File, config Config) error {
 	// Configure formatter options
-	opts := formatter.Options{
-		UseColors: !config.noColor,
-		Verbose:   config.verbose,
-	}
+	opts := formatterOptions(config)
 
 	// Format output based on requested format
 	var output string
 	var err error
 
-	if config.jsonOut {
+	switch {
+	case config.template != nil:
+		output, err = formatter.FormatTemplate(result, config.template)
+	case config.format == "json":
 		output, err = formatter.FormatJSON(result)
-	} else if config.htmlOut {
-		output, err = formatter.FormatHTML(result)
-	} else {
+	case config.format == "html":
+		output, err = formatter.FormatHTML(result, opts)
+	case config.format == "markdown":
+		output, err = formatter.FormatMarkdown(result, opts)
+	case config.format == "gitlab":
+		output, err = formatter.FormatGitLab(result)
+	case config.format == "slack":
+		output, err = formatter.FormatSlack(result, opts)
+	case config.format == "teams":
+		output, err = formatter.FormatTeams(result, opts)
+	case config.format == "junit":
+		output, err = formatter.FormatJUnit(result)
+	case config.format == "sarif":
+		output, err = formatter.FormatSARIF(result)
+	case config.format == "csv":
+		output, err = formatter.FormatCSV(result)
+	case config.format == "tsv":
+		output, err = formatter.FormatTSV(result)
+	case config.format == "yaml":
+		output, err = formatter.FormatYAML(result)
+	default:
 		output, err = formatter.FormatText(result, opts)
 	}
 
@@ -167,7 +583,59 @@ func generateAndWriteOutput(result *model.ResourceCollection, outputWriter *os.F
 		return fmt.Errorf("error formatting output: %v", err)
 	}
 
-	// Write the output
+	return writeOutput(output, outputWriter)
+}
+
+// sourceDir returns the directory of the Terraform configuration: the -source-dir flag, or the
+// directory of the plan file, assuming the plan was saved next to the configuration
+func sourceDir(config Config, planFile string) string {
+	if config.sourceDir != "" {
+		return config.sourceDir
+	}
+	if planFile == "" {
+		return "."
+	}
+	return filepath.Dir(planFile)
+}
+
+// outputFormats lists the values accepted by -format
+var outputFormats = []string{"text", "json", "html", "markdown", "gitlab", "slack", "teams", "junit", "sarif", "csv", "tsv", "yaml"}
+
+// isOutputFormat checks if a -format value is supported
+func isOutputFormat(format string) bool {
+	for _, f := range outputFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// writeNote writes a Markdown note to a file
+func writeNote(path string, format func() (string, error)) error {
+	note, err := format()
+	if err != nil {
+		return fmt.Errorf("error formatting note: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(note), 0o644); err != nil {
+		return fmt.Errorf("error writing note: %v", err)
+	}
+	return nil
+}
+
+// formatterOptions returns the formatter options for the command-line configuration
+func formatterOptions(config Config) formatter.Options {
+	return formatter.Options{
+		UseColors:      !config.noColor,
+		Verbose:        config.verbose,
+		SortByRisk:     config.sortBy == "risk",
+		AttributeDiffs: config.attributeDiffs,
+		ReportURL:      firstNonEmpty(config.reportURL, ciJobURL(os.Getenv)),
+	}
+}
+
+// writeOutput writes formatted output through a buffered writer
+func writeOutput(output string, outputWriter *os.File) error {
 	writer := bufio.NewWriter(outputWriter)
 	if _, err := writer.WriteString(output); err != nil {
 		return fmt.Errorf("error writing output: %v", err)
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/actions"
+	"github.com/marc-poljak/terraform-plan-filter/internal/baseline"
+	"github.com/marc-poljak/terraform-plan-filter/internal/formatter"
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+	"github.com/marc-poljak/terraform-plan-filter/internal/parser"
+	"github.com/marc-poljak/terraform-plan-filter/internal/policy"
+	"github.com/marc-poljak/terraform-plan-filter/internal/source"
+	"github.com/marc-poljak/terraform-plan-filter/internal/stack"
+	"github.com/marc-poljak/terraform-plan-filter/internal/webhook"
+)
+
+// runStacks parses the plans of several stacks concurrently and writes one report grouped
+// by stack. Stacks whose plans fail to parse are reported without stopping the others.
+func runStacks(inputs []stack.Input, analyzer *planAnalyzer, baselineFile *baseline.File, parseOpts parser.Options, config Config) {
+	// Match the baseline to each stack by name
+	baselines := map[string]baseline.Baseline{}
+	for _, input := range inputs {
+		acknowledged, err := baselineFile.Stack(input.Name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		baselines[input.Name] = acknowledged
+	}
+
+	report := stack.Load(inputs, runtime.NumCPU(), func(input stack.Input) (*model.ResourceCollection, error) {
+		result, err := parsePlanFile(input.Path, parseOpts)
+		if err != nil {
+			return nil, err
+		}
+		stackAnalyzer := *analyzer
+		stackAnalyzer.baseline = baselines[input.Name]
+		result = stackAnalyzer.analyze(result)
+		if config.format == "sarif" {
+			source.Locate(result, sourceDir(config, input.Path))
+		}
+		return result, nil
+	})
+
+	opts := formatterOptions(config)
+
+	var output string
+	var err error
+	switch {
+	case config.template != nil:
+		output, err = formatter.FormatStacksTemplate(report, config.template)
+	case config.format == "json":
+		output, err = formatter.FormatStacksJSON(report)
+	case config.format == "html":
+		output, err = formatter.FormatStacksHTML(report, opts)
+	case config.format == "markdown":
+		output, err = formatter.FormatStacksMarkdown(report, opts)
+	case config.format == "gitlab":
+		output, err = formatter.FormatStacksGitLab(report)
+	case config.format == "slack":
+		output, err = formatter.FormatStacksSlack(report, opts)
+	case config.format == "teams":
+		output, err = formatter.FormatStacksTeams(report, opts)
+	case config.format == "junit":
+		output, err = formatter.FormatStacksJUnit(report)
+	case config.format == "sarif":
+		output, err = formatter.FormatStacksSARIF(report)
+	case config.format == "csv":
+		output, err = formatter.FormatStacksCSV(report)
+	case config.format == "tsv":
+		output, err = formatter.FormatStacksTSV(report)
+	case config.format == "yaml":
+		output, err = formatter.FormatStacksYAML(report)
+	default:
+		output, err = formatter.FormatStacksText(report, opts)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error formatting output: %v\n", err)
+		os.Exit(1)
+	}
+
+	outputWriter, err := setupOutputDestination(config.outputFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	if outputWriter != os.Stdout {
+		defer safeClose(outputWriter, "output file")
+	}
+
+	if err := writeOutput(output, outputWriter); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	// Write the Markdown note for the merge request if requested
+	if config.noteFile != "" {
+		if err := writeNote(config.noteFile, func() (string, error) { return formatter.FormatStacksMarkdown(report, opts) }); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Send the summary to the chat webhook if requested
+	if config.webhook != "" {
+		err := sendWebhook(config, func(opts formatter.Options) (string, error) {
+			if config.webhookFormat == webhook.FormatTeams {
+				return formatter.FormatStacksTeams(report, opts)
+			}
+			return formatter.FormatStacksSlack(report, opts)
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Publish the summary, annotations and step outputs when running in GitHub Actions
+	if !config.noGitHubActions && actions.Detect(os.Getenv) {
+		publishStacksGitHubActions(report, config)
+	}
+
+	// Report failed stacks and policy violations on stderr unless the built-in text report already
+	// lists them, and fail with the matching exit code
+	if config.format != "text" || config.template != nil || outputWriter != os.Stdout {
+		for _, failed := range report.Failed() {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", failed.Name, failed.Err)
+		}
+		for _, s := range report.Stacks {
+			if s.Resources != nil && len(s.Resources.Violations) > 0 {
+				fmt.Fprintf(os.Stderr, "%s:\n%s", s.Name, formatter.FormatViolationsText(s.Resources.Violations, opts))
+			}
+		}
+	}
+	if code := policy.ExitCode(report.Violations()); code != 0 {
+		os.Exit(code)
+	}
+	if len(report.Failed()) > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/formatter"
+)
+
+// loadTemplate reads and parses the -template file. HTML templates are chosen with
+// -format html; the template replaces the built-in output of the format.
+func loadTemplate(config Config) (*formatter.Template, error) {
+	if config.format != "text" && config.format != "html" {
+		return nil, fmt.Errorf("-template works with -format text or html, not %s", config.format)
+	}
+
+	text, err := os.ReadFile(config.templateFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading template: %v", err)
+	}
+
+	tmpl, err := formatter.ParseTemplate(filepath.Base(config.templateFile), string(text), config.format == "html", formatterOptions(config))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing template: %v", err)
+	}
+	return tmpl, nil
+}
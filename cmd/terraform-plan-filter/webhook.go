@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/formatter"
+	"github.com/marc-poljak/terraform-plan-filter/internal/webhook"
+)
+
+// resolveWebhookFormat returns the payload format for -webhook, from -webhook-format or the URL
+func resolveWebhookFormat(config Config) (string, error) {
+	switch config.webhookFormat {
+	case webhook.FormatSlack, webhook.FormatTeams:
+		return config.webhookFormat, nil
+	case "":
+		if format := webhook.DetectFormat(config.webhook); format != "" {
+			return format, nil
+		}
+		return "", fmt.Errorf("cannot tell the payload format from the webhook URL, use -webhook-format slack or teams")
+	default:
+		return "", fmt.Errorf("invalid -webhook-format value %q (expected slack or teams)", config.webhookFormat)
+	}
+}
+
+// sendWebhook formats the summary for the chat webhook and sends it
+func sendWebhook(config Config, format func(formatter.Options) (string, error)) error {
+	payload, err := format(formatterOptions(config))
+	if err != nil {
+		return fmt.Errorf("error formatting webhook payload: %v", err)
+	}
+
+	return webhook.NewSender().Send(config.webhook, []byte(payload))
+}
+
+// ciJobURL returns the URL of the running GitHub Actions run or GitLab CI job, or an empty string
+func ciJobURL(getenv func(string) string) string {
+	if server, repo, run := getenv("GITHUB_SERVER_URL"), getenv("GITHUB_REPOSITORY"), getenv("GITHUB_RUN_ID"); server != "" && repo != "" && run != "" {
+		return fmt.Sprintf("%s/%s/actions/runs/%s", server, repo, run)
+	}
+	return getenv("CI_JOB_URL")
+}
@@ -2,4 +2,6 @@ module github.com/marc-poljak/terraform-plan-filter
 
 go 1.19
 
-// replace github.com/marc-poljak/terraform-plan-filter => ./
\ No newline at end of file
+// replace github.com/marc-poljak/terraform-plan-filter => ./
+
+require gopkg.in/yaml.v3 v3.0.1
@@ -0,0 +1,158 @@
+package actions
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+// Annotation levels of the workflow commands
+const (
+	LevelNotice  = "notice"
+	LevelWarning = "warning"
+	LevelError   = "error"
+)
+
+// Annotation is a message shown on the workflow run and the pull request
+type Annotation struct {
+	Level   string
+	Title   string
+	Message string
+}
+
+// Output is a step output readable by later steps as steps.<id>.outputs.<name>
+type Output struct {
+	Name  string
+	Value string
+}
+
+// Detect checks if the tool runs inside GitHub Actions
+func Detect(getenv func(string) string) bool {
+	return getenv("GITHUB_ACTIONS") == "true"
+}
+
+// Reporter publishes a plan summary to a GitHub Actions run
+type Reporter struct {
+	Getenv   func(string) string // os.Getenv, or a fake environment in tests
+	Commands io.Writer           // Where workflow commands are written for the runner to pick up
+}
+
+// Publish appends the Markdown summary to the step summary, writes the annotations as
+// workflow commands and sets the step outputs. Files the runner didn't provide are skipped.
+func (r *Reporter) Publish(summary string, annotations []Annotation, outputs []Output) error {
+	if path := r.Getenv("GITHUB_STEP_SUMMARY"); path != "" {
+		if err := appendFile(path, summary+"\n"); err != nil {
+			return fmt.Errorf("error writing step summary: %v", err)
+		}
+	}
+
+	for _, a := range annotations {
+		fmt.Fprintf(r.Commands, "::%s title=%s::%s\n", a.Level, escapeProperty(a.Title), escapeData(a.Message))
+	}
+
+	if path := r.Getenv("GITHUB_OUTPUT"); path != "" {
+		var sb strings.Builder
+		for _, o := range outputs {
+			fmt.Fprintf(&sb, "%s=%s\n", o.Name, o.Value)
+		}
+		if err := appendFile(path, sb.String()); err != nil {
+			return fmt.Errorf("error writing step outputs: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// Annotations returns a warning for every destroyed resource, an error for destroyed
+// stateful resources, and an annotation for every policy violation. The stack name,
+// if any, is added to the titles.
+func Annotations(stack string, resources *model.ResourceCollection) []Annotation {
+	var annotations []Annotation
+
+	suffix := ""
+	if stack != "" {
+		suffix = " in " + stack
+	}
+
+	for _, address := range resources.GetResourcesForAction(model.ActionDestroy) {
+		verb, title := "destroyed", "Terraform destroy"
+		if resources.GetResourceChange(address).IsReplacement() {
+			verb, title = "replaced", "Terraform replace"
+		}
+
+		level := LevelWarning
+		if resources.IsDangerous(address) {
+			level, title = LevelError, "Stateful resource "+verb
+		}
+		annotations = append(annotations, Annotation{
+			Level:   level,
+			Title:   title + suffix,
+			Message: fmt.Sprintf("%s will be %s", address, verb),
+		})
+	}
+
+	for _, v := range resources.Violations {
+		annotations = append(annotations, Annotation{
+			Level:   violationLevel(v),
+			Title:   fmt.Sprintf("Policy %s%s", v.Rule, suffix),
+			Message: v.Message,
+		})
+	}
+
+	return annotations
+}
+
+// violationLevel returns the annotation level for a policy violation
+func violationLevel(v model.PolicyViolation) string {
+	switch {
+	case v.IsBlocking():
+		return LevelError
+	case v.Exemption != nil && !v.Exemption.Expired:
+		return LevelNotice
+	case v.Severity == model.SeverityWarn || v.Severity == model.SeverityError:
+		return LevelWarning
+	default:
+		return LevelNotice
+	}
+}
+
+// Outputs returns the step outputs for the number of changes
+func Outputs(adds, changes, destroys int) []Output {
+	return []Output{
+		{"adds", strconv.Itoa(adds)},
+		{"changes", strconv.Itoa(changes)},
+		{"destroys", strconv.Itoa(destroys)},
+		{"has_changes", strconv.FormatBool(adds+changes+destroys > 0)},
+	}
+}
+
+// appendFile appends text to a file provided by the runner
+func appendFile(path, text string) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.WriteString(text); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// escapeData escapes the message of a workflow command
+func escapeData(s string) string {
+	s = strings.ReplaceAll(s, "%", "%25")
+	s = strings.ReplaceAll(s, "\r", "%0D")
+	return strings.ReplaceAll(s, "\n", "%0A")
+}
+
+// escapeProperty escapes a property value of a workflow command
+func escapeProperty(s string) string {
+	s = escapeData(s)
+	s = strings.ReplaceAll(s, ":", "%3A")
+	return strings.ReplaceAll(s, ",", "%2C")
+}
@@ -0,0 +1,100 @@
+package actions
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+// fakeEnvironment returns a getenv function reading from a map
+func fakeEnvironment(vars map[string]string) func(string) string {
+	return func(name string) string {
+		return vars[name]
+	}
+}
+
+func TestDetect(t *testing.T) {
+	if !Detect(fakeEnvironment(map[string]string{"GITHUB_ACTIONS": "true"})) {
+		t.Errorf("Expected GitHub Actions to be detected")
+	}
+	if Detect(fakeEnvironment(map[string]string{"CI": "true"})) {
+		t.Errorf("Expected other CI systems not to be detected as GitHub Actions")
+	}
+}
+
+func TestPublish(t *testing.T) {
+	dir := t.TempDir()
+	summaryFile := filepath.Join(dir, "summary.md")
+	outputFile := filepath.Join(dir, "output")
+	if err := os.WriteFile(outputFile, []byte("earlier=1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var commands strings.Builder
+	reporter := &Reporter{
+		Getenv:   fakeEnvironment(map[string]string{"GITHUB_STEP_SUMMARY": summaryFile, "GITHUB_OUTPUT": outputFile}),
+		Commands: &commands,
+	}
+	annotations := []Annotation{{Level: LevelError, Title: "Policy tags, in app: 100%", Message: "first line\nsecond line"}}
+
+	if err := reporter.Publish("## Summary", annotations, Outputs(1, 0, 2)); err != nil {
+		t.Fatalf("Publish returned an error: %v", err)
+	}
+
+	summary, _ := os.ReadFile(summaryFile)
+	if string(summary) != "## Summary\n" {
+		t.Errorf("Unexpected step summary %q", summary)
+	}
+
+	outputs, _ := os.ReadFile(outputFile)
+	expected := "earlier=1\nadds=1\nchanges=0\ndestroys=2\nhas_changes=true\n"
+	if string(outputs) != expected {
+		t.Errorf("Expected outputs %q, got %q", expected, outputs)
+	}
+
+	expected = "::error title=Policy tags%2C in app%3A 100%25::first line%0Asecond line\n"
+	if commands.String() != expected {
+		t.Errorf("Expected command %q, got %q", expected, commands.String())
+	}
+}
+
+func TestPublishOutsideRunner(t *testing.T) {
+	var commands strings.Builder
+	reporter := &Reporter{Getenv: fakeEnvironment(nil), Commands: &commands}
+
+	if err := reporter.Publish("## Summary", nil, Outputs(0, 0, 0)); err != nil {
+		t.Errorf("Expected missing runner files to be skipped, got %v", err)
+	}
+}
+
+func TestAnnotations(t *testing.T) {
+	resources := model.NewResourceCollection()
+	resources.AddResourceChange(&model.ResourceChange{Address: "aws_instance.old", Actions: []model.Action{model.ActionDestroy}})
+	resources.AddResourceChange(&model.ResourceChange{Address: "aws_db_instance.main", Actions: []model.Action{model.ActionDestroy, model.ActionCreate}})
+	resources.AddResourceChange(&model.ResourceChange{Address: "aws_s3_bucket.logs", Actions: []model.Action{model.ActionCreate}})
+	resources.Dangerous["aws_db_instance.main"] = struct{}{}
+	resources.Violations = []model.PolicyViolation{
+		{Rule: "tags", Severity: model.SeverityError, ExitCode: 8, Message: "aws_s3_bucket.logs is missing required tags: Owner"},
+		{Rule: "max-changes", Severity: model.SeverityWarn, Message: "3 changes exceed the maximum of 2"},
+	}
+
+	annotations := Annotations("prod", resources)
+
+	expected := []Annotation{
+		{LevelError, "Stateful resource replaced in prod", "aws_db_instance.main will be replaced"},
+		{LevelWarning, "Terraform destroy in prod", "aws_instance.old will be destroyed"},
+		{LevelError, "Policy tags in prod", "aws_s3_bucket.logs is missing required tags: Owner"},
+		{LevelWarning, "Policy max-changes in prod", "3 changes exceed the maximum of 2"},
+	}
+	if len(annotations) != len(expected) {
+		t.Fatalf("Expected %d annotations, got %+v", len(expected), annotations)
+	}
+	for i := range expected {
+		if annotations[i] != expected[i] {
+			t.Errorf("Annotation %d: expected %+v, got %+v", i, expected[i], annotations[i])
+		}
+	}
+}
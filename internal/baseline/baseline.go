@@ -0,0 +1,197 @@
+package baseline
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+// Baseline is a set of acknowledged changes, keyed by address with the action label
+// (create, update, destroy or replace) they were acknowledged for
+type Baseline map[string]string
+
+// File is a saved JSON output read as baseline, either of a single plan or of many
+// stacks with a baseline for each stack by name
+type File struct {
+	plan   Baseline
+	stacks map[string]Baseline
+}
+
+// snapshot is the part of the JSON output read as a baseline
+type snapshot struct {
+	Create       []string `json:"create"`
+	Update       []string `json:"update"`
+	Destroy      []string `json:"destroy"`
+	Acknowledged []struct {
+		Address string `json:"address"`
+		Action  string `json:"action"`
+	} `json:"acknowledged"`
+}
+
+// stacksSnapshot is the part of the JSON output for many stacks read as a baseline
+type stacksSnapshot struct {
+	Stacks []struct {
+		Name string `json:"name"`
+		snapshot
+	} `json:"stacks"`
+}
+
+// Parse reads a baseline from a saved JSON output of a single plan or of many stacks.
+// Documents without any of the lists of the JSON output, such as a Terraform plan, are
+// rejected.
+func Parse(reader io.Reader) (*File, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	if _, ok := fields["stacks"]; ok {
+		var s stacksSnapshot
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, err
+		}
+
+		file := &File{stacks: map[string]Baseline{}}
+		for _, stack := range s.Stacks {
+			if stack.Name == "" {
+				return nil, fmt.Errorf("stacks require a name")
+			}
+			baseline, err := stack.snapshot.baseline()
+			if err != nil {
+				return nil, fmt.Errorf("stack %s: %v", stack.Name, err)
+			}
+			file.stacks[stack.Name] = baseline
+		}
+		return file, nil
+	}
+
+	found := false
+	for _, key := range []string{"create", "update", "destroy", "acknowledged"} {
+		if _, ok := fields[key]; ok {
+			found = true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("not a saved JSON output of terraform-plan-filter (expected create, update, destroy, acknowledged or stacks)")
+	}
+
+	var s snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	baseline, err := s.baseline()
+	if err != nil {
+		return nil, err
+	}
+	return &File{plan: baseline}, nil
+}
+
+// baseline returns the changes of a snapshot. A resource listed both to create and to
+// destroy is acknowledged as a replacement.
+func (s snapshot) baseline() (Baseline, error) {
+	actions := map[string][]model.Action{}
+	for _, address := range s.Create {
+		actions[address] = append(actions[address], model.ActionCreate)
+	}
+	for _, address := range s.Update {
+		actions[address] = append(actions[address], model.ActionUpdate)
+	}
+	for _, address := range s.Destroy {
+		actions[address] = append(actions[address], model.ActionDestroy)
+	}
+
+	baseline := Baseline{}
+	for address, a := range actions {
+		change := model.ResourceChange{Address: address, Actions: a}
+		baseline[address] = change.ActionLabel()
+	}
+
+	// Changes acknowledged by the baseline the output was produced with stay acknowledged
+	for _, entry := range s.Acknowledged {
+		if entry.Address == "" || entry.Action == "" {
+			return nil, fmt.Errorf("acknowledged entries require an address and an action")
+		}
+		baseline[entry.Address] = entry.Action
+	}
+
+	return baseline, nil
+}
+
+// Plan returns the baseline of a single plan, nil without a baseline file. The output of
+// many stacks cannot be matched to a single plan.
+func (f *File) Plan() (Baseline, error) {
+	if f == nil {
+		return nil, nil
+	}
+	if f.stacks != nil {
+		return nil, fmt.Errorf("the baseline is the output of several stacks and cannot be applied to a single plan")
+	}
+	return f.plan, nil
+}
+
+// Stack returns the baseline of the named stack, empty when the stack is not in the
+// baseline and nil without a baseline file. The baseline of a single plan is refused, as
+// stacks often share addresses and it would acknowledge the changes of every stack.
+func (f *File) Stack(name string) (Baseline, error) {
+	if f == nil {
+		return nil, nil
+	}
+	if f.stacks == nil {
+		return nil, fmt.Errorf("the baseline is the output of a single plan; save the JSON output of all stacks as baseline for several plans")
+	}
+	if baseline, ok := f.stacks[name]; ok {
+		return baseline, nil
+	}
+	return Baseline{}, nil
+}
+
+// LoadFile reads a baseline file
+func LoadFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading baseline file: %v", err)
+	}
+
+	file, err := Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing baseline file %s: %v", path, err)
+	}
+
+	return file, nil
+}
+
+// Apply moves the changes already in the baseline out of the plan into the acknowledged
+// changes. A resource whose action differs from the baseline, e.g. an update that became
+// a replacement, is still reported. It returns the number of acknowledged changes.
+func (b Baseline) Apply(resources *model.ResourceCollection) int {
+	if !resources.HasDetailedResources {
+		return 0
+	}
+
+	count := 0
+	for _, address := range resources.Addresses() {
+		label, ok := b[address]
+		if !ok || label != resources.GetResourceChange(address).ActionLabel() {
+			continue
+		}
+		resources.Acknowledged[address] = resources.RemoveResource(address)
+		count++
+	}
+
+	if count > 0 {
+		resources.SummaryAdds = resources.CountResourcesForAction(model.ActionCreate)
+		resources.SummaryChanges = resources.CountResourcesForAction(model.ActionUpdate)
+		resources.SummaryDestroys = resources.CountResourcesForAction(model.ActionDestroy)
+	}
+
+	return count
+}
@@ -0,0 +1,146 @@
+package baseline
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+func TestParse(t *testing.T) {
+	input := `{
+  "create": ["aws_s3_bucket.logs", "aws_db_instance.main"],
+  "update": ["aws_instance.web"],
+  "destroy": ["aws_db_instance.main"],
+  "acknowledged": [{"address": "aws_iam_role.ci", "action": "destroy"}]
+}`
+
+	file, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Parse returned an error: %v", err)
+	}
+	baseline, err := file.Plan()
+	if err != nil {
+		t.Fatalf("Plan returned an error: %v", err)
+	}
+
+	expected := Baseline{
+		"aws_s3_bucket.logs":   "create",
+		"aws_db_instance.main": "replace",
+		"aws_instance.web":     "update",
+		"aws_iam_role.ci":      "destroy",
+	}
+	if len(baseline) != len(expected) {
+		t.Fatalf("Expected %d entries, got %v", len(expected), baseline)
+	}
+	for address, label := range expected {
+		if baseline[address] != label {
+			t.Errorf("Expected %s to be acknowledged as %s, got %q", address, label, baseline[address])
+		}
+	}
+}
+
+func TestParseStacks(t *testing.T) {
+	input := `{
+  "schema_version": 1,
+  "stacks": [
+    {"name": "prod", "path": "prod/plan.json", "create": ["aws_s3_bucket.logs"], "update": [], "destroy": []},
+    {"name": "dev", "path": "dev/plan.json", "error": "invalid plan"}
+  ]
+}`
+
+	file, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Parse returned an error: %v", err)
+	}
+
+	if prod, _ := file.Stack("prod"); prod["aws_s3_bucket.logs"] != "create" {
+		t.Errorf("Expected the prod bucket to be acknowledged, got %v", prod)
+	}
+	for _, name := range []string{"dev", "staging"} {
+		if acknowledged, err := file.Stack(name); err != nil || len(acknowledged) != 0 {
+			t.Errorf("Expected nothing acknowledged for %s, got %v (%v)", name, acknowledged, err)
+		}
+	}
+	if _, err := file.Plan(); err == nil {
+		t.Errorf("Expected an error applying the baseline of several stacks to a single plan")
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, input := range []string{
+		`not json`,
+		`[]`,
+		`{}`,
+		`{"format_version": "1.2", "resource_changes": []}`,
+		`{"acknowledged": [{"address": "aws_instance.web"}]}`,
+		`{"stacks": [{"create": ["aws_s3_bucket.logs"]}]}`,
+	} {
+		if _, err := Parse(strings.NewReader(input)); err == nil {
+			t.Errorf("Expected an error for %q", input)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	resources := model.NewResourceCollection()
+	resources.AddResourceChange(&model.ResourceChange{Address: "aws_instance.web", Actions: []model.Action{model.ActionUpdate}})
+	resources.AddResourceChange(&model.ResourceChange{Address: "aws_db_instance.main", Actions: []model.Action{model.ActionDestroy, model.ActionCreate}})
+	resources.AddResourceChange(&model.ResourceChange{Address: "aws_s3_bucket.new", Actions: []model.Action{model.ActionCreate}})
+	resources.HasDetailedResources = true
+	resources.FoundSummary = true
+	resources.SummaryAdds, resources.SummaryChanges, resources.SummaryDestroys = 2, 1, 1
+
+	// The database was only an update in the baseline, so its replacement is still new
+	baseline := Baseline{"aws_instance.web": "update", "aws_db_instance.main": "update"}
+
+	if count := baseline.Apply(resources); count != 1 {
+		t.Errorf("Expected 1 acknowledged change, got %d", count)
+	}
+	if _, ok := resources.Acknowledged["aws_instance.web"]; !ok {
+		t.Errorf("Expected aws_instance.web to be acknowledged")
+	}
+	if addresses := resources.Addresses(); len(addresses) != 2 {
+		t.Errorf("Expected 2 remaining resources, got %v", addresses)
+	}
+	if _, ok := resources.Changes["aws_instance.web"]; ok {
+		t.Errorf("Expected the acknowledged change to be removed from the plan changes")
+	}
+	if resources.TotalChanges() != 3 || resources.SummaryChanges != 0 {
+		t.Errorf("Expected the summary to be recounted, got %d changes and %d updates", resources.TotalChanges(), resources.SummaryChanges)
+	}
+}
+
+func TestStacksShareAddresses(t *testing.T) {
+	input := `{"stacks": [
+    {"name": "a", "create": ["aws_s3_bucket.logs"], "update": [], "destroy": []},
+    {"name": "b", "create": [], "update": [], "destroy": []}
+  ]}`
+
+	file, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Parse returned an error: %v", err)
+	}
+
+	for name, expected := range map[string]int{"a": 1, "b": 0} {
+		resources := model.NewResourceCollection()
+		resources.AddResourceChange(&model.ResourceChange{Address: "aws_s3_bucket.logs", Actions: []model.Action{model.ActionCreate}})
+		resources.HasDetailedResources = true
+
+		acknowledged, err := file.Stack(name)
+		if err != nil {
+			t.Fatalf("Stack returned an error: %v", err)
+		}
+		if count := acknowledged.Apply(resources); count != expected {
+			t.Errorf("Expected %d acknowledged changes in stack %s, got %d", expected, name, count)
+		}
+	}
+
+	single, err := Parse(strings.NewReader(`{"create": ["aws_s3_bucket.logs"]}`))
+	if err != nil {
+		t.Fatalf("Parse returned an error: %v", err)
+	}
+	if _, err := single.Stack("b"); err == nil {
+		t.Errorf("Expected an error applying the baseline of a single plan to a stack")
+	}
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+	"github.com/marc-poljak/terraform-plan-filter/internal/util"
+)
+
+// FileNames are the project configuration files discovered from the working directory, in order of preference
+var FileNames = []string{".tfplanfilter.yml", ".tfplanfilter.yaml", ".tfplanfilter.json"}
+
+// Config holds the project-level configuration
+type Config struct {
+	// IgnoreAttributes maps resource types (globs allowed) to attribute paths whose
+	// changes are not significant. Updates that only touch these paths are suppressed.
+	IgnoreAttributes AttributeIgnores `yaml:"ignore_attributes" json:"ignore_attributes"`
+
+	// StatefulResources extends or replaces the built-in catalogue of stateful resource
+	// types whose destruction is flagged as dangerous
+	StatefulResources StatefulResources `yaml:"stateful_resources" json:"stateful_resources"`
+
+	// RiskWeights overrides the built-in risk scoring weights and thresholds by name
+	RiskWeights map[string]int `yaml:"risk_weights" json:"risk_weights"`
+}
+
+// StatefulResources adjusts the catalogue of stateful resource types
+type StatefulResources struct {
+	Types      []string `yaml:"types" json:"types"`             // Additional stateful types, globs allowed
+	Exclude    []string `yaml:"exclude" json:"exclude"`         // Types never treated as stateful, globs allowed
+	NoDefaults bool     `yaml:"no_defaults" json:"no_defaults"` // Only use Types instead of extending the built-in catalogue
+}
+
+// AttributeIgnores maps resource type globs to attribute path patterns
+type AttributeIgnores map[string][]string
+
+// Parse reads a configuration document in YAML or JSON format
+func Parse(reader io.Reader) (*Config, error) {
+	var cfg Config
+
+	decoder := yaml.NewDecoder(reader)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&cfg); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// LoadFile reads the configuration from a file
+func LoadFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading config file: %v", err)
+	}
+
+	cfg, err := Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing config file %s: %v", path, err)
+	}
+
+	return cfg, nil
+}
+
+// Discover looks for a configuration file in dir and its parent directories.
+// It returns an empty string if none is found.
+func Discover(dir string) string {
+	return util.FindFileUpwards(dir, FileNames...)
+}
+
+// PathsForType returns the ignored attribute paths that apply to a resource type
+func (a AttributeIgnores) PathsForType(resourceType string) []string {
+	// Iterate in a stable order so results don't depend on map ordering
+	patterns := make([]string, 0, len(a))
+	for pattern := range a {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+
+	var paths []string
+	for _, pattern := range patterns {
+		if model.MatchGlob(pattern, resourceType) {
+			paths = append(paths, a[pattern]...)
+		}
+	}
+	return paths
+}
+
+// Suppresses checks if a change is an update that only touches ignored attributes
+func (a AttributeIgnores) Suppresses(change *model.ResourceChange) bool {
+	return change.OnlyChangesAttributes(a.PathsForType(change.Type))
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+func TestParseIgnoreAttributes(t *testing.T) {
+	yamlConfig := `
+ignore_attributes:
+  "*":
+    - tags.LastModified
+  aws_instance:
+    - tags_all.*
+    - ebs_block_device[*].throughput
+`
+
+	cfg, err := Parse(strings.NewReader(yamlConfig))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	paths := cfg.IgnoreAttributes.PathsForType("aws_instance")
+	expected := []string{"tags.LastModified", "tags_all.*", "ebs_block_device[*].throughput"}
+	if strings.Join(paths, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected paths %v, got %v", expected, paths)
+	}
+
+	if paths := cfg.IgnoreAttributes.PathsForType("aws_s3_bucket"); len(paths) != 1 {
+		t.Errorf("Expected only the wildcard paths for aws_s3_bucket, got %v", paths)
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	cfg, err := Parse(strings.NewReader(`{"ignore_attributes": {"aws_instance": ["tags.*"]}}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(cfg.IgnoreAttributes["aws_instance"]) != 1 {
+		t.Errorf("Expected one ignored path for aws_instance, got %v", cfg.IgnoreAttributes)
+	}
+}
+
+func TestParseUnknownField(t *testing.T) {
+	if _, err := Parse(strings.NewReader("ignore_attribute:\n  aws_instance: [tags]\n")); err == nil {
+		t.Errorf("Expected error for unknown field, but got none")
+	}
+}
+
+func TestSuppresses(t *testing.T) {
+	ignores := AttributeIgnores{
+		"aws_instance": {"tags.Last*", "ebs_block_device[*].throughput"},
+	}
+
+	update := func(before, after map[string]interface{}) *model.ResourceChange {
+		return &model.ResourceChange{
+			Address: "aws_instance.web", Type: "aws_instance", Name: "web",
+			Actions: []model.Action{model.ActionUpdate}, Before: before, After: after,
+		}
+	}
+
+	tests := []struct {
+		name     string
+		change   *model.ResourceChange
+		expected bool
+	}{
+		{
+			name: "Only wildcard tag changes",
+			change: update(
+				map[string]interface{}{"tags": map[string]interface{}{"LastModified": "a", "LastRun": "a"}},
+				map[string]interface{}{"tags": map[string]interface{}{"LastModified": "b", "LastRun": "b"}},
+			),
+			expected: true,
+		},
+		{
+			name: "Only list element changes",
+			change: update(
+				map[string]interface{}{"ebs_block_device": []interface{}{map[string]interface{}{"throughput": float64(125)}}},
+				map[string]interface{}{"ebs_block_device": []interface{}{map[string]interface{}{"throughput": float64(250)}}},
+			),
+			expected: true,
+		},
+		{
+			name: "Significant change as well",
+			change: update(
+				map[string]interface{}{"instance_type": "t3.micro", "tags": map[string]interface{}{"LastModified": "a"}},
+				map[string]interface{}{"instance_type": "m5.large", "tags": map[string]interface{}{"LastModified": "b"}},
+			),
+			expected: false,
+		},
+		{
+			name: "Other resource type",
+			change: &model.ResourceChange{
+				Address: "aws_s3_bucket.logs", Type: "aws_s3_bucket", Actions: []model.Action{model.ActionUpdate},
+				Before: map[string]interface{}{"tags": map[string]interface{}{"LastModified": "a"}},
+				After:  map[string]interface{}{"tags": map[string]interface{}{"LastModified": "b"}},
+			},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ignores.Suppresses(tt.change); got != tt.expected {
+				t.Errorf("Expected Suppresses = %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
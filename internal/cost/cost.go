@@ -0,0 +1,246 @@
+package cost
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+// PricingFile is a locally maintained table of monthly prices
+type PricingFile struct {
+	Currency  string                   `yaml:"currency" json:"currency"`
+	Region    string                   `yaml:"region" json:"region"` // Region used when a resource doesn't name one
+	Resources map[string]ResourcePrice `yaml:"resources" json:"resources"`
+}
+
+// ResourcePrice describes how a resource type is priced. The monthly price of a
+// resource is Monthly, plus the price selected by the Key attribute, plus the
+// quantity of each PerUnit attribute times its price.
+type ResourcePrice struct {
+	Monthly float64                       `yaml:"monthly" json:"monthly"`   // Fixed monthly price
+	Key     string                        `yaml:"key" json:"key"`           // Attribute selecting a price, e.g. instance_type
+	Prices  map[string]float64            `yaml:"prices" json:"prices"`     // Monthly price by Key value
+	PerUnit []UnitPrice                   `yaml:"per_unit" json:"per_unit"` // Prices per unit of numeric attributes
+	Regions map[string]RegionalPriceTable `yaml:"regions" json:"regions"`   // Region-specific prices overriding Prices
+}
+
+// UnitPrice is the monthly price per unit of a numeric attribute, e.g. per GB of storage
+type UnitPrice struct {
+	Attribute string  `yaml:"attribute" json:"attribute"`
+	Price     float64 `yaml:"price" json:"price"`
+}
+
+// RegionalPriceTable overrides the prices of a resource type in one region
+type RegionalPriceTable struct {
+	Monthly *float64           `yaml:"monthly" json:"monthly"`
+	Prices  map[string]float64 `yaml:"prices" json:"prices"`
+	PerUnit []UnitPrice        `yaml:"per_unit" json:"per_unit"`
+}
+
+// Parse reads a pricing file in YAML or JSON format
+func Parse(reader io.Reader) (*PricingFile, error) {
+	var pricing PricingFile
+
+	decoder := yaml.NewDecoder(reader)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&pricing); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	if pricing.Currency == "" {
+		pricing.Currency = "USD"
+	}
+	for resourceType, price := range pricing.Resources {
+		if len(price.Prices) > 0 && price.Key == "" {
+			return nil, fmt.Errorf("%s: prices require a key attribute", resourceType)
+		}
+	}
+
+	return &pricing, nil
+}
+
+// LoadFile reads a pricing file
+func LoadFile(path string) (*PricingFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading pricing file: %v", err)
+	}
+
+	pricing, err := Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing pricing file %s: %v", path, err)
+	}
+
+	return pricing, nil
+}
+
+// Estimate prices every resource change and returns the monthly cost impact of the plan.
+// Resources that can't be priced are listed as unpriced with the reason.
+func (p *PricingFile) Estimate(resources *model.ResourceCollection) *model.CostReport {
+	report := &model.CostReport{
+		Currency:  p.Currency,
+		Resources: map[string]model.ResourceCost{},
+	}
+
+	for _, address := range resources.Addresses() {
+		change, ok := resources.Changes[address]
+		if !ok {
+			report.Unpriced = append(report.Unpriced, model.UnpricedResource{Address: address, Reason: "no attribute values in the plan"})
+			continue
+		}
+
+		price, ok := p.priceFor(change.Type)
+		if !ok {
+			report.Unpriced = append(report.Unpriced, model.UnpricedResource{Address: address, Reason: "no price for " + change.Type})
+			continue
+		}
+
+		before, err := p.monthly(price, change.Before, nil)
+		if err == nil {
+			var after float64
+			after, err = p.monthly(price, change.After, change.AfterUnknown)
+			if err == nil {
+				report.Resources[address] = model.ResourceCost{Before: before, After: after}
+				report.MonthlyBefore += before
+				report.MonthlyAfter += after
+				continue
+			}
+		}
+		report.Unpriced = append(report.Unpriced, model.UnpricedResource{Address: address, Reason: err.Error()})
+	}
+
+	return report
+}
+
+// priceFor returns the price of a resource type, preferring an exact entry over glob entries
+func (p *PricingFile) priceFor(resourceType string) (ResourcePrice, bool) {
+	if price, ok := p.Resources[resourceType]; ok {
+		return price, true
+	}
+
+	patterns := make([]string, 0, len(p.Resources))
+	for pattern := range p.Resources {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+
+	for _, pattern := range patterns {
+		if model.MatchGlob(pattern, resourceType) {
+			return p.Resources[pattern], true
+		}
+	}
+	return ResourcePrice{}, false
+}
+
+// monthly computes the monthly price of a resource from its attribute values.
+// A nil value means the resource doesn't exist on that side of the change.
+func (p *PricingFile) monthly(price ResourcePrice, value, unknown interface{}) (float64, error) {
+	if value == nil {
+		return 0, nil
+	}
+
+	monthly, prices, perUnit := price.Monthly, price.Prices, price.PerUnit
+	if regional, ok := price.Regions[p.regionOf(value)]; ok {
+		if regional.Monthly != nil {
+			monthly = *regional.Monthly
+		}
+		if regional.Prices != nil {
+			prices = regional.Prices
+		}
+		if regional.PerUnit != nil {
+			perUnit = regional.PerUnit
+		}
+	}
+
+	total := monthly
+
+	if price.Key != "" {
+		if isUnknown(unknown, price.Key) {
+			return 0, fmt.Errorf("%s is only known after apply", price.Key)
+		}
+		key, _ := model.LookupAttribute(value, model.ParseAttributePath(price.Key))
+		keyPrice, ok := prices[fmt.Sprint(key)]
+		if !ok {
+			return 0, fmt.Errorf("no price for %s %v", price.Key, key)
+		}
+		total += keyPrice
+	}
+
+	for _, unit := range perUnit {
+		if isUnknown(unknown, unit.Attribute) {
+			return 0, fmt.Errorf("%s is only known after apply", unit.Attribute)
+		}
+		quantity, ok := numberAttribute(value, unit.Attribute)
+		if !ok {
+			continue
+		}
+		total += quantity * unit.Price
+	}
+
+	return total, nil
+}
+
+// regionOf returns the region of a resource from its region, location or zone attribute,
+// falling back to the region of the pricing file
+func (p *PricingFile) regionOf(value interface{}) string {
+	for _, attribute := range []string{"region", "location"} {
+		if region, ok := model.LookupAttribute(value, []string{attribute}); ok {
+			if s, ok := region.(string); ok && s != "" {
+				return s
+			}
+		}
+	}
+
+	for _, attribute := range []string{"zone", "availability_zone"} {
+		if zone, ok := model.LookupAttribute(value, []string{attribute}); ok {
+			if s, ok := zone.(string); ok && s != "" {
+				return regionFromZone(s)
+			}
+		}
+	}
+
+	return p.Region
+}
+
+// regionFromZone derives the region from a zone name: us-central1-a becomes
+// us-central1 and us-east-1a becomes us-east-1
+func regionFromZone(zone string) string {
+	if idx := strings.LastIndex(zone, "-"); idx >= 0 && len(zone)-idx == 2 {
+		return zone[:idx]
+	}
+	if n := len(zone); n >= 2 && zone[n-1] >= 'a' && zone[n-1] <= 'z' && zone[n-2] >= '0' && zone[n-2] <= '9' {
+		return zone[:n-1]
+	}
+	return zone
+}
+
+// numberAttribute returns a numeric attribute value, accepting numbers in strings
+func numberAttribute(value interface{}, path string) (float64, bool) {
+	v, ok := model.LookupAttribute(value, model.ParseAttributePath(path))
+	if !ok {
+		return 0, false
+	}
+
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// isUnknown checks if an attribute is only known after apply
+func isUnknown(unknown interface{}, path string) bool {
+	v, ok := model.LookupAttribute(unknown, model.ParseAttributePath(path))
+	return ok && v == true
+}
@@ -0,0 +1,133 @@
+package cost
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+const samplePricing = `
+currency: EUR
+region: us-east-1
+resources:
+  aws_instance:
+    key: instance_type
+    prices:
+      t3.micro: 8
+      m5.large: 70
+    regions:
+      eu-central-1:
+        prices:
+          m5.large: 80
+  aws_ebs_volume:
+    per_unit:
+      - attribute: size
+        price: 0.1
+  aws_nat_gateway:
+    monthly: 33
+  "aws_iam_*":
+    monthly: 0
+`
+
+func TestEstimate(t *testing.T) {
+	pricing, err := Parse(strings.NewReader(samplePricing))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	resources := model.NewResourceCollection()
+	resources.AddResourceChange(&model.ResourceChange{
+		Address: "aws_instance.web", Type: "aws_instance", Actions: []model.Action{model.ActionUpdate},
+		Before: map[string]interface{}{"instance_type": "t3.micro"},
+		After:  map[string]interface{}{"instance_type": "m5.large"},
+	})
+	resources.AddResourceChange(&model.ResourceChange{
+		Address: "aws_instance.eu", Type: "aws_instance", Actions: []model.Action{model.ActionCreate},
+		After: map[string]interface{}{"instance_type": "m5.large", "availability_zone": "eu-central-1a"},
+	})
+	resources.AddResourceChange(&model.ResourceChange{
+		Address: "aws_ebs_volume.data", Type: "aws_ebs_volume", Actions: []model.Action{model.ActionDestroy},
+		Before: map[string]interface{}{"size": 500.0},
+	})
+	resources.AddResourceChange(&model.ResourceChange{
+		Address: "aws_iam_role.ci", Type: "aws_iam_role", Actions: []model.Action{model.ActionCreate},
+		After: map[string]interface{}{"name": "ci"},
+	})
+	resources.AddResourceChange(&model.ResourceChange{
+		Address: "aws_instance.big", Type: "aws_instance", Actions: []model.Action{model.ActionCreate},
+		After: map[string]interface{}{"instance_type": "x2iedn.32xlarge"},
+	})
+	resources.AddResourceChange(&model.ResourceChange{
+		Address: "aws_lambda_function.api", Type: "aws_lambda_function", Actions: []model.Action{model.ActionCreate},
+		After: map[string]interface{}{},
+	})
+
+	report := pricing.Estimate(resources)
+
+	expected := map[string]float64{
+		"aws_instance.web":    62,
+		"aws_instance.eu":     80,
+		"aws_ebs_volume.data": -50,
+		"aws_iam_role.ci":     0,
+	}
+	for address, delta := range expected {
+		c, ok := report.Resources[address]
+		if !ok {
+			t.Errorf("Expected %s to be priced", address)
+			continue
+		}
+		if math.Abs(c.Delta()-delta) > 0.001 {
+			t.Errorf("Expected %s to change by %.2f, got %.2f", address, delta, c.Delta())
+		}
+	}
+
+	if report.Currency != "EUR" || math.Abs(report.Delta()-92) > 0.001 {
+		t.Errorf("Expected a plan delta of 92 EUR, got %.2f %s", report.Delta(), report.Currency)
+	}
+
+	if len(report.Unpriced) != 2 ||
+		report.Unpriced[0].Reason != "no price for instance_type x2iedn.32xlarge" ||
+		report.Unpriced[1].Reason != "no price for aws_lambda_function" {
+		t.Errorf("Unexpected unpriced resources: %+v", report.Unpriced)
+	}
+}
+
+func TestEstimateUnknownAttribute(t *testing.T) {
+	pricing, err := Parse(strings.NewReader(samplePricing))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	resources := model.NewResourceCollection()
+	resources.AddResourceChange(&model.ResourceChange{
+		Address: "aws_ebs_volume.new", Type: "aws_ebs_volume", Actions: []model.Action{model.ActionCreate},
+		After:        map[string]interface{}{},
+		AfterUnknown: map[string]interface{}{"size": true},
+	})
+
+	report := pricing.Estimate(resources)
+	if len(report.Unpriced) != 1 || report.Unpriced[0].Reason != "size is only known after apply" {
+		t.Errorf("Unexpected unpriced resources: %+v", report.Unpriced)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, source := range []string{
+		"resources:\n  aws_instance:\n    prices: {t3.micro: 8}\n",
+		"resources:\n  aws_instance:\n    price: 8\n",
+	} {
+		if _, err := Parse(strings.NewReader(source)); err == nil {
+			t.Errorf("Expected an error for:\n%s", source)
+		}
+	}
+}
+
+func TestRegionFromZone(t *testing.T) {
+	for zone, region := range map[string]string{"us-east-1a": "us-east-1", "us-central1-a": "us-central1", "westeurope": "westeurope"} {
+		if got := regionFromZone(zone); got != region {
+			t.Errorf("regionFromZone(%q) = %q, expected %q", zone, got, region)
+		}
+	}
+}
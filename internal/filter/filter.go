@@ -0,0 +1,230 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+	"github.com/marc-poljak/terraform-plan-filter/internal/query"
+)
+
+// Filter selects a subset of resources from a ResourceCollection
+type Filter struct {
+	Include   []string       // Address globs a resource must match (any of)
+	Exclude   []string       // Address globs that remove a resource (any of)
+	Actions   []model.Action // Actions to keep, all actions when empty
+	Replace   bool           // Keep replacements regardless of Actions
+	Types     []string       // Resource type globs to keep
+	Modules   []string       // Module addresses to keep, including nested modules
+	Providers []string       // Provider names to keep (e.g. aws or hashicorp/aws)
+	Where     *query.Expr    // Query expression a resource must match
+	Changed   []string       // Attribute paths of which at least one must change
+}
+
+// IsEmpty checks if the filter has no criteria and would keep every resource
+func (f *Filter) IsEmpty() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0 && len(f.Actions) == 0 && !f.Replace &&
+		len(f.Types) == 0 && len(f.Modules) == 0 && len(f.Providers) == 0 && f.Where == nil &&
+		len(f.Changed) == 0
+}
+
+// ParseActions parses a list of action names as accepted by the -action flag
+func (f *Filter) ParseActions(names []string) error {
+	for _, name := range names {
+		switch strings.ToLower(name) {
+		case "create", "add":
+			f.Actions = append(f.Actions, model.ActionCreate)
+		case "update", "change":
+			f.Actions = append(f.Actions, model.ActionUpdate)
+		case "destroy", "delete":
+			f.Actions = append(f.Actions, model.ActionDestroy)
+		case "replace":
+			f.Replace = true
+		default:
+			return fmt.Errorf("unknown action %q (expected create, update, destroy or replace)", name)
+		}
+	}
+	return nil
+}
+
+// Apply returns a new collection holding only the resources matched by the filter.
+// Summary counts are recalculated so totals reflect the filtered subset.
+func (f *Filter) Apply(resources *model.ResourceCollection) *model.ResourceCollection {
+	if f.IsEmpty() || !resources.HasDetailedResources {
+		return resources
+	}
+
+	filtered := model.NewResourceCollection()
+	addresses := resources.Addresses()
+
+	for _, address := range addresses {
+		change := resources.GetResourceChange(address)
+		if !f.matchesResource(change) {
+			continue
+		}
+
+		actions := f.selectActions(change)
+		if len(actions) == 0 {
+			continue
+		}
+
+		kept := *change
+		kept.Actions = actions
+		filtered.AddResourceChange(&kept)
+		if resources.IsDangerous(address) && kept.HasAction(model.ActionDestroy) {
+			filtered.Dangerous[address] = struct{}{}
+		}
+		if score, ok := resources.RiskScores[address]; ok {
+			filtered.RiskScores[address] = score
+		}
+	}
+
+	filtered.HasDetailedResources = true
+	filtered.FoundSummary = resources.FoundSummary
+	filtered.SummaryAdds = filtered.CountResourcesForAction(model.ActionCreate)
+	filtered.SummaryChanges = filtered.CountResourcesForAction(model.ActionUpdate)
+	filtered.SummaryDestroys = filtered.CountResourcesForAction(model.ActionDestroy)
+	filtered.HiddenResources = resources.HiddenResources + len(addresses) - len(filtered.Addresses())
+	filtered.IgnoredResources = resources.IgnoredResources
+	filtered.Suppressed = resources.Suppressed
+	filtered.Acknowledged = resources.Acknowledged
+	filtered.Violations = resources.Violations
+	filtered.SecurityFindings = resources.SecurityFindings
+	filtered.Costs = resources.Costs
+	filtered.RiskScore = resources.RiskScore
+	filtered.RiskLevel = resources.RiskLevel
+	filtered.ModuleDirs = resources.ModuleDirs
+	filtered.Locations = resources.Locations
+
+	return filtered
+}
+
+// Matches checks if the filter selects a resource change for at least one of its actions
+func (f *Filter) Matches(change *model.ResourceChange) bool {
+	return f.matchesResource(change) && len(f.selectActions(change)) > 0
+}
+
+// matchesResource checks the address, type, module, provider, query and attribute criteria
+func (f *Filter) matchesResource(change *model.ResourceChange) bool {
+	if len(f.Include) > 0 && !matchesAnyAddress(f.Include, change) {
+		return false
+	}
+
+	if matchesAnyAddress(f.Exclude, change) {
+		return false
+	}
+
+	if len(f.Types) > 0 && !model.MatchAnyGlob(f.Types, change.Type) {
+		return false
+	}
+
+	if len(f.Modules) > 0 && !matchesAnyModule(f.Modules, change.ModuleAddress) {
+		return false
+	}
+
+	if len(f.Providers) > 0 && !matchesAnyProvider(f.Providers, change.ProviderName) {
+		return false
+	}
+
+	if f.Where != nil && !f.Where.Match(change) {
+		return false
+	}
+
+	if len(f.Changed) > 0 && !changesAnyAttribute(f.Changed, change) {
+		return false
+	}
+
+	return true
+}
+
+// selectActions returns the actions of a change that the filter keeps
+func (f *Filter) selectActions(change *model.ResourceChange) []model.Action {
+	if len(f.Actions) == 0 && !f.Replace {
+		return change.Actions
+	}
+
+	if f.Replace && change.IsReplacement() {
+		return change.Actions
+	}
+
+	var actions []model.Action
+	for _, action := range change.Actions {
+		for _, wanted := range f.Actions {
+			if action == wanted {
+				actions = append(actions, action)
+				break
+			}
+		}
+	}
+	return actions
+}
+
+// matchesAnyAddress checks a change against address globs
+func matchesAnyAddress(patterns []string, change *model.ResourceChange) bool {
+	for _, pattern := range patterns {
+		if MatchAddress(pattern, change) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchAddress checks a change against an address glob.
+// A glob matches either the full address or the address relative to its module,
+// so "aws_iam_*" also selects IAM resources declared inside modules.
+func MatchAddress(pattern string, change *model.ResourceChange) bool {
+	relative := strings.TrimPrefix(change.Address, change.ModuleAddress+".")
+	return model.MatchGlob(pattern, change.Address) || model.MatchGlob(pattern, relative)
+}
+
+// changesAnyAttribute checks if a change touches any of the attribute paths
+func changesAnyAttribute(paths []string, change *model.ResourceChange) bool {
+	for _, path := range paths {
+		if change.AttributeChanged(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAnyModule checks if a module address equals or is nested in one of the modules.
+// The "root" module selects resources declared outside any module.
+func matchesAnyModule(modules []string, moduleAddress string) bool {
+	for _, module := range modules {
+		if module == "root" && moduleAddress == "" {
+			return true
+		}
+		if !strings.HasPrefix(module, "module.") {
+			module = "module." + module
+		}
+		if moduleAddress == module ||
+			strings.HasPrefix(moduleAddress, module+".") ||
+			strings.HasPrefix(moduleAddress, module+"[") ||
+			model.MatchGlob(module, moduleAddress) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAnyProvider checks a provider source address against short or full provider names
+func matchesAnyProvider(providers []string, providerName string) bool {
+	for _, provider := range providers {
+		if providerName == provider || strings.HasSuffix(providerName, "/"+provider) {
+			return true
+		}
+	}
+	return false
+}
+
+// SplitList splits a comma-separated flag value into trimmed, non-empty items
+func SplitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
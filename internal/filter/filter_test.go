@@ -0,0 +1,167 @@
+package filter
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+// createSampleCollection returns a collection with resources across modules and providers
+func createSampleCollection() *model.ResourceCollection {
+	resources := model.NewResourceCollection()
+	resources.FoundSummary = true
+
+	changes := []*model.ResourceChange{
+		{Address: "aws_iam_role.deploy", Type: "aws_iam_role", Name: "deploy",
+			ProviderName: "registry.terraform.io/hashicorp/aws", Actions: []model.Action{model.ActionCreate}},
+		{Address: "module.network.aws_vpc.main", ModuleAddress: "module.network", Type: "aws_vpc", Name: "main",
+			ProviderName: "registry.terraform.io/hashicorp/aws", Actions: []model.Action{model.ActionUpdate}},
+		{Address: "module.network.module.subnets.aws_subnet.private", ModuleAddress: "module.network.module.subnets",
+			Type: "aws_subnet", Name: "private", ProviderName: "registry.terraform.io/hashicorp/aws",
+			Actions: []model.Action{model.ActionDestroy, model.ActionCreate}},
+		{Address: "module.app.aws_iam_policy.app", ModuleAddress: "module.app", Type: "aws_iam_policy", Name: "app",
+			ProviderName: "registry.terraform.io/hashicorp/aws", Actions: []model.Action{model.ActionDestroy}},
+		{Address: "google_storage_bucket.assets", Type: "google_storage_bucket", Name: "assets",
+			ProviderName: "registry.terraform.io/hashicorp/google", Actions: []model.Action{model.ActionCreate}},
+	}
+
+	for _, change := range changes {
+		resources.AddResourceChange(change)
+	}
+	resources.SummaryAdds = resources.CountResourcesForAction(model.ActionCreate)
+	resources.SummaryChanges = resources.CountResourcesForAction(model.ActionUpdate)
+	resources.SummaryDestroys = resources.CountResourcesForAction(model.ActionDestroy)
+
+	return resources
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   Filter
+		actions  []string
+		expected []string
+	}{
+		{
+			name:     "Include module glob",
+			filter:   Filter{Include: []string{"module.network.*"}},
+			expected: []string{"module.network.aws_vpc.main", "module.network.module.subnets.aws_subnet.private"},
+		},
+		{
+			name:     "Include type glob matches inside modules",
+			filter:   Filter{Include: []string{"aws_iam_*"}},
+			expected: []string{"aws_iam_role.deploy", "module.app.aws_iam_policy.app"},
+		},
+		{
+			name:     "Exclude glob",
+			filter:   Filter{Exclude: []string{"module.*"}},
+			expected: []string{"aws_iam_role.deploy", "google_storage_bucket.assets"},
+		},
+		{
+			name:     "Action filter",
+			actions:  []string{"destroy"},
+			expected: []string{"module.app.aws_iam_policy.app", "module.network.module.subnets.aws_subnet.private"},
+		},
+		{
+			name:     "Replace action",
+			actions:  []string{"replace"},
+			expected: []string{"module.network.module.subnets.aws_subnet.private"},
+		},
+		{
+			name:     "Type filter",
+			filter:   Filter{Types: []string{"aws_vpc", "google_*"}},
+			expected: []string{"google_storage_bucket.assets", "module.network.aws_vpc.main"},
+		},
+		{
+			name:     "Module filter includes nested modules",
+			filter:   Filter{Modules: []string{"network"}},
+			expected: []string{"module.network.aws_vpc.main", "module.network.module.subnets.aws_subnet.private"},
+		},
+		{
+			name:     "Root module filter",
+			filter:   Filter{Modules: []string{"root"}},
+			expected: []string{"aws_iam_role.deploy", "google_storage_bucket.assets"},
+		},
+		{
+			name:     "Provider filter",
+			filter:   Filter{Providers: []string{"google"}},
+			expected: []string{"google_storage_bucket.assets"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := tt.filter
+			if err := f.ParseActions(tt.actions); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			result := f.Apply(createSampleCollection())
+			if got := result.Addresses(); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+
+			if hidden := 5 - len(tt.expected); result.HiddenResources != hidden {
+				t.Errorf("Expected %d hidden resources, got %d", hidden, result.HiddenResources)
+			}
+		})
+	}
+}
+
+func TestApplyRecalculatesSummary(t *testing.T) {
+	f := Filter{}
+	if err := f.ParseActions([]string{"create"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	result := f.Apply(createSampleCollection())
+
+	// The replacement keeps only its create half
+	if result.SummaryAdds != 3 || result.SummaryChanges != 0 || result.SummaryDestroys != 0 {
+		t.Errorf("Expected summary 3/0/0, got %d/%d/%d",
+			result.SummaryAdds, result.SummaryChanges, result.SummaryDestroys)
+	}
+
+	if result.TotalChanges() != 3 {
+		t.Errorf("Expected 3 total changes, got %d", result.TotalChanges())
+	}
+}
+
+func TestApplyEmptyFilter(t *testing.T) {
+	resources := createSampleCollection()
+	f := Filter{}
+
+	if result := f.Apply(resources); result != resources {
+		t.Errorf("Expected an empty filter to return the collection unchanged")
+	}
+}
+
+func TestParseActionsInvalid(t *testing.T) {
+	f := Filter{}
+	if err := f.ParseActions([]string{"explode"}); err == nil {
+		t.Errorf("Expected error for unknown action, but got none")
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		value    string
+		expected bool
+	}{
+		{"aws_iam_*", "aws_iam_role.deploy", true},
+		{"module.network.*", "module.network.aws_vpc.main", true},
+		{"module.network.*", "module.networking.aws_vpc.main", false},
+		{"aws_instance.web[?]", "aws_instance.web[0]", true},
+		{"*.main", "aws_vpc.main", true},
+		{"aws_vpc", "aws_vpc.main", false},
+		{"*", "", true},
+	}
+
+	for _, tt := range tests {
+		if got := model.MatchGlob(tt.pattern, tt.value); got != tt.expected {
+			t.Errorf("MatchGlob(%q, %q) = %v, expected %v", tt.pattern, tt.value, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package forge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Comment is a comment on a pull or merge request
+type Comment struct {
+	ID   int64
+	Body string
+}
+
+// Client reads and writes the comments of one pull or merge request
+type Client interface {
+	ListComments() ([]Comment, error)
+	CreateComment(body string) error
+	UpdateComment(id int64, body string) error
+}
+
+// Marker returns the hidden marker identifying the comments of this tool. The id tells
+// apart several summaries on the same request, e.g. one per workspace.
+func Marker(id string) string {
+	if id == "" {
+		return "<!-- terraform-plan-filter -->"
+	}
+	return fmt.Sprintf("<!-- terraform-plan-filter:%s -->", id)
+}
+
+// Upsert updates the comment carrying the marker, or creates one if there is none yet.
+// It reports whether a new comment was created.
+func Upsert(client Client, marker, body string) (bool, error) {
+	comments, err := client.ListComments()
+	if err != nil {
+		return false, fmt.Errorf("error listing comments: %v", err)
+	}
+
+	body = marker + "\n" + body
+	for _, comment := range comments {
+		if strings.Contains(comment.Body, marker) {
+			if err := client.UpdateComment(comment.ID, body); err != nil {
+				return false, fmt.Errorf("error updating comment %d: %v", comment.ID, err)
+			}
+			return false, nil
+		}
+	}
+
+	if err := client.CreateComment(body); err != nil {
+		return false, fmt.Errorf("error creating comment: %v", err)
+	}
+	return true, nil
+}
+
+// maxRetries is how often a rate-limited request is retried
+const maxRetries = 3
+
+// maxWait is the longest wait for a rate limit to reset
+const maxWait = 2 * time.Minute
+
+// api sends JSON requests to a forge, waiting and retrying when rate limited
+type api struct {
+	HTTP    *http.Client
+	Headers map[string]string   // Authentication and media type headers sent with every request
+	Sleep   func(time.Duration) // time.Sleep, replaced in tests
+	Now     func() time.Time    // time.Now, replaced in tests
+}
+
+// newAPI creates an api with the given headers and a request timeout
+func newAPI(headers map[string]string) *api {
+	return &api{HTTP: &http.Client{Timeout: 30 * time.Second}, Headers: headers, Sleep: time.Sleep, Now: time.Now}
+}
+
+// do sends a request with an optional JSON body and decodes a JSON response into out.
+// It returns the response headers for pagination.
+func (a *api) do(method, url string, in, out interface{}) (http.Header, error) {
+	var payload []byte
+	if in != nil {
+		var err error
+		if payload, err = json.Marshal(in); err != nil {
+			return nil, err
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequest(method, url, bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		for name, value := range a.Headers {
+			req.Header.Set(name, value)
+		}
+		if in != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		resp, err := a.HTTP.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		if wait, limited := a.rateLimitWait(resp); limited && attempt < maxRetries {
+			a.Sleep(wait)
+			continue
+		}
+		if resp.StatusCode >= 300 {
+			return nil, fmt.Errorf("%s %s: %s: %s", method, url, resp.Status, strings.TrimSpace(string(body)))
+		}
+
+		if out != nil {
+			if err := json.Unmarshal(body, out); err != nil {
+				return nil, fmt.Errorf("error decoding response of %s %s: %v", method, url, err)
+			}
+		}
+		return resp.Header, nil
+	}
+}
+
+// rateLimitWait checks if a response was rate limited and returns how long to wait,
+// from the Retry-After header or the time the rate limit resets
+func (a *api) rateLimitWait(resp *http.Response) (time.Duration, bool) {
+	limited := resp.StatusCode == http.StatusTooManyRequests ||
+		(resp.StatusCode == http.StatusForbidden && (resp.Header.Get("Retry-After") != "" || resp.Header.Get("X-RateLimit-Remaining") == "0"))
+	if !limited {
+		return 0, false
+	}
+
+	wait := time.Second
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		wait = time.Duration(seconds) * time.Second
+	} else if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		wait = time.Unix(reset, 0).Sub(a.Now())
+	}
+
+	if wait < 0 {
+		wait = 0
+	}
+	if wait > maxWait {
+		wait = maxWait
+	}
+	return wait, true
+}
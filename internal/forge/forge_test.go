@@ -0,0 +1,255 @@
+package forge
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeForge is a local stand-in for the comment API of GitHub or GitLab. It serves the
+// comments in pages of two and records the requests that change them.
+type fakeForge struct {
+	comments []Comment
+	created  []string
+	updated  map[int64]string
+	limited  int // Number of requests to answer with a rate limit before serving them
+	requests int
+}
+
+// page returns the comments of a page counted from one
+func (f *fakeForge) page(page int) ([]Comment, bool) {
+	start := (page - 1) * 2
+	if start >= len(f.comments) {
+		return nil, false
+	}
+	end := start + 2
+	if end > len(f.comments) {
+		end = len(f.comments)
+	}
+	return f.comments[start:end], end < len(f.comments)
+}
+
+// handle serves list, create and update requests, answering with a rate limit first if requested
+func (f *fakeForge) handle(w http.ResponseWriter, r *http.Request, nextPage func(int) (string, string), idFromPath func(string) (int64, bool)) {
+	f.requests++
+	if f.limited > 0 {
+		f.limited--
+		w.Header().Set("Retry-After", "7")
+		w.WriteHeader(http.StatusTooManyRequests)
+		return
+	}
+
+	var input struct {
+		Body string `json:"body"`
+	}
+	switch r.Method {
+	case "GET":
+		page := 1
+		fmt.Sscanf(r.URL.Query().Get("page"), "%d", &page)
+		comments, more := f.page(page)
+		if more {
+			name, value := nextPage(page + 1)
+			w.Header().Set(name, value)
+		}
+		if comments == nil {
+			comments = []Comment{}
+		}
+		out := make([]map[string]interface{}, 0, len(comments))
+		for _, c := range comments {
+			out = append(out, map[string]interface{}{"id": c.ID, "body": c.Body})
+		}
+		json.NewEncoder(w).Encode(out)
+	case "POST":
+		json.NewDecoder(r.Body).Decode(&input)
+		f.created = append(f.created, input.Body)
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"id": 100}`)
+	default:
+		id, ok := idFromPath(r.URL.Path)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&input)
+		f.updated[id] = input.Body
+		fmt.Fprintf(w, `{"id": %d}`, id)
+	}
+}
+
+// newGitHubServer starts a stand-in for the GitHub issue comments API of acme/infra#7
+func newGitHubServer(t *testing.T, f *fakeForge) *GitHub {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if !strings.HasPrefix(r.URL.Path, "/repos/acme/infra/issues/") {
+			http.NotFound(w, r)
+			return
+		}
+		f.handle(w, r, func(page int) (string, string) {
+			return "Link", fmt.Sprintf(`<%s/repos/acme/infra/issues/7/comments?per_page=100&page=%d>; rel="next", <%s/last>; rel="last"`, server.URL, page, server.URL)
+		}, func(path string) (int64, bool) {
+			var id int64
+			_, err := fmt.Sscanf(path, "/repos/acme/infra/issues/comments/%d", &id)
+			return id, err == nil
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewGitHub(server.URL, "acme/infra", 7, "secret")
+	client.Sleep = func(time.Duration) {}
+	return client
+}
+
+// newGitLabServer starts a stand-in for the GitLab notes API of merge request !7 of group/infra
+func newGitLabServer(t *testing.T, f *fakeForge) *GitLab {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if !strings.HasPrefix(r.URL.EscapedPath(), "/projects/group%2Finfra/merge_requests/7/notes") {
+			http.NotFound(w, r)
+			return
+		}
+		f.handle(w, r, func(page int) (string, string) {
+			return "X-Next-Page", fmt.Sprint(page)
+		}, func(path string) (int64, bool) {
+			var id int64
+			_, err := fmt.Sscanf(path, "/projects/group/infra/merge_requests/7/notes/%d", &id)
+			return id, err == nil
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewGitLab(server.URL, "group/infra", 7, "secret")
+	client.Sleep = func(time.Duration) {}
+	return client
+}
+
+// existingComments returns comments spread over three pages with the tool's comment on the second
+func existingComments() []Comment {
+	return []Comment{
+		{ID: 1, Body: "LGTM"},
+		{ID: 2, Body: "Please rebase"},
+		{ID: 3, Body: "Thanks"},
+		{ID: 4, Body: Marker("") + "\nold summary"},
+		{ID: 5, Body: "Ship it"},
+	}
+}
+
+func TestUpsertUpdatesExistingComment(t *testing.T) {
+	clients := map[string]func(*testing.T, *fakeForge) Client{
+		"github": func(t *testing.T, f *fakeForge) Client { return newGitHubServer(t, f) },
+		"gitlab": func(t *testing.T, f *fakeForge) Client { return newGitLabServer(t, f) },
+	}
+
+	for name, newClient := range clients {
+		t.Run(name, func(t *testing.T) {
+			f := &fakeForge{comments: existingComments(), updated: map[int64]string{}}
+
+			created, err := Upsert(newClient(t, f), Marker(""), "new summary")
+			if err != nil {
+				t.Fatalf("Upsert returned an error: %v", err)
+			}
+			if created || len(f.created) != 0 {
+				t.Errorf("Expected no new comment, got %v", f.created)
+			}
+			if f.updated[4] != Marker("")+"\nnew summary" {
+				t.Errorf("Expected comment 4 on the second page to be updated, got %v", f.updated)
+			}
+		})
+	}
+}
+
+func TestUpsertCreatesComment(t *testing.T) {
+	f := &fakeForge{comments: existingComments(), updated: map[int64]string{}}
+
+	// A summary with a different id must not replace the comment of the default one
+	created, err := Upsert(newGitHubServer(t, f), Marker("prod"), "prod summary")
+	if err != nil {
+		t.Fatalf("Upsert returned an error: %v", err)
+	}
+	if !created || len(f.created) != 1 || f.created[0] != "<!-- terraform-plan-filter:prod -->\nprod summary" {
+		t.Errorf("Expected one new comment, got %v", f.created)
+	}
+	if len(f.updated) != 0 {
+		t.Errorf("Expected no comment to be updated, got %v", f.updated)
+	}
+}
+
+func TestRateLimitRetried(t *testing.T) {
+	f := &fakeForge{updated: map[int64]string{}, limited: 2}
+	client := newGitLabServer(t, f)
+
+	var waits []time.Duration
+	client.Sleep = func(d time.Duration) { waits = append(waits, d) }
+
+	if _, err := Upsert(client, Marker(""), "summary"); err != nil {
+		t.Fatalf("Upsert returned an error: %v", err)
+	}
+	if len(waits) != 2 || waits[0] != 7*time.Second {
+		t.Errorf("Expected two waits of 7s, got %v", waits)
+	}
+	if len(f.created) != 1 {
+		t.Errorf("Expected the comment to be created after the rate limit, got %v", f.created)
+	}
+}
+
+func TestRateLimitGivesUp(t *testing.T) {
+	f := &fakeForge{updated: map[int64]string{}, limited: maxRetries + 1}
+
+	if _, err := Upsert(newGitHubServer(t, f), Marker(""), "summary"); err == nil {
+		t.Errorf("Expected an error once the retries are used up")
+	}
+	if f.requests != maxRetries+1 {
+		t.Errorf("Expected %d requests, got %d", maxRetries+1, f.requests)
+	}
+}
+
+func TestNewAPITimeout(t *testing.T) {
+	if timeout := newAPI(nil).HTTP.Timeout; timeout <= 0 {
+		t.Errorf("Expected requests to time out, got a timeout of %v", timeout)
+	}
+}
+
+func TestRateLimitWaitUntilReset(t *testing.T) {
+	now := time.Unix(1000, 0)
+	a := &api{Now: func() time.Time { return now }}
+
+	resp := &http.Response{StatusCode: http.StatusForbidden, Header: http.Header{}}
+	resp.Header.Set("X-RateLimit-Remaining", "0")
+	resp.Header.Set("X-RateLimit-Reset", "1030")
+	if wait, limited := a.rateLimitWait(resp); !limited || wait != 30*time.Second {
+		t.Errorf("Expected a wait of 30s, got %v (limited %v)", wait, limited)
+	}
+
+	resp.Header.Set("X-RateLimit-Reset", "99999")
+	if wait, _ := a.rateLimitWait(resp); wait != maxWait {
+		t.Errorf("Expected the wait to be capped at %v, got %v", maxWait, wait)
+	}
+
+	forbidden := &http.Response{StatusCode: http.StatusForbidden, Header: http.Header{}}
+	if _, limited := a.rateLimitWait(forbidden); limited {
+		t.Errorf("Expected a plain 403 not to be treated as a rate limit")
+	}
+}
+
+func TestPullRequestFromRef(t *testing.T) {
+	tests := map[string]int{
+		"refs/pull/42/merge": 42,
+		"refs/heads/main":    0,
+		"":                   0,
+	}
+	for ref, want := range tests {
+		if got := PullRequestFromRef(ref); got != want {
+			t.Errorf("PullRequestFromRef(%q) = %d, want %d", ref, got, want)
+		}
+	}
+}
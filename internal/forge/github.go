@@ -0,0 +1,94 @@
+package forge
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultGitHubURL is the API of github.com
+const DefaultGitHubURL = "https://api.github.com"
+
+// GitHub reads and writes the comments of a GitHub pull request
+type GitHub struct {
+	api
+	BaseURL string // API URL, DefaultGitHubURL or the API of a GitHub Enterprise server
+	Repo    string // owner/name
+	Number  int    // Pull request number
+}
+
+// NewGitHub creates a client for the comments of a pull request
+func NewGitHub(baseURL, repo string, number int, token string) *GitHub {
+	return &GitHub{
+		api: *newAPI(map[string]string{
+			"Authorization":        "Bearer " + token,
+			"Accept":               "application/vnd.github+json",
+			"X-GitHub-Api-Version": "2022-11-28",
+		}),
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		Repo:    repo,
+		Number:  number,
+	}
+}
+
+// githubComment is an issue comment in the GitHub API
+type githubComment struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+}
+
+// ListComments returns all comments of the pull request, following the pagination links
+func (g *GitHub) ListComments() ([]Comment, error) {
+	var comments []Comment
+
+	url := fmt.Sprintf("%s/repos/%s/issues/%d/comments?per_page=100", g.BaseURL, g.Repo, g.Number)
+	for url != "" {
+		var page []githubComment
+		header, err := g.do("GET", url, nil, &page)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range page {
+			comments = append(comments, Comment{ID: c.ID, Body: c.Body})
+		}
+		url = nextLink(header.Get("Link"))
+	}
+
+	return comments, nil
+}
+
+// CreateComment adds a comment to the pull request
+func (g *GitHub) CreateComment(body string) error {
+	url := fmt.Sprintf("%s/repos/%s/issues/%d/comments", g.BaseURL, g.Repo, g.Number)
+	_, err := g.do("POST", url, map[string]string{"body": body}, nil)
+	return err
+}
+
+// UpdateComment replaces the body of a comment
+func (g *GitHub) UpdateComment(id int64, body string) error {
+	url := fmt.Sprintf("%s/repos/%s/issues/comments/%d", g.BaseURL, g.Repo, id)
+	_, err := g.do("PATCH", url, map[string]string{"body": body}, nil)
+	return err
+}
+
+// linkPattern matches one entry of a Link header
+var linkPattern = regexp.MustCompile(`<([^>]+)>;\s*rel="([^"]+)"`)
+
+// nextLink returns the URL of the next page from a Link header, empty on the last page
+func nextLink(header string) string {
+	for _, match := range linkPattern.FindAllStringSubmatch(header, -1) {
+		if match[2] == "next" {
+			return match[1]
+		}
+	}
+	return ""
+}
+
+// PullRequestFromRef returns the pull request number of a GitHub Actions ref such as refs/pull/42/merge
+func PullRequestFromRef(ref string) int {
+	var number int
+	if _, err := fmt.Sscanf(ref, "refs/pull/%d/", &number); err != nil {
+		return 0
+	}
+	return number
+}
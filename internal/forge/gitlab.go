@@ -0,0 +1,71 @@
+package forge
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// DefaultGitLabURL is the API of gitlab.com
+const DefaultGitLabURL = "https://gitlab.com/api/v4"
+
+// GitLab reads and writes the notes of a GitLab merge request
+type GitLab struct {
+	api
+	BaseURL string // API URL, DefaultGitLabURL or the API of a self-managed instance
+	Project string // Project ID or path such as group/project
+	IID     int    // Merge request IID
+}
+
+// NewGitLab creates a client for the notes of a merge request
+func NewGitLab(baseURL, project string, iid int, token string) *GitLab {
+	return &GitLab{
+		api:     *newAPI(map[string]string{"PRIVATE-TOKEN": token}),
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		Project: project,
+		IID:     iid,
+	}
+}
+
+// gitlabNote is a merge request note in the GitLab API
+type gitlabNote struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+}
+
+// notesURL returns the URL of the notes of the merge request
+func (g *GitLab) notesURL() string {
+	return fmt.Sprintf("%s/projects/%s/merge_requests/%d/notes", g.BaseURL, url.PathEscape(g.Project), g.IID)
+}
+
+// ListComments returns all notes of the merge request, following the X-Next-Page header
+func (g *GitLab) ListComments() ([]Comment, error) {
+	var comments []Comment
+
+	page := "1"
+	for page != "" {
+		var notes []gitlabNote
+		header, err := g.do("GET", g.notesURL()+"?per_page=100&page="+page, nil, &notes)
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range notes {
+			comments = append(comments, Comment{ID: n.ID, Body: n.Body})
+		}
+		page = header.Get("X-Next-Page")
+	}
+
+	return comments, nil
+}
+
+// CreateComment adds a note to the merge request
+func (g *GitLab) CreateComment(body string) error {
+	_, err := g.do("POST", g.notesURL(), map[string]string{"body": body}, nil)
+	return err
+}
+
+// UpdateComment replaces the body of a note
+func (g *GitLab) UpdateComment(id int64, body string) error {
+	_, err := g.do("PUT", fmt.Sprintf("%s/%d", g.notesURL(), id), map[string]string{"body": body}, nil)
+	return err
+}
@@ -0,0 +1,103 @@
+package formatter
+
+import (
+	"encoding/csv"
+	"strconv"
+	"strings"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+// csvColumns are the columns of the CSV and TSV output, in order
+var csvColumns = []string{
+	"address", "module", "type", "name", "provider", "action", "actions",
+	"action_reason", "replace_paths", "risk", "changed_attributes",
+}
+
+// FormatCSV formats the resource changes as CSV with a header row and one row per resource
+func FormatCSV(resources *model.ResourceCollection) (string, error) {
+	return formatDelimited(',', []model.Stack{{Resources: resources}}, false)
+}
+
+// FormatTSV formats the resource changes as tab-separated values
+func FormatTSV(resources *model.ResourceCollection) (string, error) {
+	return formatDelimited('\t', []model.Stack{{Resources: resources}}, false)
+}
+
+// FormatStacksCSV formats the resource changes of many stacks as CSV, with the stack in the
+// first column. Stacks whose plans failed to parse have no rows.
+func FormatStacksCSV(report *model.StackReport) (string, error) {
+	return formatDelimited(',', report.Stacks, true)
+}
+
+// FormatStacksTSV formats the resource changes of many stacks as tab-separated values
+func FormatStacksTSV(report *model.StackReport) (string, error) {
+	return formatDelimited('\t', report.Stacks, true)
+}
+
+// formatDelimited writes the header and a row per resource change of the stacks, with the
+// stack name in front if requested. Fields with separators, quotes or line breaks are quoted
+// by encoding/csv.
+func formatDelimited(comma rune, stacks []model.Stack, withStack bool) (string, error) {
+	var sb strings.Builder
+	w := csv.NewWriter(&sb)
+	w.Comma = comma
+
+	header := csvColumns
+	if withStack {
+		header = append([]string{"stack"}, csvColumns...)
+	}
+	if err := w.Write(header); err != nil {
+		return "", err
+	}
+
+	for _, stack := range stacks {
+		if stack.Resources == nil {
+			continue
+		}
+		for _, address := range stack.Resources.Addresses() {
+			record := csvRecord(stack.Resources, address)
+			if withStack {
+				record = append([]string{stack.Name}, record...)
+			}
+			if err := w.Write(record); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// csvRecord returns the fields of a resource change in the order of csvColumns
+func csvRecord(resources *model.ResourceCollection, address string) []string {
+	change := resources.GetResourceChange(address)
+
+	actions := make([]string, 0, len(change.Actions))
+	for _, action := range change.Actions {
+		actions = append(actions, string(action))
+	}
+
+	risk := ""
+	if score, ok := resources.RiskScores[address]; ok {
+		risk = strconv.Itoa(score)
+	}
+
+	return []string{
+		address,
+		change.ModuleAddress,
+		change.Type,
+		change.Name,
+		change.ProviderName,
+		change.ActionLabel(),
+		strings.Join(actions, ";"),
+		change.ActionReason,
+		strings.Join(change.ReplacePaths, ";"),
+		risk,
+		strconv.Itoa(len(change.ChangedAttributes())),
+	}
+}
@@ -0,0 +1,83 @@
+package formatter
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+// csvPlan returns a plan with a replacement and a for_each key containing a comma and quotes
+func csvPlan() *model.ResourceCollection {
+	resources := model.NewResourceCollection()
+	resources.AddResourceChange(&model.ResourceChange{
+		Address:       `module.app.aws_s3_bucket.b["a,\"b\""]`,
+		ModuleAddress: "module.app",
+		Type:          "aws_s3_bucket",
+		Name:          "b",
+		ProviderName:  "registry.terraform.io/hashicorp/aws",
+		Actions:       []model.Action{model.ActionUpdate},
+		Before:        map[string]interface{}{"acl": "private", "tags": map[string]interface{}{"env": "dev"}},
+		After:         map[string]interface{}{"acl": "public-read", "tags": map[string]interface{}{"env": "prod"}},
+	})
+	resources.AddResourceChange(&model.ResourceChange{
+		Address:      "aws_instance.web",
+		Type:         "aws_instance",
+		Name:         "web",
+		ProviderName: "registry.terraform.io/hashicorp/aws",
+		Actions:      []model.Action{model.ActionDestroy, model.ActionCreate},
+		ActionReason: "replace_because_cannot_update",
+		ReplacePaths: []string{"ami", "ebs_block_device[0].volume_size"},
+	})
+	resources.RiskScores["aws_instance.web"] = 35
+	resources.HasDetailedResources = true
+	return resources
+}
+
+func TestFormatCSV(t *testing.T) {
+	result, err := FormatCSV(csvPlan())
+	if err != nil {
+		t.Fatalf("FormatCSV returned an error: %v", err)
+	}
+
+	expected := `address,module,type,name,provider,action,actions,action_reason,replace_paths,risk,changed_attributes
+aws_instance.web,,aws_instance,web,registry.terraform.io/hashicorp/aws,replace,destroy;create,replace_because_cannot_update,ami;ebs_block_device[0].volume_size,35,0
+"module.app.aws_s3_bucket.b[""a,\""b\""""]",module.app,aws_s3_bucket,b,registry.terraform.io/hashicorp/aws,update,update,,,,2
+`
+	if result != expected {
+		t.Errorf("Unexpected CSV output:\n%s\nwant:\n%s", result, expected)
+	}
+
+	// The quoted address reads back unchanged
+	records, err := csv.NewReader(strings.NewReader(result)).ReadAll()
+	if err != nil {
+		t.Fatalf("Expected valid CSV: %v", err)
+	}
+	if records[2][0] != `module.app.aws_s3_bucket.b["a,\"b\""]` {
+		t.Errorf("Expected the address to survive quoting, got %q", records[2][0])
+	}
+}
+
+func TestFormatStacksTSV(t *testing.T) {
+	stacks := &model.StackReport{Stacks: []model.Stack{
+		{Name: "prod", Resources: csvPlan()},
+		{Name: "dev"},
+	}}
+
+	result, err := FormatStacksTSV(stacks)
+	if err != nil {
+		t.Fatalf("FormatStacksTSV returned an error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(result, "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected a header and two rows, got:\n%s", result)
+	}
+	if !strings.HasPrefix(lines[0], "stack\taddress\tmodule\t") {
+		t.Errorf("Expected the stack column first, got %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "prod\taws_instance.web\t\taws_instance\t") {
+		t.Errorf("Unexpected row %q", lines[1])
+	}
+}
@@ -0,0 +1,223 @@
+package formatter
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+	"github.com/marc-poljak/terraform-plan-filter/internal/util"
+)
+
+// maxDiffValueLength is the length at which attribute values in a diff are truncated
+const maxDiffValueLength = 80
+
+// FormatDiffText formats the difference between two plans as colored text
+func FormatDiffText(diff *model.PlanDiff, opts Options) (string, error) {
+	var sb strings.Builder
+
+	sb.WriteString("\n")
+	sb.WriteString(util.BoldText("=== TERRAFORM PLAN DIFF ===", opts.UseColors))
+	sb.WriteString("\n")
+	if diff.OldSource != "" || diff.NewSource != "" {
+		fmt.Fprintf(&sb, "%s → %s\n", diff.OldSource, diff.NewSource)
+	}
+	sb.WriteString("\n")
+
+	if diff.IsEmpty() {
+		sb.WriteString("Both plans make the same changes.\n")
+		return sb.String(), nil
+	}
+
+	formatDiffSectionText(&sb, "NEW IN PLAN", diff.Added, util.ColorGreen, "+", opts)
+	formatDiffSectionText(&sb, "NO LONGER IN PLAN", diff.Removed, util.ColorRed, "-", opts)
+	formatDiffSectionText(&sb, "ACTION CHANGED", diff.ActionChanged, util.ColorYellow, "~", opts)
+	formatDiffSectionText(&sb, "ATTRIBUTE CHANGES", diff.AttributesChanged, util.ColorYellow, "~", opts)
+
+	return sb.String(), nil
+}
+
+// formatDiffSectionText formats one group of diff entries
+func formatDiffSectionText(sb *strings.Builder, label string, entries []model.DiffEntry, color, symbol string, opts Options) {
+	if len(entries) == 0 {
+		return
+	}
+
+	sb.WriteString(util.BoldText(fmt.Sprintf("%s (%d):", label, len(entries)), opts.UseColors))
+	sb.WriteString("\n")
+
+	for _, entry := range entries {
+		line := fmt.Sprintf("%s %s", symbol, entry.Address)
+		fmt.Fprintf(sb, "  %s (%s)\n", util.ColorizeText(line, color, opts.UseColors), diffActionLabel(entry))
+
+		for _, attribute := range entry.Attributes {
+			fmt.Fprintf(sb, "      %s\n", describeAttributeDiff(attribute))
+		}
+	}
+	sb.WriteString("\n")
+}
+
+// diffActionLabel describes the actions of a diff entry in both plans
+func diffActionLabel(entry model.DiffEntry) string {
+	switch {
+	case entry.OldAction == "":
+		return entry.NewAction
+	case entry.NewAction == "":
+		return "was " + entry.OldAction
+	case entry.OldAction != entry.NewAction:
+		return entry.OldAction + " → " + entry.NewAction
+	default:
+		return entry.NewAction
+	}
+}
+
+// describeAttributeDiff describes how the planned change of an attribute moved
+func describeAttributeDiff(d model.AttributeDiff) string {
+	switch d.Kind {
+	case model.AttributeNewlyChanged:
+		return fmt.Sprintf("+ %s: %s (newly changed)", d.Path, formatPlannedValue(d.NewValue, d.NewUnknown, d.NewSensitive))
+	case model.AttributeNoLongerChanged:
+		return fmt.Sprintf("- %s (no longer changed)", d.Path)
+	default:
+		return fmt.Sprintf("~ %s: %s → %s", d.Path, formatPlannedValue(d.OldValue, d.OldUnknown, d.OldSensitive),
+			formatPlannedValue(d.NewValue, d.NewUnknown, d.NewSensitive))
+	}
+}
+
+// formatPlannedValue formats the value a plan sets an attribute to, hiding sensitive values
+func formatPlannedValue(value interface{}, unknown, sensitive bool) string {
+	if sensitive && !unknown {
+		return model.SensitiveValue
+	}
+	return formatDiffValue(value, unknown)
+}
+
+// formatDiffValue formats a planned attribute value for display
+func formatDiffValue(value interface{}, unknown bool) string {
+	if unknown {
+		return "(known after apply)"
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+
+	text := string(data)
+	if len(text) > maxDiffValueLength {
+		text = text[:maxDiffValueLength-3] + "..."
+	}
+	return text
+}
+
+// jsonDiffEntry is the JSON representation of a diff entry
+type jsonDiffEntry struct {
+	Address    string              `json:"address"`
+	OldAction  string              `json:"old_action,omitempty"`
+	NewAction  string              `json:"new_action,omitempty"`
+	Attributes []jsonAttributeDiff `json:"attributes,omitempty"`
+}
+
+// jsonAttributeDiff is the JSON representation of an attribute difference
+type jsonAttributeDiff struct {
+	Path         string      `json:"path"`
+	Kind         string      `json:"kind"`
+	OldValue     interface{} `json:"old_value,omitempty"`
+	NewValue     interface{} `json:"new_value,omitempty"`
+	OldUnknown   bool        `json:"old_unknown,omitempty"`
+	NewUnknown   bool        `json:"new_unknown,omitempty"`
+	OldSensitive bool        `json:"old_sensitive,omitempty"`
+	NewSensitive bool        `json:"new_sensitive,omitempty"`
+}
+
+// FormatDiffJSON formats the difference between two plans as JSON
+func FormatDiffJSON(diff *model.PlanDiff) (string, error) {
+	type jsonOutput struct {
+		OldPlan           string          `json:"old_plan,omitempty"`
+		NewPlan           string          `json:"new_plan,omitempty"`
+		Added             []jsonDiffEntry `json:"added"`
+		Removed           []jsonDiffEntry `json:"removed"`
+		ActionChanged     []jsonDiffEntry `json:"action_changed"`
+		AttributesChanged []jsonDiffEntry `json:"attributes_changed"`
+		Identical         bool            `json:"identical"`
+		Timestamp         time.Time       `json:"timestamp"`
+	}
+
+	output := jsonOutput{
+		OldPlan:           diff.OldSource,
+		NewPlan:           diff.NewSource,
+		Added:             buildJSONDiffEntries(diff.Added),
+		Removed:           buildJSONDiffEntries(diff.Removed),
+		ActionChanged:     buildJSONDiffEntries(diff.ActionChanged),
+		AttributesChanged: buildJSONDiffEntries(diff.AttributesChanged),
+		Identical:         diff.IsEmpty(),
+		Timestamp:         time.Now(),
+	}
+
+	jsonBytes, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonBytes), nil
+}
+
+// buildJSONDiffEntries converts diff entries for JSON output
+func buildJSONDiffEntries(entries []model.DiffEntry) []jsonDiffEntry {
+	result := []jsonDiffEntry{}
+	for _, entry := range entries {
+		je := jsonDiffEntry{Address: entry.Address, OldAction: entry.OldAction, NewAction: entry.NewAction}
+		for _, a := range entry.Attributes {
+			je.Attributes = append(je.Attributes, jsonAttributeDiff(a))
+		}
+		result = append(result, je)
+	}
+	return result
+}
+
+// FormatDiffHTML formats the difference between two plans as HTML
+func FormatDiffHTML(diff *model.PlanDiff) (string, error) {
+	var sb strings.Builder
+
+	writeHTMLHeader(&sb)
+	sb.WriteString("    <h1>Terraform Plan Diff</h1>\n")
+	if diff.OldSource != "" || diff.NewSource != "" {
+		fmt.Fprintf(&sb, "    <div class=\"summary\"><p>%s → %s</p></div>\n",
+			html.EscapeString(diff.OldSource), html.EscapeString(diff.NewSource))
+	}
+
+	if diff.IsEmpty() {
+		sb.WriteString("    <p>Both plans make the same changes.</p>\n")
+	}
+
+	writeHTMLDiffSection(&sb, "New in plan", diff.Added, "create")
+	writeHTMLDiffSection(&sb, "No longer in plan", diff.Removed, "destroy")
+	writeHTMLDiffSection(&sb, "Action changed", diff.ActionChanged, "update")
+	writeHTMLDiffSection(&sb, "Attribute changes", diff.AttributesChanged, "update")
+
+	writeHTMLFooter(&sb)
+
+	return sb.String(), nil
+}
+
+// writeHTMLDiffSection writes one group of diff entries
+func writeHTMLDiffSection(sb *strings.Builder, title string, entries []model.DiffEntry, colorClass string) {
+	if len(entries) == 0 {
+		return
+	}
+
+	fmt.Fprintf(sb, "    <div class=\"action-group %s\">\n", colorClass)
+	fmt.Fprintf(sb, "        <h2>%s (%d)</h2>\n", title, len(entries))
+
+	for _, entry := range entries {
+		fmt.Fprintf(sb, "        <div class=\"resource\">%s (%s)</div>\n",
+			html.EscapeString(entry.Address), html.EscapeString(diffActionLabel(entry)))
+		for _, attribute := range entry.Attributes {
+			fmt.Fprintf(sb, "        <div class=\"attribute-diff\">%s</div>\n", html.EscapeString(describeAttributeDiff(attribute)))
+		}
+	}
+
+	sb.WriteString("    </div>\n")
+}
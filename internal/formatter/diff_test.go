@@ -0,0 +1,139 @@
+package formatter
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+// createSampleDiff returns a diff with an entry in every group
+func createSampleDiff() *model.PlanDiff {
+	return &model.PlanDiff{
+		OldSource:     "old.json",
+		NewSource:     "new.json",
+		Added:         []model.DiffEntry{{Address: "aws_s3_bucket.logs", NewAction: "create"}},
+		Removed:       []model.DiffEntry{{Address: "aws_iam_role.ci", OldAction: "create"}},
+		ActionChanged: []model.DiffEntry{{Address: "aws_db_instance.main", OldAction: "update", NewAction: "replace"}},
+		AttributesChanged: []model.DiffEntry{{
+			Address: "aws_instance.web", OldAction: "update", NewAction: "update",
+			Attributes: []model.AttributeDiff{
+				{Path: "ami", Kind: model.AttributeNoLongerChanged, OldValue: "b"},
+				{Path: "arn", Kind: model.AttributeNewlyChanged, NewUnknown: true},
+				{Path: "instance_type", Kind: model.AttributeValueChanged, OldValue: "m5.large", NewValue: "m6i.large"},
+			},
+		}},
+	}
+}
+
+func TestFormatDiffText(t *testing.T) {
+	result, err := FormatDiffText(createSampleDiff(), Options{})
+	if err != nil {
+		t.Fatalf("FormatDiffText returned error: %v", err)
+	}
+
+	expectedPhrases := []string{
+		"=== TERRAFORM PLAN DIFF ===",
+		"old.json → new.json",
+		"NEW IN PLAN (1):\n  + aws_s3_bucket.logs (create)",
+		"NO LONGER IN PLAN (1):\n  - aws_iam_role.ci (was create)",
+		"ACTION CHANGED (1):\n  ~ aws_db_instance.main (update → replace)",
+		"      - ami (no longer changed)",
+		"      + arn: (known after apply) (newly changed)",
+		`      ~ instance_type: "m5.large" → "m6i.large"`,
+	}
+	for _, phrase := range expectedPhrases {
+		if !strings.Contains(result, phrase) {
+			t.Errorf("Expected output to contain %q, got:\n%s", phrase, result)
+		}
+	}
+
+	empty, err := FormatDiffText(&model.PlanDiff{}, Options{})
+	if err != nil {
+		t.Fatalf("FormatDiffText returned error: %v", err)
+	}
+	if !strings.Contains(empty, "Both plans make the same changes.") {
+		t.Errorf("Expected a note for identical plans, got:\n%s", empty)
+	}
+}
+
+func TestFormatDiffJSON(t *testing.T) {
+	result, err := FormatDiffJSON(createSampleDiff())
+	if err != nil {
+		t.Fatalf("FormatDiffJSON returned error: %v", err)
+	}
+
+	var parsed struct {
+		ActionChanged []struct {
+			OldAction string `json:"old_action"`
+			NewAction string `json:"new_action"`
+		} `json:"action_changed"`
+		AttributesChanged []struct {
+			Attributes []struct {
+				Path string `json:"path"`
+				Kind string `json:"kind"`
+			} `json:"attributes"`
+		} `json:"attributes_changed"`
+		Identical bool `json:"identical"`
+	}
+	if err := json.Unmarshal([]byte(result), &parsed); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v", err)
+	}
+
+	if len(parsed.ActionChanged) != 1 || parsed.ActionChanged[0].NewAction != "replace" {
+		t.Errorf("Unexpected action changes: %+v", parsed.ActionChanged)
+	}
+	if len(parsed.AttributesChanged) != 1 || len(parsed.AttributesChanged[0].Attributes) != 3 {
+		t.Errorf("Unexpected attribute changes: %+v", parsed.AttributesChanged)
+	}
+	if parsed.Identical {
+		t.Errorf("Expected the plans not to be identical")
+	}
+}
+
+func TestFormatDiffHTML(t *testing.T) {
+	result, err := FormatDiffHTML(createSampleDiff())
+	if err != nil {
+		t.Fatalf("FormatDiffHTML returned error: %v", err)
+	}
+
+	for _, phrase := range []string{"<h1>Terraform Plan Diff</h1>", "New in plan (1)", "update → replace", "&#34;m5.large&#34; → &#34;m6i.large&#34;"} {
+		if !strings.Contains(result, phrase) {
+			t.Errorf("Expected HTML output to contain %q", phrase)
+		}
+	}
+}
+
+func TestFormatDiffSensitive(t *testing.T) {
+	diff := &model.PlanDiff{AttributesChanged: []model.DiffEntry{{
+		Address: "aws_db_instance.main", OldAction: "update", NewAction: "update",
+		Attributes: []model.AttributeDiff{
+			{Path: "password", Kind: model.AttributeValueChanged, OldSensitive: true, NewSensitive: true},
+		},
+	}}}
+
+	text, err := FormatDiffText(diff, Options{})
+	if err != nil {
+		t.Fatalf("FormatDiffText returned an error: %v", err)
+	}
+	if !strings.Contains(text, "~ password: (sensitive) → (sensitive)") {
+		t.Errorf("Expected the sensitive change to be redacted, got:\n%s", text)
+	}
+
+	jsonOutput, err := FormatDiffJSON(diff)
+	if err != nil {
+		t.Fatalf("FormatDiffJSON returned an error: %v", err)
+	}
+	if !strings.Contains(jsonOutput, `"old_sensitive": true`) || strings.Contains(jsonOutput, "old_value") {
+		t.Errorf("Expected the JSON output to mark the value as sensitive without it, got:\n%s", jsonOutput)
+	}
+
+	htmlOutput, err := FormatDiffHTML(diff)
+	if err != nil {
+		t.Fatalf("FormatDiffHTML returned an error: %v", err)
+	}
+	if !strings.Contains(htmlOutput, "~ password: (sensitive) → (sensitive)") {
+		t.Errorf("Expected the HTML output to redact the sensitive change, got:\n%s", htmlOutput)
+	}
+}
@@ -3,18 +3,24 @@ package formatter
 import (
 	"encoding/json"
 	"fmt"
+	"html"
+	"math"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+	"github.com/marc-poljak/terraform-plan-filter/internal/risk"
 	"github.com/marc-poljak/terraform-plan-filter/internal/util"
 )
 
 // Options configures the output formatter
 type Options struct {
-	UseColors bool
-	Verbose   bool
+	UseColors      bool
+	Verbose        bool
+	SortByRisk     bool   // List resources by descending risk score instead of grouping them by type
+	AttributeDiffs bool   // Show the changed attribute values of each resource in Markdown output
+	ReportURL      string // Link to the full report in Slack and Teams notifications
 }
 
 // FormatText formats the resource collection as colored text
@@ -24,26 +30,51 @@ func FormatText(resources *model.ResourceCollection, opts Options) (string, erro
 	// Format the header
 	formatTextHeader(&sb, opts)
 
+	formatResourcesText(&sb, resources, opts)
+
+	return sb.String(), nil
+}
+
+// formatResourcesText formats the resources, totals and findings of one plan
+func formatResourcesText(sb *strings.Builder, resources *model.ResourceCollection, opts Options) {
+	// Warn about dangerous changes before the long resource lists
+	formatDangerWarningText(sb, resources, opts)
+
 	// If we have detailed resources, show them grouped by action then type
 	if resources.HasDetailedResources {
 		// Display resources for each action type in order
-		formatActionResourcesText(&sb, resources, model.ActionCreate, "RESOURCES TO CREATE", opts)
-		formatActionResourcesText(&sb, resources, model.ActionUpdate, "RESOURCES TO UPDATE", opts)
-		formatActionResourcesText(&sb, resources, model.ActionDestroy, "RESOURCES TO DESTROY", opts)
+		formatActionResourcesText(sb, resources, model.ActionCreate, "RESOURCES TO CREATE", opts)
+		formatActionResourcesText(sb, resources, model.ActionUpdate, "RESOURCES TO UPDATE", opts)
+		formatActionResourcesText(sb, resources, model.ActionDestroy, "RESOURCES TO DESTROY", opts)
+
+		// In verbose mode, list the updates that only touched ignored attributes
+		// and the changes acknowledged by the baseline
+		if opts.Verbose {
+			formatSuppressedResourcesText(sb, resources, opts)
+			formatAcknowledgedResourcesText(sb, resources, opts)
+		}
 	} else if resources.FoundSummary {
 		// No detailed resources, but we have a summary
-		formatSummaryOnlyText(&sb, resources, opts)
+		formatSummaryOnlyText(sb, resources, opts)
 	}
 
 	// Format total changes
-	formatTotalChangesText(&sb, resources, opts)
+	formatTotalChangesText(sb, resources, opts)
+
+	// Note how many resources were left out by ignore rules and filters
+	formatExcludedNotesText(sb, resources)
+
+	// Show the cost impact if it was estimated
+	formatCostText(sb, resources.Costs, opts)
 
 	// If we have a summary directly from the plan, show it
 	if resources.FoundSummary {
-		formatPlanSummaryText(&sb, resources, opts)
+		formatPlanSummaryText(sb, resources, opts)
 	}
 
-	return sb.String(), nil
+	// List the security findings and policy violations last so they stand out
+	formatSecurityFindingsText(sb, resources.SecurityFindings, opts)
+	sb.WriteString(FormatViolationsText(resources.Violations, opts))
 }
 
 // formatTextHeader adds the header section to the text output
@@ -82,15 +113,24 @@ func formatActionResourcesText(sb *strings.Builder, resources *model.ResourceCol
 	}
 	sb.WriteString("\n")
 
+	// With risk ordering, list the resources without grouping
+	if opts.SortByRisk {
+		for _, resource := range sortByRisk(resources, actionResources) {
+			formatResourceLineText(sb, resources, resource, color, symbol, opts)
+		}
+		sb.WriteString("\n")
+		return
+	}
+
 	// Handle module resources first if they exist
 	if hasModuleResources(typeMap) {
-		formatModuleResourcesText(sb, typeMap, color, symbol, opts)
+		formatModuleResourcesText(sb, resources, typeMap, color, symbol, opts)
 		// Filter out "module" from types since we've already processed it
 		types = filterOutModuleType(types)
 	}
 
 	// Format resources by type (excluding modules which we've already handled)
-	formatResourcesByTypeText(sb, types, typeMap, color, symbol, opts)
+	formatResourcesByTypeText(sb, resources, types, typeMap, color, symbol, opts)
 }
 
 // getSortedResourceTypes returns a sorted slice of resource types
@@ -121,7 +161,7 @@ func filterOutModuleType(types []string) []string {
 }
 
 // formatModuleResourcesText formats module resources
-func formatModuleResourcesText(sb *strings.Builder, typeMap map[string][]string, color, symbol string, opts Options) {
+func formatModuleResourcesText(sb *strings.Builder, resources *model.ResourceCollection, typeMap map[string][]string, color, symbol string, opts Options) {
 	moduleResources := typeMap["module"]
 
 	// Print type subheader
@@ -134,23 +174,18 @@ func formatModuleResourcesText(sb *strings.Builder, typeMap map[string][]string,
 
 	// Print module resources
 	for _, resource := range moduleResources {
-		if opts.UseColors {
-			fmt.Fprintf(sb, "    %s%s %s%s\n",
-				color, symbol, resource, util.ColorReset)
-		} else {
-			fmt.Fprintf(sb, "    %s %s\n", symbol, resource)
-		}
+		formatResourceLineText(sb, resources, resource, color, symbol, opts)
 	}
 	sb.WriteString("\n")
 }
 
 // formatResourcesByTypeText formats resources grouped by type
-func formatResourcesByTypeText(sb *strings.Builder, types []string, typeMap map[string][]string, color, symbol string, opts Options) {
+func formatResourcesByTypeText(sb *strings.Builder, resources *model.ResourceCollection, types []string, typeMap map[string][]string, color, symbol string, opts Options) {
 	for _, resourceType := range types {
-		resources := typeMap[resourceType]
+		typeResources := typeMap[resourceType]
 
 		// Skip empty resource types
-		if len(resources) == 0 {
+		if len(typeResources) == 0 {
 			continue
 		}
 
@@ -163,18 +198,110 @@ func formatResourcesByTypeText(sb *strings.Builder, types []string, typeMap map[
 		}
 
 		// Print resources of this type
-		for _, resource := range resources {
-			if opts.UseColors {
-				fmt.Fprintf(sb, "    %s%s %s%s\n",
-					color, symbol, resource, util.ColorReset)
-			} else {
-				fmt.Fprintf(sb, "    %s %s\n", symbol, resource)
-			}
+		for _, resource := range typeResources {
+			formatResourceLineText(sb, resources, resource, color, symbol, opts)
 		}
 		sb.WriteString("\n")
 	}
 }
 
+// formatResourceLineText formats a single resource line, marking dangerous changes
+func formatResourceLineText(sb *strings.Builder, resources *model.ResourceCollection, resource, color, symbol string, opts Options) {
+	if opts.UseColors {
+		fmt.Fprintf(sb, "    %s%s %s%s", color, symbol, resource, util.ColorReset)
+	} else {
+		fmt.Fprintf(sb, "    %s %s", symbol, resource)
+	}
+
+	if opts.SortByRisk {
+		fmt.Fprintf(sb, "  (risk %d)", resources.RiskScores[resource])
+	}
+	if resources.IsDangerous(resource) {
+		marker := util.BoldText(dangerMarker(resources, resource), opts.UseColors)
+		sb.WriteString("  " + util.ColorizeText(marker, util.ColorRed, opts.UseColors))
+	}
+	sb.WriteString("\n")
+}
+
+// sortByRisk returns the addresses ordered by descending risk score, then by address
+func sortByRisk(resources *model.ResourceCollection, addresses []string) []string {
+	sorted := append([]string(nil), addresses...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		si, sj := resources.RiskScores[sorted[i]], resources.RiskScores[sorted[j]]
+		if si != sj {
+			return si > sj
+		}
+		return sorted[i] < sorted[j]
+	})
+	return sorted
+}
+
+// dangerMarker describes why a resource change is dangerous
+func dangerMarker(resources *model.ResourceCollection, address string) string {
+	if resources.GetResourceChange(address).IsReplacement() {
+		return "⚠ DANGER: stateful resource will be replaced"
+	}
+	return "⚠ DANGER: stateful resource will be destroyed"
+}
+
+// formatDangerWarningText adds a warning line when stateful resources will be destroyed or replaced
+func formatDangerWarningText(sb *strings.Builder, resources *model.ResourceCollection, opts Options) {
+	if len(resources.Dangerous) == 0 {
+		return
+	}
+
+	warning := fmt.Sprintf("⚠ DANGER: %s with stateful data will be destroyed or replaced",
+		pluralizeResources(len(resources.Dangerous)))
+	sb.WriteString(util.ColorizeText(util.BoldText(warning, opts.UseColors), util.ColorRed, opts.UseColors))
+	sb.WriteString("\n\n")
+}
+
+// formatSuppressedResourcesText formats the updates reclassified as no-op
+func formatSuppressedResourcesText(sb *strings.Builder, resources *model.ResourceCollection, opts Options) {
+	suppressed := resources.GetSuppressedResources()
+	if len(suppressed) == 0 {
+		return
+	}
+
+	if opts.UseColors {
+		sb.WriteString(util.BoldText("SUPPRESSED UPDATES (only ignored attributes changed):", opts.UseColors))
+	} else {
+		sb.WriteString("SUPPRESSED UPDATES (only ignored attributes changed):")
+	}
+	sb.WriteString("\n")
+
+	for _, resource := range suppressed {
+		attributes := strings.Join(resources.Suppressed[resource].ChangedAttributes(), ", ")
+		if opts.UseColors {
+			fmt.Fprintf(sb, "    %s= %s%s (%s)\n", util.ColorBlue, resource, util.ColorReset, attributes)
+		} else {
+			fmt.Fprintf(sb, "    = %s (%s)\n", resource, attributes)
+		}
+	}
+	sb.WriteString("\n")
+}
+
+// formatAcknowledgedResourcesText formats the changes acknowledged by the baseline
+func formatAcknowledgedResourcesText(sb *strings.Builder, resources *model.ResourceCollection, opts Options) {
+	acknowledged := resources.GetAcknowledgedResources()
+	if len(acknowledged) == 0 {
+		return
+	}
+
+	sb.WriteString(util.BoldText("ACKNOWLEDGED BY BASELINE:", opts.UseColors))
+	sb.WriteString("\n")
+
+	for _, resource := range acknowledged {
+		label := resources.Acknowledged[resource].ActionLabel()
+		if opts.UseColors {
+			fmt.Fprintf(sb, "    %s= %s%s (%s)\n", util.ColorBlue, resource, util.ColorReset, label)
+		} else {
+			fmt.Fprintf(sb, "    = %s (%s)\n", resource, label)
+		}
+	}
+	sb.WriteString("\n")
+}
+
 // formatSummaryOnlyText formats the summary when no detailed resources are available
 func formatSummaryOnlyText(sb *strings.Builder, resources *model.ResourceCollection, opts Options) {
 	if resources.SummaryAdds > 0 {
@@ -217,6 +344,51 @@ func formatTotalChangesText(sb *strings.Builder, resources *model.ResourceCollec
 	} else {
 		fmt.Fprintf(sb, "TOTAL CHANGES: %d\n", totalChanges)
 	}
+
+	if resources.RiskLevel != "" {
+		score := fmt.Sprintf("%d (%s)", resources.RiskScore, resources.RiskLevel)
+		if opts.UseColors {
+			fmt.Fprintf(sb, "%sRISK SCORE:%s %s\n", util.ColorBold, util.ColorReset, util.ColorizeText(score, riskColor(resources.RiskLevel), true))
+		} else {
+			fmt.Fprintf(sb, "RISK SCORE: %s\n", score)
+		}
+	}
+}
+
+// riskColor returns the color for a plan risk level
+func riskColor(level string) string {
+	switch level {
+	case risk.LevelHigh:
+		return util.ColorRed
+	case risk.LevelMedium:
+		return util.ColorYellow
+	default:
+		return util.ColorGreen
+	}
+}
+
+// formatExcludedNotesText notes the resources left out of the output
+func formatExcludedNotesText(sb *strings.Builder, resources *model.ResourceCollection) {
+	if resources.IgnoredResources > 0 {
+		fmt.Fprintf(sb, "(%s ignored by ignore rules)\n", pluralizeResources(resources.IgnoredResources))
+	}
+	if len(resources.Suppressed) > 0 {
+		fmt.Fprintf(sb, "(%s suppressed as no-op by ignored attributes)\n", pluralizeResources(len(resources.Suppressed)))
+	}
+	if len(resources.Acknowledged) > 0 {
+		fmt.Fprintf(sb, "(%s acknowledged by baseline)\n", pluralizeResources(len(resources.Acknowledged)))
+	}
+	if resources.HiddenResources > 0 {
+		fmt.Fprintf(sb, "(%s hidden by filters)\n", pluralizeResources(resources.HiddenResources))
+	}
+}
+
+// pluralizeResources returns the resource count with the matching noun
+func pluralizeResources(count int) string {
+	if count == 1 {
+		return "1 resource"
+	}
+	return fmt.Sprintf("%d resources", count)
 }
 
 // formatPlanSummaryText formats the plan summary line
@@ -232,47 +404,351 @@ func formatPlanSummaryText(sb *strings.Builder, resources *model.ResourceCollect
 	}
 }
 
+// formatCostText formats the estimated monthly cost impact per resource and for the plan
+func formatCostText(sb *strings.Builder, costs *model.CostReport, opts Options) {
+	if costs == nil {
+		return
+	}
+
+	sb.WriteString("\n")
+	sb.WriteString(util.BoldText(fmt.Sprintf("COST ESTIMATE (%s per month):", costs.Currency), opts.UseColors))
+	sb.WriteString("\n")
+
+	addresses := costChangedAddresses(costs)
+	width := len("TOTAL")
+	for _, address := range addresses {
+		if len(address) > width {
+			width = len(address)
+		}
+	}
+
+	for _, address := range addresses {
+		c := costs.Resources[address]
+		fmt.Fprintf(sb, "  %-*s  %s  (%.2f → %.2f)\n", width, address, formatCostDelta(c.Delta(), opts), c.Before, c.After)
+	}
+	fmt.Fprintf(sb, "  %-*s  %s  (%.2f → %.2f)\n", width, "TOTAL", formatCostDelta(costs.Delta(), opts), costs.MonthlyBefore, costs.MonthlyAfter)
+
+	if len(costs.Unpriced) > 0 {
+		fmt.Fprintf(sb, "  UNPRICED (%d):\n", len(costs.Unpriced))
+		for _, unpriced := range costs.Unpriced {
+			fmt.Fprintf(sb, "    ? %s (%s)\n", unpriced.Address, unpriced.Reason)
+		}
+	}
+}
+
+// costChangedAddresses returns the priced resources whose cost changes, largest change first
+func costChangedAddresses(costs *model.CostReport) []string {
+	var addresses []string
+	for address, c := range costs.Resources {
+		if c.Delta() != 0 {
+			addresses = append(addresses, address)
+		}
+	}
+
+	sort.Slice(addresses, func(i, j int) bool {
+		di, dj := math.Abs(costs.Resources[addresses[i]].Delta()), math.Abs(costs.Resources[addresses[j]].Delta())
+		if di != dj {
+			return di > dj
+		}
+		return addresses[i] < addresses[j]
+	})
+	return addresses
+}
+
+// formatCostDelta formats a cost change with its sign, red for increases and green for savings
+func formatCostDelta(delta float64, opts Options) string {
+	text := fmt.Sprintf("%+10.2f", delta)
+	switch {
+	case delta > 0:
+		return util.ColorizeText(text, util.ColorRed, opts.UseColors)
+	case delta < 0:
+		return util.ColorizeText(text, util.ColorGreen, opts.UseColors)
+	default:
+		return text
+	}
+}
+
+// formatSecurityFindingsText formats the security-sensitive changes found in the plan
+func formatSecurityFindingsText(sb *strings.Builder, findings []model.SecurityFinding, opts Options) {
+	if len(findings) == 0 {
+		return
+	}
+
+	sb.WriteString("\n")
+	header := fmt.Sprintf("SECURITY (%d):", len(findings))
+	sb.WriteString(util.ColorizeText(util.BoldText(header, opts.UseColors), util.ColorYellow, opts.UseColors))
+	sb.WriteString("\n")
+
+	for _, finding := range findings {
+		label := util.ColorizeText(fmt.Sprintf("! [%s]", finding.Detector), util.ColorYellow, opts.UseColors)
+		fmt.Fprintf(sb, "  %s %s\n", label, finding.Message)
+	}
+}
+
+// FormatViolationsText formats a list of policy violations as text
+func FormatViolationsText(violations []model.PolicyViolation, opts Options) string {
+	if len(violations) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\n")
+	header := fmt.Sprintf("POLICY VIOLATIONS (%d):", len(violations))
+	if opts.UseColors {
+		sb.WriteString(util.ColorizeText(util.BoldText(header, opts.UseColors), util.ColorRed, opts.UseColors))
+	} else {
+		sb.WriteString(header)
+	}
+	sb.WriteString("\n")
+
+	for _, v := range violations {
+		symbol, color := violationSymbol(v)
+		line := fmt.Sprintf("%s [%s]", symbol, v.Rule)
+		fmt.Fprintf(&sb, "  %s %s%s\n", util.ColorizeText(line, color, opts.UseColors), v.Message, exemptionNote(v))
+	}
+
+	return sb.String()
+}
+
+// violationSymbol returns the symbol and color for a violation based on its severity and exemption
+func violationSymbol(v model.PolicyViolation) (string, string) {
+	if v.Exemption != nil && !v.Exemption.Expired {
+		return "○", util.ColorCyan
+	}
+
+	switch v.Severity {
+	case model.SeverityWarn:
+		return "⚠", util.ColorYellow
+	case model.SeverityInfo:
+		return "ℹ", util.ColorBlue
+	default:
+		return "✗", util.ColorRed
+	}
+}
+
+// exemptionNote describes the exemption covering a violation, if any
+func exemptionNote(v model.PolicyViolation) string {
+	if v.Exemption == nil {
+		return ""
+	}
+
+	e := v.Exemption
+	if e.Expired {
+		return fmt.Sprintf(" (exemption by %s expired on %s)", e.Owner, e.Expires)
+	}
+
+	note := fmt.Sprintf(" (exempted by %s until %s", e.Owner, e.Expires)
+	if e.Reason != "" {
+		note += ": " + e.Reason
+	}
+	return note + ")"
+}
+
+// JSONSchemaVersion is the version of the JSON and YAML output, published as
+// schema/report.schema.json. It is increased whenever the shape of the output changes.
+const JSONSchemaVersion = 1
+
 // FormatJSON formats the resource collection as JSON
 func FormatJSON(resources *model.ResourceCollection) (string, error) {
-	type jsonOutput struct {
-		Create  []string `json:"create"`
-		Update  []string `json:"update"`
-		Destroy []string `json:"destroy"`
-		Summary struct {
-			Total    int `json:"total"`
-			Adds     int `json:"adds"`
-			Changes  int `json:"changes"`
-			Destroys int `json:"destroys"`
-		} `json:"summary"`
-		HasDetailedResources bool      `json:"has_detailed_resources"`
-		FoundSummary         bool      `json:"found_summary"`
-		Timestamp            time.Time `json:"timestamp"`
-	}
-
-	output := jsonOutput{
+	jsonBytes, err := json.MarshalIndent(buildJSONDocument(resources), "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonBytes), nil
+}
+
+// jsonDocument is the JSON output for a single plan
+type jsonDocument struct {
+	SchemaVersion int `json:"schema_version" desc:"Version of the output schema"`
+	jsonReport
+}
+
+// buildJSONDocument converts a resource collection into the JSON output for a single plan
+func buildJSONDocument(resources *model.ResourceCollection) *jsonDocument {
+	return &jsonDocument{SchemaVersion: JSONSchemaVersion, jsonReport: *buildJSONReport(resources)}
+}
+
+// jsonReport is the JSON representation of a resource collection
+type jsonReport struct {
+	Create     []string `json:"create" desc:"Addresses of the resources to create, including replacements"`
+	Update     []string `json:"update" desc:"Addresses of the resources to update in place"`
+	Destroy    []string `json:"destroy" desc:"Addresses of the resources to destroy, including replacements"`
+	Suppressed []string `json:"suppressed,omitempty" desc:"Updates treated as no-op because only ignored attributes change"`
+	Summary    struct {
+		Total        int    `json:"total" desc:"Number of changes"`
+		Adds         int    `json:"adds" desc:"Number of resources to create"`
+		Changes      int    `json:"changes" desc:"Number of resources to update"`
+		Destroys     int    `json:"destroys" desc:"Number of resources to destroy"`
+		Hidden       int    `json:"hidden,omitempty" desc:"Resources left out by filters"`
+		Ignored      int    `json:"ignored,omitempty" desc:"Resources left out by ignore rules"`
+		Suppressed   int    `json:"suppressed,omitempty" desc:"Updates suppressed as no-op"`
+		Acknowledged int    `json:"acknowledged,omitempty" desc:"Changes acknowledged by the baseline"`
+		RiskScore    int    `json:"risk_score" desc:"Risk score of the plan"`
+		RiskLevel    string `json:"risk_level,omitempty" desc:"Risk level of the plan: low, medium or high"`
+	} `json:"summary" desc:"Counts of the changes"`
+	Acknowledged         []jsonAcknowledged `json:"acknowledged,omitempty" desc:"Changes acknowledged by the baseline"`
+	RiskScores           map[string]int     `json:"risk_scores,omitempty" desc:"Risk score of each resource by address"`
+	Cost                 *jsonCost          `json:"cost,omitempty" desc:"Estimated monthly cost impact"`
+	Security             []jsonFinding      `json:"security,omitempty" desc:"Security-sensitive changes"`
+	Violations           []jsonViolation    `json:"violations,omitempty" desc:"Policy violations"`
+	HasDetailedResources bool               `json:"has_detailed_resources" desc:"Whether the plan listed the individual resources"`
+	FoundSummary         bool               `json:"found_summary" desc:"Whether a plan summary line was found"`
+}
+
+// buildJSONReport converts a resource collection for JSON output
+func buildJSONReport(resources *model.ResourceCollection) *jsonReport {
+	output := &jsonReport{
 		Create:               resources.GetResourcesForAction(model.ActionCreate),
 		Update:               resources.GetResourcesForAction(model.ActionUpdate),
 		Destroy:              resources.GetResourcesForAction(model.ActionDestroy),
+		Suppressed:           resources.GetSuppressedResources(),
 		HasDetailedResources: resources.HasDetailedResources,
 		FoundSummary:         resources.FoundSummary,
-		Timestamp:            time.Now(),
 	}
 
 	output.Summary.Adds = resources.SummaryAdds
 	output.Summary.Changes = resources.SummaryChanges
 	output.Summary.Destroys = resources.SummaryDestroys
 	output.Summary.Total = resources.TotalChanges()
+	output.Summary.Hidden = resources.HiddenResources
+	output.Summary.Ignored = resources.IgnoredResources
+	output.Summary.Suppressed = len(resources.Suppressed)
+	output.Summary.Acknowledged = len(resources.Acknowledged)
+	output.Summary.RiskScore = resources.RiskScore
+	output.Summary.RiskLevel = resources.RiskLevel
+	output.RiskScores = resources.RiskScores
+	for _, address := range resources.GetAcknowledgedResources() {
+		output.Acknowledged = append(output.Acknowledged, jsonAcknowledged{address, resources.Acknowledged[address].ActionLabel()})
+	}
+	output.Violations = buildJSONViolations(resources.Violations)
+	output.Cost = buildJSONCost(resources.Costs)
+	for _, finding := range resources.SecurityFindings {
+		output.Security = append(output.Security, jsonFinding{finding.Detector, finding.Address, finding.Message})
+	}
 
-	jsonBytes, err := json.MarshalIndent(output, "", "  ")
-	if err != nil {
-		return "", err
+	return output
+}
+
+// jsonAcknowledged is the JSON representation of a change acknowledged by the baseline.
+// Saved outputs keep the list so they can be used as the next baseline.
+type jsonAcknowledged struct {
+	Address string `json:"address" desc:"Resource address"`
+	Action  string `json:"action" desc:"Acknowledged action: create, update, destroy or replace"`
+}
+
+// jsonCost is the JSON representation of a cost estimate
+type jsonCost struct {
+	Currency      string             `json:"currency" desc:"Currency of all amounts"`
+	MonthlyBefore float64            `json:"monthly_before" desc:"Monthly cost of the priced resources before the plan"`
+	MonthlyAfter  float64            `json:"monthly_after" desc:"Monthly cost of the priced resources after the plan"`
+	MonthlyDelta  float64            `json:"monthly_delta" desc:"Change in monthly cost"`
+	Resources     []jsonResourceCost `json:"resources" desc:"Monthly cost of each priced resource"`
+	Unpriced      []jsonUnpriced     `json:"unpriced" desc:"Resources that could not be priced"`
+}
+
+// jsonResourceCost is the JSON representation of the cost of a resource
+type jsonResourceCost struct {
+	Address       string  `json:"address" desc:"Resource address"`
+	MonthlyBefore float64 `json:"monthly_before" desc:"Monthly cost before the plan"`
+	MonthlyAfter  float64 `json:"monthly_after" desc:"Monthly cost after the plan"`
+	MonthlyDelta  float64 `json:"monthly_delta" desc:"Change in monthly cost"`
+}
+
+// jsonUnpriced is the JSON representation of a resource that could not be priced
+type jsonUnpriced struct {
+	Address string `json:"address" desc:"Resource address"`
+	Reason  string `json:"reason" desc:"Why the resource could not be priced"`
+}
+
+// buildJSONCost converts a cost estimate for JSON output, listing resources by address
+func buildJSONCost(costs *model.CostReport) *jsonCost {
+	if costs == nil {
+		return nil
 	}
 
-	return string(jsonBytes), nil
+	result := &jsonCost{
+		Currency:      costs.Currency,
+		MonthlyBefore: roundCents(costs.MonthlyBefore),
+		MonthlyAfter:  roundCents(costs.MonthlyAfter),
+		MonthlyDelta:  roundCents(costs.Delta()),
+		Resources:     []jsonResourceCost{},
+		Unpriced:      []jsonUnpriced{},
+	}
+
+	addresses := make([]string, 0, len(costs.Resources))
+	for address := range costs.Resources {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+
+	for _, address := range addresses {
+		c := costs.Resources[address]
+		result.Resources = append(result.Resources, jsonResourceCost{
+			Address:       address,
+			MonthlyBefore: roundCents(c.Before),
+			MonthlyAfter:  roundCents(c.After),
+			MonthlyDelta:  roundCents(c.Delta()),
+		})
+	}
+	for _, unpriced := range costs.Unpriced {
+		result.Unpriced = append(result.Unpriced, jsonUnpriced{Address: unpriced.Address, Reason: unpriced.Reason})
+	}
+
+	return result
+}
+
+// roundCents rounds an amount to two decimals
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// jsonFinding is the JSON representation of a security finding
+type jsonFinding struct {
+	Detector string `json:"detector" desc:"Name of the detector that reported the finding"`
+	Address  string `json:"address" desc:"Resource address"`
+	Message  string `json:"message" desc:"Description of the finding"`
+}
+
+// jsonViolation is the JSON representation of a policy violation
+type jsonViolation struct {
+	Rule      string         `json:"rule" desc:"Name of the violated rule"`
+	Severity  model.Severity `json:"severity" desc:"Severity of the rule: info, warn or error"`
+	Address   string         `json:"address,omitempty" desc:"Resource address, absent for violations of the plan as a whole"`
+	Message   string         `json:"message" desc:"Description of the violation"`
+	Blocking  bool           `json:"blocking" desc:"Whether the violation fails the run"`
+	Exemption *jsonExemption `json:"exemption,omitempty" desc:"Exemption covering the violation"`
+}
+
+// jsonExemption is the JSON representation of a policy exemption
+type jsonExemption struct {
+	Owner   string `json:"owner" desc:"Person or team that owns the exemption"`
+	Reason  string `json:"reason,omitempty" desc:"Why the exemption was granted"`
+	Expires string `json:"expires" desc:"Expiry date in YYYY-MM-DD format"`
+	Expired bool   `json:"expired" desc:"Whether the exemption has expired and no longer applies"`
+}
+
+// buildJSONViolations converts policy violations for JSON output
+func buildJSONViolations(violations []model.PolicyViolation) []jsonViolation {
+	var result []jsonViolation
+	for _, v := range violations {
+		jv := jsonViolation{
+			Rule:     v.Rule,
+			Severity: v.Severity,
+			Address:  v.Address,
+			Message:  v.Message,
+			Blocking: v.IsBlocking(),
+		}
+		if v.Exemption != nil {
+			jv.Exemption = &jsonExemption{v.Exemption.Owner, v.Exemption.Reason, v.Exemption.Expires, v.Exemption.Expired}
+		}
+		result = append(result, jv)
+	}
+	return result
 }
 
 // FormatHTML formats the resource collection as HTML
-func FormatHTML(resources *model.ResourceCollection) (string, error) {
+func FormatHTML(resources *model.ResourceCollection, opts Options) (string, error) {
 	var sb strings.Builder
 
 	// Write HTML header and styles
@@ -280,29 +756,58 @@ func FormatHTML(resources *model.ResourceCollection) (string, error) {
 
 	// Write the main content
 	sb.WriteString("    <h1>Terraform Plan Summary</h1>\n")
+	writeHTMLResources(&sb, resources, opts)
+
+	// Add timestamp and close HTML
+	writeHTMLFooter(&sb)
+
+	return sb.String(), nil
+}
+
+// writeHTMLResources writes the resources, totals and findings of one plan
+func writeHTMLResources(sb *strings.Builder, resources *model.ResourceCollection, opts Options) {
+	writeHTMLDangerWarning(sb, resources)
 	sb.WriteString("    <div class=\"summary\">\n")
 	sb.WriteString(fmt.Sprintf("        <p><strong>Total changes:</strong> %d</p>\n", resources.TotalChanges()))
+	if resources.IgnoredResources > 0 {
+		fmt.Fprintf(sb, "        <p class=\"hidden-note\">%s ignored by ignore rules</p>\n", pluralizeResources(resources.IgnoredResources))
+	}
+	if len(resources.Suppressed) > 0 {
+		fmt.Fprintf(sb, "        <p class=\"hidden-note\">%s suppressed as no-op by ignored attributes</p>\n", pluralizeResources(len(resources.Suppressed)))
+	}
+	if len(resources.Acknowledged) > 0 {
+		fmt.Fprintf(sb, "        <p class=\"hidden-note\">%s acknowledged by baseline</p>\n", pluralizeResources(len(resources.Acknowledged)))
+	}
+	if resources.RiskLevel != "" {
+		fmt.Fprintf(sb, "        <p><strong>Risk score:</strong> <span class=\"risk-%s\">%d (%s)</span></p>\n",
+			resources.RiskLevel, resources.RiskScore, resources.RiskLevel)
+	}
+	if resources.HiddenResources > 0 {
+		fmt.Fprintf(sb, "        <p class=\"hidden-note\">%s hidden by filters</p>\n", pluralizeResources(resources.HiddenResources))
+	}
 
 	// If we have detailed resources
 	if resources.HasDetailedResources {
 		// Render sections for create, update, destroy actions
-		renderHTMLActionSection(&sb, resources, model.ActionCreate, "Create", "create")
-		renderHTMLActionSection(&sb, resources, model.ActionUpdate, "Update", "update")
-		renderHTMLActionSection(&sb, resources, model.ActionDestroy, "Destroy", "destroy")
+		renderHTMLActionSection(sb, resources, model.ActionCreate, "Create", "create", opts)
+		renderHTMLActionSection(sb, resources, model.ActionUpdate, "Update", "update", opts)
+		renderHTMLActionSection(sb, resources, model.ActionDestroy, "Destroy", "destroy", opts)
 	} else if resources.FoundSummary {
 		// No detailed resources, but we have a summary
-		writeHTMLSummaryOnly(&sb, resources)
+		writeHTMLSummaryOnly(sb, resources)
 	}
 
 	// Write plan summary if available
 	if resources.FoundSummary {
-		writeHTMLPlanSummary(&sb, resources)
+		writeHTMLPlanSummary(sb, resources)
 	}
 
-	// Add timestamp and close HTML
-	writeHTMLFooter(&sb)
+	// Write the cost estimate if any
+	writeHTMLCost(sb, resources.Costs)
 
-	return sb.String(), nil
+	// Write security findings and policy violations if any
+	writeHTMLSecurityFindings(sb, resources.SecurityFindings)
+	writeHTMLViolations(sb, resources.Violations)
 }
 
 // writeHTMLHeader writes the HTML header and styles
@@ -380,6 +885,122 @@ func writeHTMLHeader(sb *strings.Builder) {
             background-color: #f0f8ff;
             border-radius: 4px;
         }
+        .risk-low {
+            color: #2a9d8f;
+            font-weight: bold;
+        }
+        .risk-medium {
+            color: #e9c46a;
+            font-weight: bold;
+        }
+        .risk-high {
+            color: #e76f51;
+            font-weight: bold;
+        }
+        .danger-banner {
+            background: #e76f51;
+            color: white;
+            font-weight: bold;
+            padding: 12px 15px;
+            border-radius: 4px;
+            margin-bottom: 20px;
+        }
+        .resource.dangerous {
+            background: #fdf0ec;
+            border-left: 4px solid #e76f51;
+            padding-left: 8px;
+        }
+        .danger {
+            color: #e76f51;
+            font-weight: bold;
+            margin-left: 10px;
+        }
+        .cost table {
+            border-collapse: collapse;
+            width: 100%;
+            margin-bottom: 15px;
+        }
+        .cost th, .cost td {
+            text-align: left;
+            padding: 6px 10px;
+            border-bottom: 1px solid #eee;
+        }
+        .cost tr.total td {
+            font-weight: bold;
+        }
+        .cost-increase {
+            color: #e76f51;
+        }
+        .cost-decrease {
+            color: #2a9d8f;
+        }
+        .attribute-diff {
+            font-family: monospace;
+            padding: 2px 0 2px 30px;
+            color: #555;
+        }
+        .security h2 {
+            color: #b5830a;
+        }
+        .finding {
+            border-left: 4px solid #e9c46a;
+            padding: 10px 15px;
+            margin-bottom: 10px;
+            background: #fdf8ea;
+            border-radius: 0 4px 4px 0;
+        }
+        .violations h2 {
+            color: #e76f51;
+        }
+        .violation {
+            border-left: 4px solid #e76f51;
+            padding: 10px 15px;
+            margin-bottom: 10px;
+            background: #fdf0ec;
+            border-radius: 0 4px 4px 0;
+        }
+        .violation.warn {
+            border-left-color: #e9c46a;
+            background: #fdf8ea;
+        }
+        .violation.info {
+            border-left-color: #0f4c81;
+            background: #f0f8ff;
+        }
+        .violation.exempt {
+            border-left-color: #999;
+            background: #f5f5f5;
+            color: #666;
+        }
+        .hidden-note {
+            font-style: italic;
+            color: #666;
+        }
+        .resource-risk {
+            color: #666;
+        }
+        .stacks {
+            border-collapse: collapse;
+            width: 100%;
+            margin-bottom: 30px;
+        }
+        .stacks th, .stacks td {
+            text-align: left;
+            padding: 6px 10px;
+            border-bottom: 1px solid #eee;
+        }
+        .stacks tr.failed td {
+            color: #e76f51;
+        }
+        .stack {
+            border-top: 2px solid #0f4c81;
+            margin-top: 30px;
+        }
+        .stack-path {
+            font-size: 0.6em;
+            font-weight: normal;
+            color: #666;
+        }
     </style>
 </head>
 <body>
@@ -387,7 +1008,7 @@ func writeHTMLHeader(sb *strings.Builder) {
 }
 
 // renderHTMLActionSection renders an HTML section for a specific action
-func renderHTMLActionSection(sb *strings.Builder, resources *model.ResourceCollection, action model.Action, actionName, colorClass string) {
+func renderHTMLActionSection(sb *strings.Builder, resources *model.ResourceCollection, action model.Action, actionName, colorClass string, opts Options) {
 	actionResources := resources.GetResourcesForAction(action)
 	if len(actionResources) == 0 {
 		return
@@ -396,6 +1017,15 @@ func renderHTMLActionSection(sb *strings.Builder, resources *model.ResourceColle
 	fmt.Fprintf(sb, "    <div class=\"action-group %s\">\n", colorClass)
 	fmt.Fprintf(sb, "        <h2>Resources to %s</h2>\n", strings.ToLower(actionName))
 
+	// With risk ordering, list the resources without grouping
+	if opts.SortByRisk {
+		for _, resource := range sortByRisk(resources, actionResources) {
+			writeHTMLResource(sb, resources, resource, opts)
+		}
+		sb.WriteString("    </div>\n")
+		return
+	}
+
 	// Group by resource type
 	typeMap := resources.ResourcesByType(action)
 
@@ -404,45 +1034,71 @@ func renderHTMLActionSection(sb *strings.Builder, resources *model.ResourceColle
 
 	// Special handling for module resources
 	if hasModuleResources(typeMap) {
-		writeHTMLModuleResources(sb, typeMap)
+		writeHTMLModuleResources(sb, resources, typeMap, opts)
 		types = filterOutModuleType(types)
 	}
 
 	// Write resources by type
-	writeHTMLResourcesByType(sb, types, typeMap)
+	writeHTMLResourcesByType(sb, resources, types, typeMap, opts)
 
 	sb.WriteString("    </div>\n")
 }
 
 // writeHTMLModuleResources writes HTML for module resources
-func writeHTMLModuleResources(sb *strings.Builder, typeMap map[string][]string) {
+func writeHTMLModuleResources(sb *strings.Builder, resources *model.ResourceCollection, typeMap map[string][]string, opts Options) {
 	moduleResources := typeMap["module"]
 	sb.WriteString("        <div class=\"resource-type\">MODULE RESOURCES</div>\n")
 
 	for _, resource := range moduleResources {
-		fmt.Fprintf(sb, "        <div class=\"resource\">%s</div>\n", resource)
+		writeHTMLResource(sb, resources, resource, opts)
 	}
 }
 
 // writeHTMLResourcesByType writes HTML for resources grouped by type
-func writeHTMLResourcesByType(sb *strings.Builder, types []string, typeMap map[string][]string) {
+func writeHTMLResourcesByType(sb *strings.Builder, resources *model.ResourceCollection, types []string, typeMap map[string][]string, opts Options) {
 	for _, resourceType := range types {
-		resources := typeMap[resourceType]
+		typeResources := typeMap[resourceType]
 
 		// Skip empty resource types
-		if len(resources) == 0 {
+		if len(typeResources) == 0 {
 			continue
 		}
 
 		fmt.Fprintf(sb, "        <div class=\"resource-type\">%s</div>\n",
 			strings.ToUpper(resourceType))
 
-		for _, resource := range resources {
-			fmt.Fprintf(sb, "        <div class=\"resource\">%s</div>\n", resource)
+		for _, resource := range typeResources {
+			writeHTMLResource(sb, resources, resource, opts)
 		}
 	}
 }
 
+// writeHTMLResource writes HTML for a single resource, marking dangerous changes
+func writeHTMLResource(sb *strings.Builder, resources *model.ResourceCollection, resource string, opts Options) {
+	risk := ""
+	if opts.SortByRisk {
+		risk = fmt.Sprintf(" <span class=\"resource-risk\">(risk %d)</span>", resources.RiskScores[resource])
+	}
+
+	if !resources.IsDangerous(resource) {
+		fmt.Fprintf(sb, "        <div class=\"resource\">%s%s</div>\n", resource, risk)
+		return
+	}
+
+	fmt.Fprintf(sb, "        <div class=\"resource dangerous\">%s%s <span class=\"danger\">%s</span></div>\n",
+		resource, risk, dangerMarker(resources, resource))
+}
+
+// writeHTMLDangerWarning writes a banner when stateful resources will be destroyed or replaced
+func writeHTMLDangerWarning(sb *strings.Builder, resources *model.ResourceCollection) {
+	if len(resources.Dangerous) == 0 {
+		return
+	}
+
+	fmt.Fprintf(sb, "    <div class=\"danger-banner\">⚠ DANGER: %s with stateful data will be destroyed or replaced</div>\n",
+		pluralizeResources(len(resources.Dangerous)))
+}
+
 // writeHTMLSummaryOnly writes HTML for when only summary information is available
 func writeHTMLSummaryOnly(sb *strings.Builder, resources *model.ResourceCollection) {
 	sb.WriteString("    <div class=\"summary-details\">\n")
@@ -473,6 +1129,86 @@ func writeHTMLPlanSummary(sb *strings.Builder, resources *model.ResourceCollecti
 	fmt.Fprintf(sb, "    <div class=\"plan-summary\">%s</div>\n", planSummary)
 }
 
+// writeHTMLCost writes the cost estimate section
+func writeHTMLCost(sb *strings.Builder, costs *model.CostReport) {
+	if costs == nil {
+		return
+	}
+
+	sb.WriteString("    <div class=\"cost\">\n")
+	fmt.Fprintf(sb, "        <h2>Cost Estimate (%s per month)</h2>\n", html.EscapeString(costs.Currency))
+	sb.WriteString("        <table>\n")
+	sb.WriteString("            <tr><th>Resource</th><th>Before</th><th>After</th><th>Change</th></tr>\n")
+	for _, address := range costChangedAddresses(costs) {
+		c := costs.Resources[address]
+		fmt.Fprintf(sb, "            <tr><td>%s</td><td>%.2f</td><td>%.2f</td><td class=\"%s\">%+.2f</td></tr>\n",
+			html.EscapeString(address), c.Before, c.After, costDeltaClass(c.Delta()), c.Delta())
+	}
+	fmt.Fprintf(sb, "            <tr class=\"total\"><td>Total</td><td>%.2f</td><td>%.2f</td><td class=\"%s\">%+.2f</td></tr>\n",
+		costs.MonthlyBefore, costs.MonthlyAfter, costDeltaClass(costs.Delta()), costs.Delta())
+	sb.WriteString("        </table>\n")
+
+	if len(costs.Unpriced) > 0 {
+		fmt.Fprintf(sb, "        <p class=\"hidden-note\">Unpriced (%d):</p>\n", len(costs.Unpriced))
+		for _, unpriced := range costs.Unpriced {
+			fmt.Fprintf(sb, "        <div class=\"resource unpriced\">%s (%s)</div>\n", html.EscapeString(unpriced.Address), html.EscapeString(unpriced.Reason))
+		}
+	}
+
+	sb.WriteString("    </div>\n")
+}
+
+// costDeltaClass returns the CSS class for a cost change
+func costDeltaClass(delta float64) string {
+	switch {
+	case delta > 0:
+		return "cost-increase"
+	case delta < 0:
+		return "cost-decrease"
+	default:
+		return ""
+	}
+}
+
+// writeHTMLSecurityFindings writes the security findings section
+func writeHTMLSecurityFindings(sb *strings.Builder, findings []model.SecurityFinding) {
+	if len(findings) == 0 {
+		return
+	}
+
+	sb.WriteString("    <div class=\"security\">\n")
+	fmt.Fprintf(sb, "        <h2>Security (%d)</h2>\n", len(findings))
+
+	for _, finding := range findings {
+		fmt.Fprintf(sb, "        <div class=\"finding\">! [%s] %s</div>\n",
+			html.EscapeString(finding.Detector), html.EscapeString(finding.Message))
+	}
+
+	sb.WriteString("    </div>\n")
+}
+
+// writeHTMLViolations writes the policy violations section
+func writeHTMLViolations(sb *strings.Builder, violations []model.PolicyViolation) {
+	if len(violations) == 0 {
+		return
+	}
+
+	sb.WriteString("    <div class=\"violations\">\n")
+	fmt.Fprintf(sb, "        <h2>Policy Violations (%d)</h2>\n", len(violations))
+
+	for _, v := range violations {
+		class := string(v.Severity)
+		if v.Exemption != nil && !v.Exemption.Expired {
+			class = "exempt"
+		}
+		symbol, _ := violationSymbol(v)
+		fmt.Fprintf(sb, "        <div class=\"violation %s\">%s [%s] %s%s</div>\n",
+			class, symbol, html.EscapeString(v.Rule), html.EscapeString(v.Message), html.EscapeString(exemptionNote(v)))
+	}
+
+	sb.WriteString("    </div>\n")
+}
+
 // writeHTMLFooter writes the timestamp and closing HTML tags
 func writeHTMLFooter(sb *strings.Builder) {
 	currentTime := time.Now().Format("January 2, 2006 15:04:05")
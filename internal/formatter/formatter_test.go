@@ -2,6 +2,7 @@ package formatter
 
 import (
 	"encoding/json"
+	"errors"
 	"strings"
 	"testing"
 
@@ -73,7 +74,7 @@ func TestFormatJSON(t *testing.T) {
 	}
 
 	// Check that the expected fields are present
-	expectedFields := []string{"create", "update", "destroy", "summary", "has_detailed_resources", "found_summary", "timestamp"}
+	expectedFields := []string{"create", "update", "destroy", "summary", "has_detailed_resources", "found_summary", "schema_version"}
 	for _, field := range expectedFields {
 		if _, ok := parsed[field]; !ok {
 			t.Errorf("Expected JSON to contain field %q, but it didn't", field)
@@ -105,7 +106,7 @@ func TestFormatHTML(t *testing.T) {
 	resources.AddResource(model.ActionCreate, "aws_s3_bucket.logs")
 	resources.AddResource(model.ActionUpdate, "aws_instance.web")
 
-	html, err := FormatHTML(resources)
+	html, err := FormatHTML(resources, Options{})
 	if err != nil {
 		t.Fatalf("FormatHTML returned an error: %v", err)
 	}
@@ -166,3 +167,437 @@ func TestFormatSummaryOnly(t *testing.T) {
 		}
 	}
 }
+
+func TestFormatHiddenResources(t *testing.T) {
+	resources := model.NewResourceCollection()
+	resources.AddResource(model.ActionCreate, "aws_s3_bucket.logs")
+	resources.HiddenResources = 3
+
+	result, err := FormatText(resources, Options{})
+	if err != nil {
+		t.Fatalf("FormatText returned an error: %v", err)
+	}
+	if !strings.Contains(result, "(3 resources hidden by filters)") {
+		t.Errorf("Expected text output to mention hidden resources, got:\n%s", result)
+	}
+
+	html, err := FormatHTML(resources, Options{})
+	if err != nil {
+		t.Fatalf("FormatHTML returned an error: %v", err)
+	}
+	if !strings.Contains(html, "3 resources hidden by filters") {
+		t.Errorf("Expected HTML output to mention hidden resources")
+	}
+}
+
+func TestFormatSuppressedVerbose(t *testing.T) {
+	resources := model.NewResourceCollection()
+	resources.AddResource(model.ActionUpdate, "aws_instance.api")
+	resources.AddSuppressedChange(&model.ResourceChange{
+		Address: "aws_instance.web",
+		Actions: []model.Action{model.ActionUpdate},
+		Before:  map[string]interface{}{"tags": map[string]interface{}{"Build": "1"}},
+		After:   map[string]interface{}{"tags": map[string]interface{}{"Build": "2"}},
+	})
+
+	result, err := FormatText(resources, Options{Verbose: true})
+	if err != nil {
+		t.Fatalf("FormatText returned an error: %v", err)
+	}
+	for _, phrase := range []string{"SUPPRESSED UPDATES", "= aws_instance.web (tags.Build)", "(1 resource suppressed as no-op"} {
+		if !strings.Contains(result, phrase) {
+			t.Errorf("Expected verbose output to contain %q, got:\n%s", phrase, result)
+		}
+	}
+
+	result, err = FormatText(resources, Options{})
+	if err != nil {
+		t.Fatalf("FormatText returned an error: %v", err)
+	}
+	if strings.Contains(result, "SUPPRESSED UPDATES") {
+		t.Errorf("Expected suppressed updates to be listed only in verbose mode")
+	}
+
+	for name, format := range map[string]func(*model.ResourceCollection, Options) (string, error){"HTML": FormatHTML, "Markdown": FormatMarkdown} {
+		if result, _ := format(resources, Options{}); !strings.Contains(result, "1 resource suppressed as no-op") {
+			t.Errorf("Expected the %s output to note the suppressed resource, got:\n%s", name, result)
+		}
+	}
+}
+
+func TestFormatViolationsText(t *testing.T) {
+	violations := []model.PolicyViolation{
+		{Rule: "no-destroy", Address: "aws_s3_bucket.old", Message: "aws_s3_bucket.old will be destroyed"},
+		{Rule: "max-changes", Message: "63 changes exceed the maximum of 50"},
+	}
+
+	result := FormatViolationsText(violations, Options{})
+	expectedPhrases := []string{
+		"POLICY VIOLATIONS (2):",
+		"✗ [no-destroy] aws_s3_bucket.old will be destroyed",
+		"✗ [max-changes] 63 changes exceed the maximum of 50",
+	}
+	for _, phrase := range expectedPhrases {
+		if !strings.Contains(result, phrase) {
+			t.Errorf("Expected output to contain %q, got:\n%s", phrase, result)
+		}
+	}
+
+	if FormatViolationsText(nil, Options{}) != "" {
+		t.Errorf("Expected no output without violations")
+	}
+}
+
+func TestFormatViolations(t *testing.T) {
+	resources := model.NewResourceCollection()
+	resources.AddResource(model.ActionDestroy, "aws_db_instance.main")
+	resources.AddResource(model.ActionCreate, "aws_db_instance.main")
+	resources.AddResource(model.ActionUpdate, "aws_iam_role.ci")
+	resources.Violations = []model.PolicyViolation{
+		{Rule: "protect-databases", Severity: model.SeverityError, Address: "aws_db_instance.main", Message: "aws_db_instance.main: databases must not be replaced", ExitCode: 7,
+			Exemption: &model.PolicyExemption{Owner: "dba-team", Reason: "engine upgrade", Expires: "2026-03-31"}},
+		{Rule: "review-iam", Severity: model.SeverityWarn, Address: "aws_iam_role.ci", Message: "aws_iam_role.ci violates review-iam"},
+	}
+
+	text, err := FormatText(resources, Options{})
+	if err != nil {
+		t.Fatalf("FormatText returned error: %v", err)
+	}
+	for _, phrase := range []string{
+		"POLICY VIOLATIONS (2):",
+		"○ [protect-databases] aws_db_instance.main: databases must not be replaced (exempted by dba-team until 2026-03-31: engine upgrade)",
+		"⚠ [review-iam] aws_iam_role.ci violates review-iam",
+	} {
+		if !strings.Contains(text, phrase) {
+			t.Errorf("Expected text output to contain %q, got:\n%s", phrase, text)
+		}
+	}
+
+	htmlOutput, err := FormatHTML(resources, Options{})
+	if err != nil {
+		t.Fatalf("FormatHTML returned error: %v", err)
+	}
+	if !strings.Contains(htmlOutput, "Policy Violations (2)") || !strings.Contains(htmlOutput, `class="violation exempt"`) {
+		t.Errorf("Expected HTML output to contain the violations section")
+	}
+
+	jsonOutput, err := FormatJSON(resources)
+	if err != nil {
+		t.Fatalf("FormatJSON returned error: %v", err)
+	}
+	var parsed struct {
+		Violations []struct {
+			Rule      string `json:"rule"`
+			Severity  string `json:"severity"`
+			Blocking  bool   `json:"blocking"`
+			Exemption *struct {
+				Owner string `json:"owner"`
+			} `json:"exemption"`
+		} `json:"violations"`
+	}
+	if err := json.Unmarshal([]byte(jsonOutput), &parsed); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v", err)
+	}
+	if len(parsed.Violations) != 2 {
+		t.Fatalf("Expected 2 violations in JSON output, got %d", len(parsed.Violations))
+	}
+	if v := parsed.Violations[0]; v.Blocking || v.Exemption == nil || v.Exemption.Owner != "dba-team" {
+		t.Errorf("Unexpected exempted violation in JSON output: %+v", v)
+	}
+	if v := parsed.Violations[1]; v.Severity != "warn" || v.Blocking {
+		t.Errorf("Unexpected warning in JSON output: %+v", v)
+	}
+}
+
+func TestFormatDangerousResources(t *testing.T) {
+	resources := model.NewResourceCollection()
+	resources.AddResourceChange(&model.ResourceChange{
+		Address: "aws_db_instance.main", Type: "aws_db_instance", Name: "main",
+		Actions: []model.Action{model.ActionDestroy, model.ActionCreate},
+	})
+	resources.AddResourceChange(&model.ResourceChange{
+		Address: "aws_instance.web", Type: "aws_instance", Name: "web",
+		Actions: []model.Action{model.ActionDestroy},
+	})
+	resources.HasDetailedResources = true
+	resources.Dangerous["aws_db_instance.main"] = struct{}{}
+
+	text, err := FormatText(resources, Options{})
+	if err != nil {
+		t.Fatalf("FormatText returned error: %v", err)
+	}
+	for _, phrase := range []string{
+		"⚠ DANGER: 1 resource with stateful data will be destroyed or replaced",
+		"- aws_db_instance.main  ⚠ DANGER: stateful resource will be replaced",
+	} {
+		if !strings.Contains(text, phrase) {
+			t.Errorf("Expected text output to contain %q, got:\n%s", phrase, text)
+		}
+	}
+	if strings.Contains(text, "aws_instance.web  ⚠") {
+		t.Errorf("Expected aws_instance.web not to be marked, got:\n%s", text)
+	}
+
+	htmlOutput, err := FormatHTML(resources, Options{})
+	if err != nil {
+		t.Fatalf("FormatHTML returned error: %v", err)
+	}
+	if !strings.Contains(htmlOutput, `class="danger-banner"`) || !strings.Contains(htmlOutput, `<div class="resource dangerous">aws_db_instance.main`) {
+		t.Errorf("Expected HTML output to mark the dangerous resource")
+	}
+}
+
+func TestFormatSortByRisk(t *testing.T) {
+	resources := model.NewResourceCollection()
+	resources.AddResource(model.ActionCreate, "aws_s3_bucket.logs")
+	resources.AddResource(model.ActionCreate, "aws_iam_role.ci")
+	resources.AddResource(model.ActionCreate, "aws_instance.web")
+	resources.HasDetailedResources = true
+	resources.RiskScores = map[string]int{"aws_s3_bucket.logs": 1, "aws_iam_role.ci": 6, "aws_instance.web": 1}
+	resources.RiskScore = 8
+	resources.RiskLevel = "low"
+
+	result, err := FormatText(resources, Options{SortByRisk: true})
+	if err != nil {
+		t.Fatalf("FormatText returned error: %v", err)
+	}
+
+	expected := "    + aws_iam_role.ci  (risk 6)\n    + aws_instance.web  (risk 1)\n    + aws_s3_bucket.logs  (risk 1)\n"
+	if !strings.Contains(result, expected) {
+		t.Errorf("Expected resources ordered by risk, got:\n%s", result)
+	}
+	if !strings.Contains(result, "RISK SCORE: 8 (low)") {
+		t.Errorf("Expected the plan risk score in the summary, got:\n%s", result)
+	}
+
+	htmlOutput, err := FormatHTML(resources, Options{SortByRisk: true})
+	if err != nil {
+		t.Fatalf("FormatHTML returned error: %v", err)
+	}
+	role := strings.Index(htmlOutput, "aws_iam_role.ci <span class=\"resource-risk\">(risk 6)</span>")
+	bucket := strings.Index(htmlOutput, "aws_s3_bucket.logs <span class=\"resource-risk\">(risk 1)</span>")
+	if role < 0 || bucket < role || strings.Contains(htmlOutput, "class=\"resource-type\"") {
+		t.Errorf("Expected ungrouped HTML resources ordered by risk, got:\n%s", htmlOutput)
+	}
+}
+
+func TestFormatSecurityFindings(t *testing.T) {
+	resources := model.NewResourceCollection()
+	resources.AddResource(model.ActionUpdate, "aws_security_group.web")
+	resources.HasDetailedResources = true
+	resources.SecurityFindings = []model.SecurityFinding{
+		{Detector: "open-ingress", Address: "aws_security_group.web", Message: "aws_security_group.web opens ingress port 22 to 0.0.0.0/0"},
+	}
+
+	text, err := FormatText(resources, Options{})
+	if err != nil {
+		t.Fatalf("FormatText returned error: %v", err)
+	}
+	if !strings.Contains(text, "SECURITY (1):\n  ! [open-ingress] aws_security_group.web opens ingress port 22 to 0.0.0.0/0") {
+		t.Errorf("Expected a security section, got:\n%s", text)
+	}
+
+	jsonOutput, err := FormatJSON(resources)
+	if err != nil {
+		t.Fatalf("FormatJSON returned error: %v", err)
+	}
+	var parsed struct {
+		Security []struct {
+			Detector string `json:"detector"`
+			Address  string `json:"address"`
+		} `json:"security"`
+	}
+	if err := json.Unmarshal([]byte(jsonOutput), &parsed); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v", err)
+	}
+	if len(parsed.Security) != 1 || parsed.Security[0].Detector != "open-ingress" || parsed.Security[0].Address != "aws_security_group.web" {
+		t.Errorf("Unexpected security findings in JSON output: %+v", parsed.Security)
+	}
+}
+
+func TestFormatCost(t *testing.T) {
+	resources := model.NewResourceCollection()
+	resources.AddResource(model.ActionUpdate, "aws_instance.web")
+	resources.AddResource(model.ActionCreate, "aws_lambda_function.api")
+	resources.HasDetailedResources = true
+	resources.Costs = &model.CostReport{
+		Currency:      "USD",
+		Resources:     map[string]model.ResourceCost{"aws_instance.web": {Before: 8, After: 70}},
+		Unpriced:      []model.UnpricedResource{{Address: "aws_lambda_function.api", Reason: "no price for aws_lambda_function"}},
+		MonthlyBefore: 8,
+		MonthlyAfter:  70,
+	}
+
+	text, err := FormatText(resources, Options{})
+	if err != nil {
+		t.Fatalf("FormatText returned error: %v", err)
+	}
+	for _, phrase := range []string{
+		"COST ESTIMATE (USD per month):",
+		"aws_instance.web      +62.00  (8.00 → 70.00)",
+		"UNPRICED (1):\n    ? aws_lambda_function.api (no price for aws_lambda_function)",
+	} {
+		if !strings.Contains(text, phrase) {
+			t.Errorf("Expected text output to contain %q, got:\n%s", phrase, text)
+		}
+	}
+
+	jsonOutput, err := FormatJSON(resources)
+	if err != nil {
+		t.Fatalf("FormatJSON returned error: %v", err)
+	}
+	var parsed struct {
+		Cost struct {
+			MonthlyDelta float64 `json:"monthly_delta"`
+			Unpriced     []struct {
+				Address string `json:"address"`
+			} `json:"unpriced"`
+		} `json:"cost"`
+	}
+	if err := json.Unmarshal([]byte(jsonOutput), &parsed); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v", err)
+	}
+	if parsed.Cost.MonthlyDelta != 62 || len(parsed.Cost.Unpriced) != 1 {
+		t.Errorf("Unexpected cost in JSON output: %+v", parsed.Cost)
+	}
+
+	htmlOutput, err := FormatHTML(resources, Options{})
+	if err != nil {
+		t.Fatalf("FormatHTML returned error: %v", err)
+	}
+	if !strings.Contains(htmlOutput, "Cost Estimate (USD per month)") || !strings.Contains(htmlOutput, "Unpriced (1)") {
+		t.Errorf("Expected HTML output to contain the cost estimate")
+	}
+
+	resources.Costs.Resources = map[string]model.ResourceCost{`aws_instance.web["<a>"]`: {Before: 8, After: 70}}
+	resources.Costs.Unpriced[0].Address = `aws_lambda_function.api["<b>"]`
+	htmlOutput, err = FormatHTML(resources, Options{})
+	if err != nil {
+		t.Fatalf("FormatHTML returned error: %v", err)
+	}
+	if strings.Contains(htmlOutput, "<a>") || strings.Contains(htmlOutput, "<b>") ||
+		!strings.Contains(htmlOutput, "<td>aws_instance.web[&#34;&lt;a&gt;&#34;]</td>") {
+		t.Errorf("Expected the addresses in the cost estimate to be escaped, got:\n%s", htmlOutput)
+	}
+}
+
+func TestFormatAcknowledged(t *testing.T) {
+	resources := model.NewResourceCollection()
+	resources.AddResource(model.ActionCreate, "aws_s3_bucket.new")
+	resources.Acknowledged["aws_instance.web"] = &model.ResourceChange{
+		Address: "aws_instance.web",
+		Actions: []model.Action{model.ActionUpdate},
+	}
+
+	result, err := FormatText(resources, Options{Verbose: true})
+	if err != nil {
+		t.Fatalf("FormatText returned an error: %v", err)
+	}
+	for _, phrase := range []string{"ACKNOWLEDGED BY BASELINE", "= aws_instance.web (update)", "(1 resource acknowledged by baseline)"} {
+		if !strings.Contains(result, phrase) {
+			t.Errorf("Expected verbose output to contain %q, got:\n%s", phrase, result)
+		}
+	}
+
+	jsonResult, err := FormatJSON(resources)
+	if err != nil {
+		t.Fatalf("FormatJSON returned an error: %v", err)
+	}
+	var output struct {
+		Acknowledged []struct {
+			Address string `json:"address"`
+			Action  string `json:"action"`
+		} `json:"acknowledged"`
+	}
+	if err := json.Unmarshal([]byte(jsonResult), &output); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if len(output.Acknowledged) != 1 || output.Acknowledged[0].Address != "aws_instance.web" || output.Acknowledged[0].Action != "update" {
+		t.Errorf("Expected the acknowledged change in the JSON output, got %+v", output.Acknowledged)
+	}
+
+	for name, format := range map[string]func(*model.ResourceCollection, Options) (string, error){"HTML": FormatHTML, "Markdown": FormatMarkdown} {
+		if result, _ := format(resources, Options{}); !strings.Contains(result, "1 resource acknowledged by baseline") {
+			t.Errorf("Expected the %s output to note the acknowledged change, got:\n%s", name, result)
+		}
+	}
+}
+
+func TestFormatStacks(t *testing.T) {
+	network := model.NewResourceCollection()
+	network.AddResource(model.ActionCreate, "aws_vpc.main")
+	network.HasDetailedResources = true
+	network.SummaryAdds = 1
+
+	app := model.NewResourceCollection()
+	app.AddResource(model.ActionDestroy, "aws_instance.web")
+	app.HasDetailedResources = true
+	app.SummaryDestroys = 1
+
+	report := &model.StackReport{Stacks: []model.Stack{
+		{Name: "network", Path: "network/tfplan.json", Resources: network},
+		{Name: "app", Path: "app/tfplan.json", Resources: app},
+		{Name: "broken", Path: "broken/tfplan.json", Err: errors.New("invalid plan")},
+	}}
+
+	result, err := FormatStacksText(report, Options{})
+	if err != nil {
+		t.Fatalf("FormatStacksText returned an error: %v", err)
+	}
+	for _, phrase := range []string{
+		"STACKS (3):",
+		"network  1 to add, 0 to change, 0 to destroy",
+		"broken   ✗ failed: invalid plan",
+		"GRAND TOTAL: 2 changes in 2 stacks (1 to add, 0 to change, 1 to destroy)",
+		"1 stack failed to parse",
+		"=== STACK: app (app/tfplan.json) ===",
+		"- aws_instance.web",
+	} {
+		if !strings.Contains(result, phrase) {
+			t.Errorf("Expected output to contain %q, got:\n%s", phrase, result)
+		}
+	}
+
+	jsonResult, err := FormatStacksJSON(report)
+	if err != nil {
+		t.Fatalf("FormatStacksJSON returned an error: %v", err)
+	}
+	var output struct {
+		Stacks []struct {
+			Name   string   `json:"name"`
+			Error  string   `json:"error"`
+			Create []string `json:"create"`
+		} `json:"stacks"`
+		Summary struct {
+			Failed int `json:"failed"`
+			Total  int `json:"total"`
+		} `json:"summary"`
+	}
+	if err := json.Unmarshal([]byte(jsonResult), &output); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if len(output.Stacks) != 3 || output.Summary.Failed != 1 || output.Summary.Total != 2 {
+		t.Errorf("Unexpected JSON summary: %+v", output)
+	}
+	if len(output.Stacks[0].Create) != 1 || output.Stacks[2].Error != "invalid plan" {
+		t.Errorf("Unexpected JSON stacks: %+v", output.Stacks)
+	}
+
+	htmlResult, err := FormatStacksHTML(report, Options{})
+	if err != nil {
+		t.Fatalf("FormatStacksHTML returned an error: %v", err)
+	}
+	if !strings.Contains(htmlResult, `<a href="#stack-2">app</a>`) || !strings.Contains(htmlResult, `<tr class="failed">`) {
+		t.Errorf("Expected the stack overview in the HTML output")
+	}
+
+	markdownResult, err := FormatStacksMarkdown(report, Options{})
+	if err != nil {
+		t.Fatalf("FormatStacksMarkdown returned an error: %v", err)
+	}
+	for _, phrase := range []string{"| network | 1 | 0 | 0 |", "| broken | ❌ failed: invalid plan |", "### app"} {
+		if !strings.Contains(markdownResult, phrase) {
+			t.Errorf("Expected Markdown output to contain %q, got:\n%s", phrase, markdownResult)
+		}
+	}
+}
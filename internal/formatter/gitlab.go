@@ -0,0 +1,36 @@
+package formatter
+
+import (
+	"encoding/json"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+// gitLabReport is the Terraform report read by the GitLab merge request widget
+type gitLabReport struct {
+	Create int `json:"create"`
+	Update int `json:"update"`
+	Delete int `json:"delete"`
+}
+
+// FormatGitLab formats the number of changes as a GitLab Terraform report. Like the
+// conversion in the GitLab documentation, a replacement counts as a create and a delete.
+func FormatGitLab(resources *model.ResourceCollection) (string, error) {
+	return formatGitLabReport(gitLabReport{resources.SummaryAdds, resources.SummaryChanges, resources.SummaryDestroys})
+}
+
+// FormatStacksGitLab formats the number of changes of all stacks as one GitLab Terraform report
+func FormatStacksGitLab(report *model.StackReport) (string, error) {
+	adds, changes, destroys := report.Totals()
+	return formatGitLabReport(gitLabReport{adds, changes, destroys})
+}
+
+// formatGitLabReport encodes a GitLab Terraform report
+func formatGitLabReport(report gitLabReport) (string, error) {
+	jsonBytes, err := json.Marshal(report)
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonBytes) + "\n", nil
+}
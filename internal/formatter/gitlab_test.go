@@ -0,0 +1,37 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+func TestFormatGitLab(t *testing.T) {
+	resources := model.NewResourceCollection()
+	resources.AddResourceChange(&model.ResourceChange{Address: "aws_db_instance.main", Actions: []model.Action{model.ActionDestroy, model.ActionCreate}})
+	resources.AddResourceChange(&model.ResourceChange{Address: "aws_instance.web", Actions: []model.Action{model.ActionUpdate}})
+	resources.HasDetailedResources = true
+	resources.SummaryAdds = resources.CountResourcesForAction(model.ActionCreate)
+	resources.SummaryChanges = resources.CountResourcesForAction(model.ActionUpdate)
+	resources.SummaryDestroys = resources.CountResourcesForAction(model.ActionDestroy)
+
+	result, err := FormatGitLab(resources)
+	if err != nil {
+		t.Fatalf("FormatGitLab returned an error: %v", err)
+	}
+
+	// The replacement counts as a create and a delete
+	expected := `{"create":1,"update":1,"delete":1}` + "\n"
+	if result != expected {
+		t.Errorf("Expected %q, got %q", expected, result)
+	}
+
+	stacks := &model.StackReport{Stacks: []model.Stack{{Name: "a", Resources: resources}, {Name: "b", Resources: resources}}}
+	result, err = FormatStacksGitLab(stacks)
+	if err != nil {
+		t.Fatalf("FormatStacksGitLab returned an error: %v", err)
+	}
+	if expected := `{"create":2,"update":2,"delete":2}` + "\n"; result != expected {
+		t.Errorf("Expected %q, got %q", expected, result)
+	}
+}
@@ -0,0 +1,170 @@
+package formatter
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+// junitTestSuites is the root element of a JUnit XML report
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+// junitTestSuite holds the test cases of one plan
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+// junitTestCase is a check that passed, or failed with a failure or error
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+// junitProblem describes why a test case failed
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// FormatJUnit formats the plan as a JUnit XML report for the test dashboards of CI systems.
+// Every destroyed or replaced resource becomes a test case, as does every policy violation
+// that isn't about one of them. A test case fails when it has a blocking violation.
+func FormatJUnit(resources *model.ResourceCollection) (string, error) {
+	return formatJUnitReport([]junitTestSuite{buildJUnitSuite("terraform plan", resources)})
+}
+
+// FormatStacksJUnit formats a report of many stacks as JUnit XML with a test suite per stack.
+// A stack whose plan failed to parse has a single test case with an error.
+func FormatStacksJUnit(report *model.StackReport) (string, error) {
+	suites := make([]junitTestSuite, 0, len(report.Stacks))
+	for _, stack := range report.Stacks {
+		if stack.Err != nil {
+			suites = append(suites, junitTestSuite{
+				Name:   stack.Name,
+				Tests:  1,
+				Errors: 1,
+				Cases: []junitTestCase{{
+					Name:      "parse " + stack.Path,
+					ClassName: "plan",
+					Error:     &junitProblem{Message: "plan failed to parse", Type: "parse", Text: stack.Err.Error()},
+				}},
+			})
+			continue
+		}
+		suites = append(suites, buildJUnitSuite(stack.Name, stack.Resources))
+	}
+	return formatJUnitReport(suites)
+}
+
+// buildJUnitSuite builds the test cases of a plan: the plan summary, which always passes,
+// then the destroyed and replaced resources, then the remaining policy violations
+func buildJUnitSuite(name string, resources *model.ResourceCollection) junitTestSuite {
+	suite := junitTestSuite{Name: name}
+
+	suite.Cases = append(suite.Cases, junitTestCase{
+		Name:      "plan summary",
+		ClassName: "plan",
+		SystemOut: fmt.Sprintf("Plan: %s.", formatStackCounts(resources.SummaryAdds, resources.SummaryChanges, resources.SummaryDestroys)),
+	})
+
+	byAddress := map[string][]model.PolicyViolation{}
+	for _, v := range resources.Violations {
+		byAddress[v.Address] = append(byAddress[v.Address], v)
+	}
+
+	for _, address := range resources.GetResourcesForAction(model.ActionDestroy) {
+		verb := "destroyed"
+		if resources.GetResourceChange(address).IsReplacement() {
+			verb = "replaced"
+		}
+
+		notes := []string{fmt.Sprintf("%s will be %s", address, verb)}
+		if resources.IsDangerous(address) {
+			notes = append(notes, strings.TrimPrefix(dangerMarker(resources, address), "⚠ "))
+		}
+		testCase := junitTestCase{Name: address, ClassName: model.ExtractResourceType(address)}
+		addJUnitViolations(&testCase, byAddress[address], notes)
+		delete(byAddress, address)
+		suite.Cases = append(suite.Cases, testCase)
+	}
+
+	// Violations about the plan as a whole or about created and updated resources
+	for _, v := range resources.Violations {
+		if _, ok := byAddress[v.Address]; !ok {
+			continue
+		}
+		name := v.Rule
+		if v.Address != "" {
+			name += " " + v.Address
+		}
+		testCase := junitTestCase{Name: name, ClassName: "policy"}
+		addJUnitViolations(&testCase, []model.PolicyViolation{v}, nil)
+		suite.Cases = append(suite.Cases, testCase)
+	}
+
+	suite.Tests = len(suite.Cases)
+	for _, testCase := range suite.Cases {
+		if testCase.Failure != nil {
+			suite.Failures++
+		}
+	}
+	return suite
+}
+
+// addJUnitViolations fails a test case on its blocking violations and notes the others in
+// its output
+func addJUnitViolations(testCase *junitTestCase, violations []model.PolicyViolation, notes []string) {
+	var failures, rules []string
+	for _, v := range violations {
+		line := fmt.Sprintf("%s: %s%s", v.Rule, v.Message, exemptionNote(v))
+		if v.IsBlocking() {
+			failures = append(failures, line)
+			rules = append(rules, v.Rule)
+		} else {
+			notes = append(notes, fmt.Sprintf("%s (%s)", line, v.Severity))
+		}
+	}
+
+	if len(failures) > 0 {
+		testCase.Failure = &junitProblem{
+			Message: failures[0],
+			Type:    strings.Join(rules, ","),
+			Text:    strings.Join(failures, "\n"),
+		}
+	}
+	testCase.SystemOut = strings.Join(notes, "\n")
+}
+
+// formatJUnitReport encodes the test suites with their totals
+func formatJUnitReport(suites []junitTestSuite) (string, error) {
+	report := junitTestSuites{Name: "terraform-plan-filter", Suites: suites}
+	for _, suite := range suites {
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Errors += suite.Errors
+	}
+
+	xmlBytes, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return xml.Header + string(xmlBytes) + "\n", nil
+}
@@ -0,0 +1,96 @@
+package formatter
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+// junitPlan returns a plan with a blocked replacement, an exempted destroy and a plan-level violation
+func junitPlan() *model.ResourceCollection {
+	resources := model.NewResourceCollection()
+	resources.AddResourceChange(&model.ResourceChange{Address: "aws_db_instance.main", Actions: []model.Action{model.ActionDestroy, model.ActionCreate}})
+	resources.AddResourceChange(&model.ResourceChange{Address: "aws_s3_bucket.logs", Actions: []model.Action{model.ActionDestroy}})
+	resources.AddResourceChange(&model.ResourceChange{Address: "aws_instance.web", Actions: []model.Action{model.ActionCreate}})
+	resources.Dangerous["aws_db_instance.main"] = struct{}{}
+	resources.HasDetailedResources = true
+	resources.SummaryAdds = 2
+	resources.SummaryDestroys = 2
+	resources.Violations = []model.PolicyViolation{
+		{Rule: "no-replace", Severity: model.SeverityError, Address: "aws_db_instance.main", Message: "aws_db_instance.main will be replaced", ExitCode: 6},
+		{Rule: "no-destroy", Severity: model.SeverityError, Address: "aws_s3_bucket.logs", Message: "aws_s3_bucket.logs will be destroyed", ExitCode: 5,
+			Exemption: &model.PolicyExemption{Owner: "team-data", Expires: "2099-01-01"}},
+		{Rule: "max-changes", Severity: model.SeverityError, Message: "4 changes exceed the limit of 3", ExitCode: 3},
+	}
+	return resources
+}
+
+func TestFormatJUnit(t *testing.T) {
+	result, err := FormatJUnit(junitPlan())
+	if err != nil {
+		t.Fatalf("FormatJUnit returned an error: %v", err)
+	}
+	if !strings.HasPrefix(result, xml.Header) {
+		t.Errorf("Expected an XML declaration, got:\n%s", result)
+	}
+
+	var report junitTestSuites
+	if err := xml.Unmarshal([]byte(result), &report); err != nil {
+		t.Fatalf("Expected valid XML: %v", err)
+	}
+	if report.Tests != 4 || report.Failures != 2 || len(report.Suites) != 1 {
+		t.Fatalf("Expected 4 tests with 2 failures in one suite, got %d tests, %d failures, %d suites",
+			report.Tests, report.Failures, len(report.Suites))
+	}
+
+	cases := map[string]junitTestCase{}
+	for _, testCase := range report.Suites[0].Cases {
+		cases[testCase.Name] = testCase
+	}
+
+	db := cases["aws_db_instance.main"]
+	if db.ClassName != "aws_db_instance" || db.Failure == nil || db.Failure.Type != "no-replace" {
+		t.Errorf("Expected the replaced database to fail on no-replace, got %+v", db)
+	}
+	if !strings.Contains(db.SystemOut, "DANGER: stateful resource will be replaced") {
+		t.Errorf("Expected the danger marker in the output, got %q", db.SystemOut)
+	}
+
+	bucket := cases["aws_s3_bucket.logs"]
+	if bucket.Failure != nil || !strings.Contains(bucket.SystemOut, "exempted by team-data") {
+		t.Errorf("Expected the exempted destroy to pass with a note, got %+v", bucket)
+	}
+
+	if limit := cases["max-changes"]; limit.ClassName != "policy" || limit.Failure == nil {
+		t.Errorf("Expected the plan-level violation to fail as a policy test case, got %+v", limit)
+	}
+	if _, ok := cases["plan summary"]; !ok {
+		t.Errorf("Expected a plan summary test case")
+	}
+}
+
+func TestFormatStacksJUnit(t *testing.T) {
+	stacks := &model.StackReport{Stacks: []model.Stack{
+		{Name: "prod", Path: "prod/plan.json", Resources: junitPlan()},
+		{Name: "dev", Path: "dev/plan.json", Err: fmt.Errorf("invalid character")},
+	}}
+
+	result, err := FormatStacksJUnit(stacks)
+	if err != nil {
+		t.Fatalf("FormatStacksJUnit returned an error: %v", err)
+	}
+
+	var report junitTestSuites
+	if err := xml.Unmarshal([]byte(result), &report); err != nil {
+		t.Fatalf("Expected valid XML: %v", err)
+	}
+	if len(report.Suites) != 2 || report.Suites[0].Name != "prod" || report.Tests != 5 || report.Errors != 1 {
+		t.Fatalf("Expected a suite per stack with 5 tests and 1 error, got:\n%s", result)
+	}
+	if dev := report.Suites[1].Cases[0]; dev.Error == nil || dev.Error.Text != "invalid character" {
+		t.Errorf("Expected the failed stack to have an error, got %+v", dev)
+	}
+}
@@ -0,0 +1,328 @@
+package formatter
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+// MaxMarkdownLength is the longest comment GitHub accepts. Markdown output is
+// truncated to stay below it.
+const MaxMarkdownLength = 65536
+
+// markdownReserve is kept free for closing tags and the truncation note
+const markdownReserve = 1024
+
+// markdownActions lists the action sections of the Markdown output with their emoji
+var markdownActions = []struct {
+	action model.Action
+	label  string
+	emoji  string
+}{
+	{model.ActionCreate, "Create", "🟢"},
+	{model.ActionUpdate, "Update", "🟡"},
+	{model.ActionDestroy, "Destroy", "🔴"},
+}
+
+// markdownBuilder collects Markdown output up to a length limit and counts the
+// resources left out once the limit is reached
+type markdownBuilder struct {
+	strings.Builder
+	limit   int
+	omitted int
+}
+
+// fits checks if text can be added while leaving room for closing the output
+func (b *markdownBuilder) fits(text string) bool {
+	return b.Len()+len(text) <= b.limit-markdownReserve
+}
+
+// truncated checks if resources were already left out
+func (b *markdownBuilder) truncated() bool {
+	return b.omitted > 0
+}
+
+// writeTruncationNote notes how many resources were left out
+func (b *markdownBuilder) writeTruncationNote() {
+	if !b.truncated() {
+		return
+	}
+	noun := "resources"
+	if b.omitted == 1 {
+		noun = "resource"
+	}
+	fmt.Fprintf(b, "\n> ✂️ **%d more %s not shown** to stay within the comment size limit.\n", b.omitted, noun)
+}
+
+// FormatMarkdown formats the resource collection as Markdown for pull-request comments:
+// a table of counts followed by collapsible sections per action and resource type.
+// The output stays below MaxMarkdownLength by leaving out resources.
+func FormatMarkdown(resources *model.ResourceCollection, opts Options) (string, error) {
+	b := &markdownBuilder{limit: MaxMarkdownLength}
+
+	b.WriteString("## 📋 Terraform Plan Summary\n\n")
+	writeMarkdownResources(b, resources, opts)
+	b.writeTruncationNote()
+
+	return b.String(), nil
+}
+
+// writeMarkdownResources writes the counts, findings and resources of one plan
+func writeMarkdownResources(b *markdownBuilder, resources *model.ResourceCollection, opts Options) {
+	if len(resources.Dangerous) > 0 {
+		fmt.Fprintf(b, "> ⚠️ **DANGER:** %s with stateful data will be destroyed or replaced\n\n",
+			pluralizeResources(len(resources.Dangerous)))
+	}
+
+	writeMarkdownCounts(b, resources)
+
+	// Findings come before the long resource lists so reviewers see them first
+	writeMarkdownViolations(b, resources.Violations)
+	writeMarkdownSecurityFindings(b, resources.SecurityFindings)
+	writeMarkdownCost(b, resources.Costs)
+
+	if !resources.HasDetailedResources {
+		return
+	}
+	for _, section := range markdownActions {
+		writeMarkdownAction(b, resources, section.action, section.emoji+" "+section.label, opts)
+	}
+}
+
+// writeMarkdownCounts writes the table of changes per action, the risk score and the notes
+// on resources left out of the output
+func writeMarkdownCounts(b *markdownBuilder, resources *model.ResourceCollection) {
+	b.WriteString("| Action | Resources |\n")
+	b.WriteString("| :--- | ---: |\n")
+	counts := []int{resources.SummaryAdds, resources.SummaryChanges, resources.SummaryDestroys}
+	for i, section := range markdownActions {
+		fmt.Fprintf(b, "| %s %s | %d |\n", section.emoji, section.label, counts[i])
+	}
+	fmt.Fprintf(b, "| **Total** | **%d** |\n\n", resources.TotalChanges())
+
+	if resources.RiskLevel != "" {
+		fmt.Fprintf(b, "**Risk score:** %d (%s)\n\n", resources.RiskScore, resources.RiskLevel)
+	}
+
+	var notes []string
+	if resources.IgnoredResources > 0 {
+		notes = append(notes, pluralizeResources(resources.IgnoredResources)+" ignored by ignore rules")
+	}
+	if len(resources.Suppressed) > 0 {
+		notes = append(notes, pluralizeResources(len(resources.Suppressed))+" suppressed as no-op by ignored attributes")
+	}
+	if len(resources.Acknowledged) > 0 {
+		notes = append(notes, pluralizeResources(len(resources.Acknowledged))+" acknowledged by baseline")
+	}
+	if resources.HiddenResources > 0 {
+		notes = append(notes, pluralizeResources(resources.HiddenResources)+" hidden by filters")
+	}
+	if len(notes) > 0 {
+		fmt.Fprintf(b, "_%s_\n\n", strings.Join(notes, ", "))
+	}
+}
+
+// writeMarkdownViolations writes the policy violations
+func writeMarkdownViolations(b *markdownBuilder, violations []model.PolicyViolation) {
+	if len(violations) == 0 {
+		return
+	}
+
+	items := make([]string, 0, len(violations))
+	for _, v := range violations {
+		items = append(items, fmt.Sprintf("%s `%s` %s%s", markdownViolationSymbol(v), v.Rule,
+			html.EscapeString(v.Message), html.EscapeString(exemptionNote(v))))
+	}
+	writeMarkdownList(b, fmt.Sprintf("### 🚦 Policy violations (%d)", len(violations)), items)
+}
+
+// markdownViolationSymbol returns the emoji for a violation based on its severity and exemption
+func markdownViolationSymbol(v model.PolicyViolation) string {
+	if v.Exemption != nil && !v.Exemption.Expired {
+		return "⚪"
+	}
+
+	switch v.Severity {
+	case model.SeverityWarn:
+		return "⚠️"
+	case model.SeverityInfo:
+		return "ℹ️"
+	default:
+		return "❌"
+	}
+}
+
+// writeMarkdownSecurityFindings writes the security-sensitive changes
+func writeMarkdownSecurityFindings(b *markdownBuilder, findings []model.SecurityFinding) {
+	if len(findings) == 0 {
+		return
+	}
+
+	items := make([]string, 0, len(findings))
+	for _, finding := range findings {
+		items = append(items, fmt.Sprintf("`%s` %s", finding.Detector, html.EscapeString(finding.Message)))
+	}
+	writeMarkdownList(b, fmt.Sprintf("### 🔐 Security findings (%d)", len(findings)), items)
+}
+
+// writeMarkdownList writes an optional heading and a bulleted list cut short at the length limit
+func writeMarkdownList(b *markdownBuilder, heading string, items []string) {
+	if heading != "" {
+		b.WriteString(heading + "\n\n")
+	}
+	for i, item := range items {
+		line := "- " + item + "\n"
+		if !b.fits(line) {
+			fmt.Fprintf(b, "- … and %d more\n", len(items)-i)
+			break
+		}
+		b.WriteString(line)
+	}
+	b.WriteString("\n")
+}
+
+// writeMarkdownCost writes the cost estimate as a table
+func writeMarkdownCost(b *markdownBuilder, costs *model.CostReport) {
+	if costs == nil {
+		return
+	}
+
+	fmt.Fprintf(b, "### 💰 Cost estimate (%s per month)\n\n", costs.Currency)
+	b.WriteString("| Resource | Before | After | Change |\n")
+	b.WriteString("| :--- | ---: | ---: | ---: |\n")
+	for _, address := range costChangedAddresses(costs) {
+		c := costs.Resources[address]
+		row := fmt.Sprintf("| `%s` | %.2f | %.2f | %+.2f |\n", address, c.Before, c.After, c.Delta())
+		if !b.fits(row) {
+			break
+		}
+		b.WriteString(row)
+	}
+	fmt.Fprintf(b, "| **Total** | **%.2f** | **%.2f** | **%+.2f** |\n\n", costs.MonthlyBefore, costs.MonthlyAfter, costs.Delta())
+
+	if len(costs.Unpriced) > 0 {
+		items := make([]string, 0, len(costs.Unpriced))
+		for _, unpriced := range costs.Unpriced {
+			items = append(items, fmt.Sprintf("`%s` (%s)", unpriced.Address, html.EscapeString(unpriced.Reason)))
+		}
+		fmt.Fprintf(b, "<details><summary>Unpriced (%d)</summary>\n\n", len(costs.Unpriced))
+		writeMarkdownList(b, "", items)
+		b.WriteString("</details>\n\n")
+	}
+}
+
+// writeMarkdownAction writes a collapsible section with the resources of an action,
+// grouped by type in nested sections unless they are sorted by risk
+func writeMarkdownAction(b *markdownBuilder, resources *model.ResourceCollection, action model.Action, title string, opts Options) {
+	actionResources := resources.GetResourcesForAction(action)
+	if len(actionResources) == 0 {
+		return
+	}
+	if b.truncated() {
+		b.omitted += len(actionResources)
+		return
+	}
+
+	fmt.Fprintf(b, "<details><summary><b>%s</b> (%d)</summary>\n\n", title, len(actionResources))
+
+	if opts.SortByRisk {
+		writeMarkdownResourceList(b, resources, sortByRisk(resources, actionResources), opts)
+	} else {
+		typeMap := resources.ResourcesByType(action)
+		types := getSortedResourceTypes(typeMap)
+		if hasModuleResources(typeMap) {
+			types = append([]string{"module"}, filterOutModuleType(types)...)
+		}
+
+		for _, resourceType := range types {
+			typeResources := typeMap[resourceType]
+			if b.truncated() {
+				b.omitted += len(typeResources)
+				continue
+			}
+
+			label := "<code>" + resourceType + "</code>"
+			if resourceType == "module" {
+				label = "module resources"
+			}
+			fmt.Fprintf(b, "<details><summary>%s (%d)</summary>\n\n", label, len(typeResources))
+			writeMarkdownResourceList(b, resources, typeResources, opts)
+			b.WriteString("</details>\n\n")
+		}
+	}
+
+	b.WriteString("</details>\n\n")
+}
+
+// writeMarkdownResourceList writes one list entry per resource, counting the resources
+// that no longer fit as omitted
+func writeMarkdownResourceList(b *markdownBuilder, resources *model.ResourceCollection, addresses []string, opts Options) {
+	for _, address := range addresses {
+		entry := markdownResourceEntry(resources, address, opts)
+		if b.truncated() || !b.fits(entry) {
+			b.omitted++
+			continue
+		}
+		b.WriteString(entry)
+	}
+	b.WriteString("\n")
+}
+
+// markdownResourceEntry formats a resource as a list entry, followed by a diff of its
+// attributes when requested
+func markdownResourceEntry(resources *model.ResourceCollection, address string, opts Options) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "- `%s`", address)
+	if opts.SortByRisk {
+		fmt.Fprintf(&sb, " (risk %d)", resources.RiskScores[address])
+	}
+	if resources.IsDangerous(address) {
+		fmt.Fprintf(&sb, " **%s**", strings.Replace(dangerMarker(resources, address), "⚠", "⚠️", 1))
+	}
+	sb.WriteString("\n")
+
+	if change, ok := resources.Changes[address]; ok && opts.AttributeDiffs {
+		if lines := markdownAttributeDiff(change); len(lines) > 0 {
+			sb.WriteString("\n  ```diff\n")
+			for _, line := range lines {
+				sb.WriteString("  " + line + "\n")
+			}
+			sb.WriteString("  ```\n")
+		}
+	}
+
+	return sb.String()
+}
+
+// markdownAttributeDiff returns the changed attributes of a resource as diff lines, with the
+// values of sensitive attributes redacted
+func markdownAttributeDiff(change *model.ResourceChange) []string {
+	var lines []string
+
+	for _, path := range change.ChangedAttributes() {
+		segments := model.ParseAttributePath(path)
+		sensitive := change.AttributeSensitive(path)
+		if before, ok := model.LookupAttribute(change.Before, segments); ok && before != nil {
+			value := formatDiffValue(before, false)
+			if sensitive {
+				value = model.SensitiveValue
+			}
+			lines = append(lines, fmt.Sprintf("- %s = %s", path, value))
+		}
+
+		unknown, _ := model.LookupAttribute(change.AfterUnknown, segments)
+		after, ok := model.LookupAttribute(change.After, segments)
+		if unknown == true || (ok && after != nil) {
+			value := formatDiffValue(after, unknown == true)
+			if sensitive && unknown != true {
+				value = model.SensitiveValue
+			}
+			lines = append(lines, fmt.Sprintf("+ %s = %s", path, value))
+		}
+	}
+
+	return lines
+}
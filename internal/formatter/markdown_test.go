@@ -0,0 +1,113 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+func TestFormatMarkdown(t *testing.T) {
+	resources := model.NewResourceCollection()
+	resources.AddResourceChange(&model.ResourceChange{
+		Address: "aws_instance.web", Type: "aws_instance", Name: "web",
+		Actions: []model.Action{model.ActionUpdate},
+		Before:  map[string]interface{}{"instance_type": "t3.micro"},
+		After:   map[string]interface{}{"instance_type": "m5.large"},
+	})
+	resources.AddResource(model.ActionCreate, "aws_s3_bucket.logs")
+	resources.HasDetailedResources = true
+	resources.SummaryAdds, resources.SummaryChanges = 1, 1
+	resources.Violations = []model.PolicyViolation{{Rule: "max-changes", Severity: model.SeverityWarn, Message: "2 changes exceed the maximum of 1"}}
+
+	result, err := FormatMarkdown(resources, Options{AttributeDiffs: true})
+	if err != nil {
+		t.Fatalf("FormatMarkdown returned an error: %v", err)
+	}
+
+	for _, phrase := range []string{
+		"| 🟢 Create | 1 |",
+		"| **Total** | **2** |",
+		"### 🚦 Policy violations (1)",
+		"- ⚠️ `max-changes` 2 changes exceed the maximum of 1",
+		"<details><summary><b>🟡 Update</b> (1)</summary>",
+		"<details><summary><code>aws_instance</code> (1)</summary>",
+		"- `aws_instance.web`",
+		"```diff\n  - instance_type = \"t3.micro\"\n  + instance_type = \"m5.large\"\n  ```",
+	} {
+		if !strings.Contains(result, phrase) {
+			t.Errorf("Expected Markdown output to contain %q, got:\n%s", phrase, result)
+		}
+	}
+	if strings.Count(result, "<details>") != strings.Count(result, "</details>") {
+		t.Errorf("Expected every <details> section to be closed")
+	}
+
+	result, err = FormatMarkdown(resources, Options{})
+	if err != nil {
+		t.Fatalf("FormatMarkdown returned an error: %v", err)
+	}
+	if strings.Contains(result, "```diff") {
+		t.Errorf("Expected attribute diffs only when requested")
+	}
+}
+
+func TestFormatMarkdownSensitiveAttributes(t *testing.T) {
+	resources := model.NewResourceCollection()
+	resources.AddResourceChange(&model.ResourceChange{
+		Address: "aws_db_instance.main", Type: "aws_db_instance", Name: "main",
+		Actions:         []model.Action{model.ActionUpdate},
+		Before:          map[string]interface{}{"password": "old-secret", "connection": map[string]interface{}{"token": "old-token"}, "port": 5432.0},
+		After:           map[string]interface{}{"password": "new-secret-123", "connection": map[string]interface{}{"token": "new-token"}, "port": 5433.0},
+		BeforeSensitive: map[string]interface{}{"password": true, "connection": true},
+		AfterSensitive:  map[string]interface{}{"password": true, "connection": true},
+	})
+	resources.HasDetailedResources = true
+
+	result, err := FormatMarkdown(resources, Options{AttributeDiffs: true})
+	if err != nil {
+		t.Fatalf("FormatMarkdown returned an error: %v", err)
+	}
+
+	for _, secret := range []string{"old-secret", "new-secret-123", "old-token", "new-token"} {
+		if strings.Contains(result, secret) {
+			t.Errorf("Expected the sensitive value %q to be redacted, got:\n%s", secret, result)
+		}
+	}
+	for _, phrase := range []string{
+		"- password = (sensitive)\n  + password = (sensitive)",
+		"- connection.token = (sensitive)\n  + connection.token = (sensitive)",
+		"- port = 5432\n  + port = 5433",
+	} {
+		if !strings.Contains(result, phrase) {
+			t.Errorf("Expected Markdown output to contain %q, got:\n%s", phrase, result)
+		}
+	}
+}
+
+func TestFormatMarkdownTruncation(t *testing.T) {
+	resources := model.NewResourceCollection()
+	for i := 0; i < 3000; i++ {
+		resources.AddResource(model.ActionCreate, fmt.Sprintf("module.app_%d.aws_security_group_rule.allow_https_from_load_balancer", i))
+		resources.AddResource(model.ActionDestroy, fmt.Sprintf("aws_instance.old_%d", i))
+	}
+	resources.HasDetailedResources = true
+
+	result, err := FormatMarkdown(resources, Options{})
+	if err != nil {
+		t.Fatalf("FormatMarkdown returned an error: %v", err)
+	}
+
+	if len(result) > MaxMarkdownLength {
+		t.Errorf("Expected at most %d characters, got %d", MaxMarkdownLength, len(result))
+	}
+	shown := strings.Count(result, "\n- `")
+	note := fmt.Sprintf("**%d more resources not shown**", 6000-shown)
+	if !strings.Contains(result, note) {
+		t.Errorf("Expected the truncation note %q for %d shown resources", note, shown)
+	}
+	if strings.Count(result, "<details>") != strings.Count(result, "</details>") {
+		t.Errorf("Expected every <details> section to be closed after truncation")
+	}
+}
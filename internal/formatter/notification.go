@@ -0,0 +1,162 @@
+package formatter
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+// maxTopDestroys is how many destroyed resources chat notifications list
+const maxTopDestroys = 5
+
+// notification is the short plan summary sent to chat tools, shared by the Slack and
+// Teams payloads
+type notification struct {
+	title        string
+	adds         int
+	changes      int
+	destroys     int
+	risk         string         // Risk score and level, empty without scoring
+	dangerous    int            // Stateful resources destroyed or replaced
+	violations   int            // Blocking policy violations
+	stacks       []string       // One line per stack when several plans were combined
+	topDestroys  []destroyEntry // The riskiest destroyed or replaced resources, dangerous ones first
+	moreDestroys int            // Destroyed resources not in topDestroys
+	reportURL    string
+}
+
+// destroyEntry is a destroyed or replaced resource considered for the top destroys
+type destroyEntry struct {
+	stack     string
+	address   string
+	replace   bool
+	dangerous bool
+	risk      int
+}
+
+// describe formats the resource for a list, quoting the address for chat tools that
+// support inline code
+func (e destroyEntry) describe(quote string) string {
+	text := quote + e.address + quote
+	if e.stack != "" {
+		text = e.stack + ": " + text
+	}
+	if e.replace {
+		text += " (replace)"
+	}
+	if e.dangerous {
+		text += " ⚠️ stateful"
+	}
+	return text
+}
+
+// newNotification summarizes a plan for a chat notification
+func newNotification(resources *model.ResourceCollection, opts Options) *notification {
+	n := &notification{
+		title:      "Terraform Plan Summary",
+		adds:       resources.SummaryAdds,
+		changes:    resources.SummaryChanges,
+		destroys:   resources.SummaryDestroys,
+		dangerous:  len(resources.Dangerous),
+		violations: countBlocking(resources.Violations),
+		reportURL:  opts.ReportURL,
+	}
+	if resources.RiskLevel != "" {
+		n.risk = fmt.Sprintf("%d (%s)", resources.RiskScore, resources.RiskLevel)
+	}
+
+	n.setTopDestroys(destroyEntries("", resources))
+	return n
+}
+
+// newStacksNotification summarizes the plans of many stacks for a chat notification
+func newStacksNotification(report *model.StackReport, opts Options) *notification {
+	adds, changes, destroys := report.Totals()
+	n := &notification{
+		title:      fmt.Sprintf("Terraform Plan Summary (%d %s)", len(report.Stacks), pluralizeStacks(len(report.Stacks))),
+		adds:       adds,
+		changes:    changes,
+		destroys:   destroys,
+		violations: countBlocking(report.Violations()),
+		reportURL:  opts.ReportURL,
+	}
+
+	var entries []destroyEntry
+	for _, stack := range report.Stacks {
+		if stack.Err != nil {
+			n.stacks = append(n.stacks, fmt.Sprintf("%s: failed to parse", stack.Name))
+			continue
+		}
+		resources := stack.Resources
+		n.stacks = append(n.stacks, fmt.Sprintf("%s: %s", stack.Name,
+			formatStackCounts(resources.SummaryAdds, resources.SummaryChanges, resources.SummaryDestroys)))
+		n.dangerous += len(resources.Dangerous)
+		entries = append(entries, destroyEntries(stack.Name, resources)...)
+	}
+
+	n.setTopDestroys(entries)
+	return n
+}
+
+// destroyEntries returns the destroyed and replaced resources of a plan, labeled with the
+// stack name if there is one
+func destroyEntries(stack string, resources *model.ResourceCollection) []destroyEntry {
+	var entries []destroyEntry
+	for _, address := range resources.GetResourcesForAction(model.ActionDestroy) {
+		entries = append(entries, destroyEntry{
+			stack:     stack,
+			address:   address,
+			replace:   resources.GetResourceChange(address).IsReplacement(),
+			dangerous: resources.IsDangerous(address),
+			risk:      resources.RiskScores[address],
+		})
+	}
+	return entries
+}
+
+// setTopDestroys keeps the riskiest destroyed resources, dangerous ones first
+func (n *notification) setTopDestroys(entries []destroyEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].dangerous != entries[j].dangerous {
+			return entries[i].dangerous
+		}
+		if entries[i].risk != entries[j].risk {
+			return entries[i].risk > entries[j].risk
+		}
+		if entries[i].stack != entries[j].stack {
+			return entries[i].stack < entries[j].stack
+		}
+		return entries[i].address < entries[j].address
+	})
+
+	if len(entries) > maxTopDestroys {
+		n.moreDestroys = len(entries) - maxTopDestroys
+		entries = entries[:maxTopDestroys]
+	}
+	n.topDestroys = entries
+}
+
+// summary describes the changes in one line, used where chat tools need plain text
+func (n *notification) summary() string {
+	return fmt.Sprintf("Terraform plan: %s", formatStackCounts(n.adds, n.changes, n.destroys))
+}
+
+// countBlocking counts the violations that fail the run
+func countBlocking(violations []model.PolicyViolation) int {
+	count := 0
+	for _, v := range violations {
+		if v.IsBlocking() {
+			count++
+		}
+	}
+	return count
+}
+
+// pluralizeBlocking describes a number of blocking violations
+func pluralizeBlocking(count int) string {
+	if count == 1 {
+		return "1 blocking policy violation"
+	}
+	return fmt.Sprintf("%d blocking policy violations", count)
+}
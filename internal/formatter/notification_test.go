@@ -0,0 +1,138 @@
+package formatter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+// notificationPlan returns a plan destroying seven buckets and replacing a database
+func notificationPlan() *model.ResourceCollection {
+	resources := model.NewResourceCollection()
+	for i := 1; i <= 7; i++ {
+		resources.AddResourceChange(&model.ResourceChange{Address: fmt.Sprintf("aws_s3_bucket.b%d", i), Actions: []model.Action{model.ActionDestroy}})
+	}
+	resources.AddResourceChange(&model.ResourceChange{Address: "aws_db_instance.<main>", Actions: []model.Action{model.ActionDestroy, model.ActionCreate}})
+	resources.AddResourceChange(&model.ResourceChange{Address: "aws_instance.web", Actions: []model.Action{model.ActionCreate}})
+	resources.Dangerous["aws_db_instance.<main>"] = struct{}{}
+	resources.RiskScores["aws_s3_bucket.b7"] = 40
+	resources.HasDetailedResources = true
+	resources.SummaryAdds = resources.CountResourcesForAction(model.ActionCreate)
+	resources.SummaryDestroys = resources.CountResourcesForAction(model.ActionDestroy)
+	resources.Violations = []model.PolicyViolation{{Rule: "no-destroy", Message: "destroys", ExitCode: 5}}
+	return resources
+}
+
+func TestNotificationTopDestroys(t *testing.T) {
+	n := newNotification(notificationPlan(), Options{})
+
+	if len(n.topDestroys) != maxTopDestroys || n.moreDestroys != 3 {
+		t.Fatalf("Expected %d top destroys and 3 more, got %d and %d", maxTopDestroys, len(n.topDestroys), n.moreDestroys)
+	}
+	// The stateful resource comes first, then the highest risk score, then by address
+	expected := []string{"aws_db_instance.<main>", "aws_s3_bucket.b7", "aws_s3_bucket.b1", "aws_s3_bucket.b2", "aws_s3_bucket.b3"}
+	for i, entry := range n.topDestroys {
+		if entry.address != expected[i] {
+			t.Errorf("Expected top destroy %d to be %s, got %s", i, expected[i], entry.address)
+		}
+	}
+	if n.violations != 1 || n.dangerous != 1 {
+		t.Errorf("Expected 1 violation and 1 dangerous resource, got %d and %d", n.violations, n.dangerous)
+	}
+}
+
+func TestFormatSlack(t *testing.T) {
+	result, err := FormatSlack(notificationPlan(), Options{ReportURL: "https://ci.example.com/run/1"})
+	if err != nil {
+		t.Fatalf("FormatSlack returned an error: %v", err)
+	}
+
+	var message slackMessage
+	if err := json.Unmarshal([]byte(result), &message); err != nil {
+		t.Fatalf("Expected valid JSON: %v", err)
+	}
+	if message.Text != "Terraform plan: 2 to add, 0 to change, 8 to destroy" {
+		t.Errorf("Unexpected fallback text %q", message.Text)
+	}
+
+	var types []string
+	for _, block := range message.Blocks {
+		types = append(types, block.Type)
+	}
+	if got := strings.Join(types, ","); got != "header,section,section,section,actions" {
+		t.Errorf("Unexpected blocks %s", got)
+	}
+
+	var texts []string
+	for _, block := range message.Blocks {
+		if block.Text != nil {
+			texts = append(texts, block.Text.Text)
+		}
+		for _, field := range block.Fields {
+			texts = append(texts, field.Text)
+		}
+	}
+	text := strings.Join(texts, "\n")
+	for _, expected := range []string{
+		"*🔴 Destroy*\n8",
+		"*DANGER:* 1 resource with stateful data",
+		"1 blocking policy violation",
+		"• `aws_db_instance.&lt;main&gt;` (replace) ⚠️ stateful",
+		"_… and 3 more_",
+	} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("Expected Slack message to contain %q, got:\n%s", expected, text)
+		}
+	}
+	if !strings.Contains(result, `"url": "https://ci.example.com/run/1"`) {
+		t.Errorf("Expected a button linking to the report, got:\n%s", result)
+	}
+}
+
+func TestFormatTeams(t *testing.T) {
+	resources := notificationPlan()
+	stacks := &model.StackReport{Stacks: []model.Stack{
+		{Name: "prod", Resources: resources},
+		{Name: "dev", Err: fmt.Errorf("invalid plan")},
+	}}
+
+	result, err := FormatStacksTeams(stacks, Options{ReportURL: "https://ci.example.com/run/1"})
+	if err != nil {
+		t.Fatalf("FormatStacksTeams returned an error: %v", err)
+	}
+
+	var message teamsMessage
+	if err := json.Unmarshal([]byte(result), &message); err != nil {
+		t.Fatalf("Expected valid JSON: %v", err)
+	}
+	if len(message.Attachments) != 1 || message.Attachments[0].ContentType != "application/vnd.microsoft.card.adaptive" {
+		t.Fatalf("Expected one Adaptive Card attachment, got %+v", message.Attachments)
+	}
+
+	card := message.Attachments[0].Content
+	if card.Body[0].Text != "Terraform Plan Summary (2 stacks)" {
+		t.Errorf("Unexpected title %q", card.Body[0].Text)
+	}
+	if len(card.Actions) != 1 || card.Actions[0].URL != "https://ci.example.com/run/1" {
+		t.Errorf("Expected a link to the report, got %+v", card.Actions)
+	}
+
+	for _, expected := range []string{
+		"- prod: 2 to add, 0 to change, 8 to destroy\n- dev: failed to parse",
+		"prod: aws_db_instance.<main> (replace) ⚠️ stateful",
+		"… and 3 more",
+	} {
+		found := false
+		for _, element := range card.Body {
+			if strings.Contains(element.Text, expected) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Expected card to contain %q, got:\n%s", expected, result)
+		}
+	}
+}
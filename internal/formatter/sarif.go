@@ -0,0 +1,228 @@
+package formatter
+
+import (
+	"encoding/json"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+// SARIF levels of results
+const (
+	sarifError   = "error"
+	sarifWarning = "warning"
+	sarifNote    = "note"
+)
+
+// sarifLog is the root of a SARIF 2.1.0 file
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+// sarifRun holds the rules and results of one analysis
+type sarifRun struct {
+	Tool struct {
+		Driver struct {
+			Name           string      `json:"name"`
+			InformationURI string      `json:"informationUri"`
+			Rules          []sarifRule `json:"rules"`
+		} `json:"driver"`
+	} `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+// sarifRule describes a policy rule or security detector
+type sarifRule struct {
+	ID                   string       `json:"id"`
+	ShortDescription     sarifMessage `json:"shortDescription"`
+	DefaultConfiguration struct {
+		Level string `json:"level"`
+	} `json:"defaultConfiguration"`
+}
+
+// sarifMessage is the text of a message or description
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+// sarifResult is a policy violation or security finding
+type sarifResult struct {
+	RuleID       string             `json:"ruleId"`
+	RuleIndex    int                `json:"ruleIndex"`
+	Level        string             `json:"level"`
+	Message      sarifMessage       `json:"message"`
+	Locations    []sarifLocation    `json:"locations,omitempty"`
+	Suppressions []sarifSuppression `json:"suppressions,omitempty"`
+}
+
+// sarifLocation points at the resource block in the configuration, when it is known,
+// and names the resource
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+// sarifPhysicalLocation is a line of a file
+type sarifPhysicalLocation struct {
+	ArtifactLocation struct {
+		URI string `json:"uri"`
+	} `json:"artifactLocation"`
+	Region struct {
+		StartLine int `json:"startLine"`
+	} `json:"region"`
+}
+
+// sarifLogicalLocation is a resource address
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// sarifSuppression marks a violation covered by a policy exemption
+type sarifSuppression struct {
+	Kind          string `json:"kind"`
+	Justification string `json:"justification"`
+}
+
+// FormatSARIF formats the policy violations and security findings as SARIF 2.1.0 for code
+// scanning. Results point at the resource block when its location was looked up.
+func FormatSARIF(resources *model.ResourceCollection) (string, error) {
+	b := newSARIFBuilder()
+	b.addResults("", resources)
+	return b.format()
+}
+
+// FormatStacksSARIF formats the violations and findings of many stacks as one SARIF run,
+// with the stack name in front of each message
+func FormatStacksSARIF(report *model.StackReport) (string, error) {
+	b := newSARIFBuilder()
+	for _, stack := range report.Stacks {
+		if stack.Resources != nil {
+			b.addResults(stack.Name, stack.Resources)
+		}
+	}
+	return b.format()
+}
+
+// sarifBuilder collects the results of a run and the rules they refer to
+type sarifBuilder struct {
+	run   sarifRun
+	rules map[string]int // Index of each rule by ID
+}
+
+// newSARIFBuilder creates a builder for a run of this tool
+func newSARIFBuilder() *sarifBuilder {
+	b := &sarifBuilder{rules: map[string]int{}}
+	b.run.Tool.Driver.Name = "terraform-plan-filter"
+	b.run.Tool.Driver.InformationURI = "https://github.com/marc-poljak/terraform-plan-filter"
+	b.run.Tool.Driver.Rules = []sarifRule{}
+	b.run.Results = []sarifResult{}
+	return b
+}
+
+// addResults adds the violations and findings of a plan
+func (b *sarifBuilder) addResults(stack string, resources *model.ResourceCollection) {
+	prefix := ""
+	if stack != "" {
+		prefix = stack + ": "
+	}
+
+	for _, v := range resources.Violations {
+		result := sarifResult{
+			RuleID:  "policy/" + v.Rule,
+			Level:   sarifViolationLevel(v),
+			Message: sarifMessage{prefix + v.Message + exemptionNote(v)},
+		}
+		result.RuleIndex = b.rule(result.RuleID, "Policy rule "+v.Rule, sarifSeverityLevel(v.Severity))
+		if v.Address != "" {
+			result.Locations = sarifLocations(resources, v.Address)
+		}
+		if v.Exemption != nil && !v.Exemption.Expired {
+			justification := "Exempted by " + v.Exemption.Owner + " until " + v.Exemption.Expires
+			if v.Exemption.Reason != "" {
+				justification += ": " + v.Exemption.Reason
+			}
+			result.Suppressions = []sarifSuppression{{Kind: "external", Justification: justification}}
+		}
+		b.run.Results = append(b.run.Results, result)
+	}
+
+	for _, finding := range resources.SecurityFindings {
+		result := sarifResult{
+			RuleID:    "security/" + finding.Detector,
+			Level:     sarifWarning,
+			Message:   sarifMessage{prefix + finding.Message},
+			Locations: sarifLocations(resources, finding.Address),
+		}
+		result.RuleIndex = b.rule(result.RuleID, "Security-sensitive change: "+finding.Detector, sarifWarning)
+		b.run.Results = append(b.run.Results, result)
+	}
+}
+
+// rule returns the index of a rule, adding it on first use
+func (b *sarifBuilder) rule(id, description, level string) int {
+	if index, ok := b.rules[id]; ok {
+		return index
+	}
+
+	rule := sarifRule{ID: id, ShortDescription: sarifMessage{description}}
+	rule.DefaultConfiguration.Level = level
+	b.rules[id] = len(b.run.Tool.Driver.Rules)
+	b.run.Tool.Driver.Rules = append(b.run.Tool.Driver.Rules, rule)
+	return b.rules[id]
+}
+
+// format encodes the run as a SARIF log
+func (b *sarifBuilder) format() (string, error) {
+	log := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{b.run},
+	}
+
+	jsonBytes, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonBytes) + "\n", nil
+}
+
+// sarifLocations returns the location of a resource: its block in the configuration if
+// known, and its address
+func sarifLocations(resources *model.ResourceCollection, address string) []sarifLocation {
+	location := sarifLocation{LogicalLocations: []sarifLogicalLocation{{FullyQualifiedName: address, Kind: "resource"}}}
+
+	if source, ok := resources.Locations[address]; ok {
+		location.PhysicalLocation = &sarifPhysicalLocation{}
+		location.PhysicalLocation.ArtifactLocation.URI = source.File
+		location.PhysicalLocation.Region.StartLine = source.Line
+	}
+
+	return []sarifLocation{location}
+}
+
+// sarifViolationLevel returns the level of a violation: an error when it fails the run,
+// otherwise the level of its severity
+func sarifViolationLevel(v model.PolicyViolation) string {
+	if v.IsBlocking() {
+		return sarifError
+	}
+	if v.Severity == model.SeverityError {
+		return sarifWarning
+	}
+	return sarifSeverityLevel(v.Severity)
+}
+
+// sarifSeverityLevel maps a policy severity to a SARIF level
+func sarifSeverityLevel(severity model.Severity) string {
+	switch severity {
+	case model.SeverityWarn:
+		return sarifWarning
+	case model.SeverityInfo:
+		return sarifNote
+	default:
+		return sarifError
+	}
+}
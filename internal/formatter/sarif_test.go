@@ -0,0 +1,89 @@
+package formatter
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+func TestFormatSARIF(t *testing.T) {
+	resources := junitPlan()
+	resources.SecurityFindings = []model.SecurityFinding{
+		{Detector: "open-ingress", Address: "aws_security_group.web", Message: "ingress opened to 0.0.0.0/0"},
+		{Detector: "open-ingress", Address: "aws_security_group.db", Message: "ingress opened to ::/0"},
+	}
+	resources.Locations = map[string]model.SourceLocation{"aws_db_instance.main": {File: "modules/db/main.tf", Line: 12}}
+
+	result, err := FormatSARIF(resources)
+	if err != nil {
+		t.Fatalf("FormatSARIF returned an error: %v", err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal([]byte(result), &log); err != nil {
+		t.Fatalf("Expected valid JSON: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("Expected one SARIF 2.1.0 run, got:\n%s", result)
+	}
+
+	run := log.Runs[0]
+	if len(run.Tool.Driver.Rules) != 4 {
+		t.Errorf("Expected the 3 policy rules and the detector once each, got %+v", run.Tool.Driver.Rules)
+	}
+	if len(run.Results) != 5 {
+		t.Fatalf("Expected 5 results, got %d", len(run.Results))
+	}
+
+	replace := run.Results[0]
+	if replace.RuleID != "policy/no-replace" || replace.Level != "error" {
+		t.Errorf("Expected a blocking no-replace error, got %+v", replace)
+	}
+	physical := replace.Locations[0].PhysicalLocation
+	if physical == nil || physical.ArtifactLocation.URI != "modules/db/main.tf" || physical.Region.StartLine != 12 {
+		t.Errorf("Expected the resource block location, got %+v", physical)
+	}
+
+	exempted := run.Results[1]
+	if exempted.Level != "warning" || len(exempted.Suppressions) != 1 || exempted.Locations[0].PhysicalLocation != nil {
+		t.Errorf("Expected an exempted warning without a physical location, got %+v", exempted)
+	}
+
+	if limit := run.Results[2]; limit.Locations != nil {
+		t.Errorf("Expected the plan-level violation without a location, got %+v", limit.Locations)
+	}
+
+	for _, finding := range run.Results[3:] {
+		if finding.RuleID != "security/open-ingress" || finding.RuleIndex != 3 || finding.Level != "warning" {
+			t.Errorf("Expected an open-ingress warning, got %+v", finding)
+		}
+	}
+	if name := run.Results[4].Locations[0].LogicalLocations[0].FullyQualifiedName; name != "aws_security_group.db" {
+		t.Errorf("Expected the resource address as logical location, got %q", name)
+	}
+}
+
+func TestFormatStacksSARIF(t *testing.T) {
+	stacks := &model.StackReport{Stacks: []model.Stack{
+		{Name: "prod", Resources: junitPlan()},
+		{Name: "dev", Resources: junitPlan()},
+	}}
+
+	result, err := FormatStacksSARIF(stacks)
+	if err != nil {
+		t.Fatalf("FormatStacksSARIF returned an error: %v", err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal([]byte(result), &log); err != nil {
+		t.Fatalf("Expected valid JSON: %v", err)
+	}
+	run := log.Runs[0]
+	if len(run.Results) != 6 || len(run.Tool.Driver.Rules) != 3 {
+		t.Fatalf("Expected 6 results of 3 rules in one run, got %d results and %d rules", len(run.Results), len(run.Tool.Driver.Rules))
+	}
+	if message := run.Results[3].Message.Text; message != "dev: aws_db_instance.main will be replaced" {
+		t.Errorf("Expected the stack name in the message, got %q", message)
+	}
+}
@@ -0,0 +1,141 @@
+package formatter
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// JSONSchema returns the JSON Schema of the JSON and YAML output, generated from the types
+// the output is encoded from. The desc tags of their fields become the descriptions.
+func JSONSchema() (string, error) {
+	g := &schemaGenerator{defs: map[string]interface{}{}}
+
+	schema := map[string]interface{}{
+		"$schema":     "https://json-schema.org/draft/2020-12/schema",
+		"title":       "terraform-plan-filter output",
+		"description": "JSON and YAML output of terraform-plan-filter for a single plan or for many stacks",
+		"oneOf": []interface{}{
+			g.typeSchema(reflect.TypeOf(jsonDocument{})),
+			g.typeSchema(reflect.TypeOf(jsonStacksDocument{})),
+		},
+		"$defs": g.defs,
+	}
+
+	jsonBytes, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonBytes) + "\n", nil
+}
+
+// schemaGenerator collects the definitions of the named types while building a schema
+type schemaGenerator struct {
+	defs map[string]interface{}
+}
+
+// typeSchema returns the schema of a type. Named structs are defined once under $defs
+// and referenced from where they are used.
+func (g *schemaGenerator) typeSchema(t reflect.Type) map[string]interface{} {
+	if t == reflect.TypeOf(time.Time{}) {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return g.typeSchema(t.Elem())
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		name := schemaDefName(t)
+		if _, ok := g.defs[name]; !ok {
+			g.defs[name] = nil // Reserved before recursing into the fields
+			g.defs[name] = g.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/$defs/" + name}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": g.typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.typeSchema(t.Elem())}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// structSchema returns the schema of a struct as an object. Fields without omitempty are
+// required, and the fields of embedded structs are inlined like encoding/json does.
+func (g *schemaGenerator) structSchema(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := []string{}
+	g.addFields(t, properties, &required, true)
+
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+// addFields adds the properties of the exported fields of a struct. The fields of a struct
+// embedded by pointer are left out when it is nil, so they are never required.
+func (g *schemaGenerator) addFields(t reflect.Type, properties map[string]interface{}, required *[]string, present bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous {
+			if field.Type.Kind() == reflect.Ptr {
+				g.addFields(field.Type.Elem(), properties, required, false)
+			} else {
+				g.addFields(field.Type, properties, required, present)
+			}
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		name, options, _ := strings.Cut(tag, ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := g.typeSchema(field.Type)
+		omitempty := strings.Contains(options, "omitempty")
+		if !omitempty {
+			if present {
+				*required = append(*required, name)
+			}
+			// encoding/json writes nil slices, maps and pointers as null
+			if kind := field.Type.Kind(); kind == reflect.Slice || kind == reflect.Map || kind == reflect.Ptr {
+				property = map[string]interface{}{"anyOf": []interface{}{property, map[string]interface{}{"type": "null"}}}
+			}
+		}
+		if name == "schema_version" {
+			property = map[string]interface{}{"type": "integer", "const": JSONSchemaVersion}
+		}
+		if desc := field.Tag.Get("desc"); desc != "" {
+			property["description"] = desc
+		}
+		properties[name] = property
+	}
+}
+
+// schemaDefName returns the name of a type in $defs, e.g. violation for jsonViolation
+func schemaDefName(t reflect.Type) string {
+	name := []rune(strings.TrimPrefix(t.Name(), "json"))
+	name[0] = unicode.ToLower(name[0])
+	return string(name)
+}
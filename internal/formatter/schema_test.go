@@ -0,0 +1,223 @@
+package formatter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+	"gopkg.in/yaml.v3"
+)
+
+// updateSchema rewrites the published schema file from the Go types
+var updateSchema = flag.Bool("update-schema", false, "Rewrite schema/report.schema.json")
+
+// schemaFile is the published JSON Schema of the output
+const schemaFile = "../../schema/report.schema.json"
+
+// schemaFingerprints holds the SHA-256 of the generated schema for every released schema
+// version. A change to the output types changes the fingerprint, so it needs a new version.
+var schemaFingerprints = map[int]string{
+	1: "da692d9595bc2de089f00d3021cd9f4df683b79f11d3191d6002469feb40ff96",
+}
+
+func TestJSONSchemaVersion(t *testing.T) {
+	schema, err := JSONSchema()
+	if err != nil {
+		t.Fatalf("JSONSchema returned an error: %v", err)
+	}
+
+	sum := sha256.Sum256([]byte(schema))
+	fingerprint := hex.EncodeToString(sum[:])
+	if schemaFingerprints[JSONSchemaVersion] != fingerprint {
+		t.Errorf("The output schema changed without a version bump. Increase JSONSchemaVersion, add "+
+			"%d: %q to schemaFingerprints and run go test ./internal/formatter -update-schema", JSONSchemaVersion+1, fingerprint)
+	}
+}
+
+func TestJSONSchemaFile(t *testing.T) {
+	schema, err := JSONSchema()
+	if err != nil {
+		t.Fatalf("JSONSchema returned an error: %v", err)
+	}
+
+	if *updateSchema {
+		if err := os.WriteFile(schemaFile, []byte(schema), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	published, err := os.ReadFile(schemaFile)
+	if err != nil {
+		t.Fatalf("Error reading the published schema: %v", err)
+	}
+	if string(published) != schema {
+		t.Errorf("%s is out of date, run go test ./internal/formatter -update-schema", schemaFile)
+	}
+}
+
+func TestOutputMatchesSchema(t *testing.T) {
+	var schema map[string]interface{}
+	text, _ := JSONSchema()
+	if err := json.Unmarshal([]byte(text), &schema); err != nil {
+		t.Fatalf("Expected the schema to be valid JSON: %v", err)
+	}
+
+	resources := junitPlan()
+	resources.Acknowledged["aws_iam_role.ci"] = &model.ResourceChange{Address: "aws_iam_role.ci", Actions: []model.Action{model.ActionCreate}}
+	resources.Suppressed["aws_instance.db"] = &model.ResourceChange{Address: "aws_instance.db"}
+	resources.SecurityFindings = []model.SecurityFinding{{Detector: "open-ingress", Address: "aws_security_group.web", Message: "open"}}
+	resources.Costs = &model.CostReport{
+		Currency:  "USD",
+		Resources: map[string]model.ResourceCost{"aws_db_instance.main": {Before: 10, After: 20}},
+		Unpriced:  []model.UnpricedResource{{Address: "aws_s3_bucket.logs", Reason: "no price"}},
+	}
+	stacks := &model.StackReport{Stacks: []model.Stack{
+		{Name: "prod", Path: "prod/plan.json", Resources: resources},
+		{Name: "dev", Path: "dev/plan.json", Err: fmt.Errorf("invalid plan")},
+	}}
+
+	outputs := map[string]func() (string, error){
+		"json":        func() (string, error) { return FormatJSON(resources) },
+		"empty json":  func() (string, error) { return FormatJSON(model.NewResourceCollection()) },
+		"stacks json": func() (string, error) { return FormatStacksJSON(stacks) },
+		"yaml":        func() (string, error) { return FormatYAML(resources) },
+		"stacks yaml": func() (string, error) { return FormatStacksYAML(stacks) },
+	}
+	for name, format := range outputs {
+		output, err := format()
+		if err != nil {
+			t.Fatalf("%s: format returned an error: %v", name, err)
+		}
+
+		var document interface{}
+		if err := yaml.Unmarshal([]byte(output), &document); err != nil {
+			t.Fatalf("%s: error parsing output: %v", name, err)
+		}
+		if err := validateSchema(schema, schema, normalizeYAML(document), "$"); err != nil {
+			t.Errorf("%s: output does not match the schema: %v", name, err)
+		}
+	}
+}
+
+func TestOutputReproducible(t *testing.T) {
+	stacks := &model.StackReport{Stacks: []model.Stack{{Name: "prod", Path: "prod/plan.json", Resources: junitPlan()}}}
+	outputs := map[string]func() (string, error){
+		"json":        func() (string, error) { return FormatJSON(junitPlan()) },
+		"stacks json": func() (string, error) { return FormatStacksJSON(stacks) },
+		"yaml":        func() (string, error) { return FormatYAML(junitPlan()) },
+		"stacks yaml": func() (string, error) { return FormatStacksYAML(stacks) },
+	}
+	for name, format := range outputs {
+		first, _ := format()
+		time.Sleep(time.Millisecond)
+		if second, _ := format(); first != second {
+			t.Errorf("%s: expected the same output for the same plan, got:\n%s\nand:\n%s", name, first, second)
+		}
+	}
+}
+
+// normalizeYAML converts decoded YAML to the types encoding/json decodes into
+func normalizeYAML(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, nested := range v {
+			v[key] = normalizeYAML(nested)
+		}
+	case []interface{}:
+		for i, nested := range v {
+			v[i] = normalizeYAML(nested)
+		}
+	case int:
+		return float64(v)
+	}
+	return value
+}
+
+// validateSchema checks a decoded document against the keywords JSONSchema generates
+func validateSchema(root, schema map[string]interface{}, value interface{}, path string) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		defs := root["$defs"].(map[string]interface{})
+		return validateSchema(root, defs[ref[len("#/$defs/"):]].(map[string]interface{}), value, path)
+	}
+	for _, keyword := range []string{"oneOf", "anyOf"} {
+		if options, ok := schema[keyword].([]interface{}); ok {
+			var errs []error
+			for _, option := range options {
+				err := validateSchema(root, option.(map[string]interface{}), value, path)
+				if err == nil {
+					return nil
+				}
+				errs = append(errs, err)
+			}
+			return fmt.Errorf("%s matches none of %s: %v", path, keyword, errs)
+		}
+	}
+	if expected, ok := schema["const"]; ok && value != expected {
+		return fmt.Errorf("%s is %v, expected %v", path, value, expected)
+	}
+
+	switch schema["type"] {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s is not an object", path)
+		}
+		for _, name := range asSlice(schema["required"]) {
+			if _, ok := object[name.(string)]; !ok {
+				return fmt.Errorf("%s lacks the required property %s", path, name)
+			}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		for key, nested := range object {
+			property, ok := properties[key].(map[string]interface{})
+			if !ok {
+				property, ok = schema["additionalProperties"].(map[string]interface{})
+			}
+			if !ok {
+				return fmt.Errorf("%s has the undocumented property %s", path, key)
+			}
+			if err := validateSchema(root, property, nested, path+"."+key); err != nil {
+				return err
+			}
+		}
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s is not an array", path)
+		}
+		for i, item := range array {
+			if err := validateSchema(root, schema["items"].(map[string]interface{}), item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			return fmt.Errorf("%s is not a string", path)
+		}
+	case "integer", "number":
+		if _, ok := value.(float64); !ok {
+			return fmt.Errorf("%s is not a number", path)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s is not a boolean", path)
+		}
+	case "null":
+		if value != nil {
+			return fmt.Errorf("%s is not null", path)
+		}
+	}
+	return nil
+}
+
+// asSlice returns a decoded JSON array, or nil for anything else
+func asSlice(value interface{}) []interface{} {
+	slice, _ := value.([]interface{})
+	return slice
+}
@@ -0,0 +1,118 @@
+package formatter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+// slackText is a text object of Slack Block Kit
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// slackBlock is a layout block of Slack Block Kit
+type slackBlock struct {
+	Type     string        `json:"type"`
+	Text     *slackText    `json:"text,omitempty"`
+	Fields   []slackText   `json:"fields,omitempty"`
+	Elements []interface{} `json:"elements,omitempty"`
+}
+
+// slackButton is a link button of an actions block
+type slackButton struct {
+	Type string    `json:"type"`
+	Text slackText `json:"text"`
+	URL  string    `json:"url"`
+}
+
+// slackMessage is the payload of a Slack incoming webhook
+type slackMessage struct {
+	Text   string       `json:"text"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+// FormatSlack formats a summary of the plan as a Slack Block Kit message for an incoming webhook
+func FormatSlack(resources *model.ResourceCollection, opts Options) (string, error) {
+	return formatSlackMessage(newNotification(resources, opts))
+}
+
+// FormatStacksSlack formats a summary of many stacks as a Slack Block Kit message
+func FormatStacksSlack(report *model.StackReport, opts Options) (string, error) {
+	return formatSlackMessage(newStacksNotification(report, opts))
+}
+
+// formatSlackMessage builds the blocks of a notification: the counts as fields, then the
+// warnings, the stacks, the top destroys and a button linking to the full report
+func formatSlackMessage(n *notification) (string, error) {
+	message := slackMessage{Text: n.summary()}
+
+	message.Blocks = append(message.Blocks, slackBlock{Type: "header", Text: &slackText{"plain_text", n.title}})
+
+	fields := []slackText{
+		{"mrkdwn", fmt.Sprintf("*🟢 Create*\n%d", n.adds)},
+		{"mrkdwn", fmt.Sprintf("*🟡 Update*\n%d", n.changes)},
+		{"mrkdwn", fmt.Sprintf("*🔴 Destroy*\n%d", n.destroys)},
+	}
+	if n.risk != "" {
+		fields = append(fields, slackText{"mrkdwn", "*Risk score*\n" + n.risk})
+	}
+	message.Blocks = append(message.Blocks, slackBlock{Type: "section", Fields: fields})
+
+	var warnings []string
+	if n.dangerous > 0 {
+		warnings = append(warnings, fmt.Sprintf("⚠️ *DANGER:* %s with stateful data will be destroyed or replaced", pluralizeResources(n.dangerous)))
+	}
+	if n.violations > 0 {
+		warnings = append(warnings, "🚦 *"+pluralizeBlocking(n.violations)+"*")
+	}
+	if len(warnings) > 0 {
+		message.Blocks = append(message.Blocks, slackSection(strings.Join(warnings, "\n")))
+	}
+
+	if len(n.stacks) > 0 {
+		lines := make([]string, 0, len(n.stacks))
+		for _, stack := range n.stacks {
+			lines = append(lines, "• "+escapeSlack(stack))
+		}
+		message.Blocks = append(message.Blocks, slackSection("*Stacks*\n"+strings.Join(lines, "\n")))
+	}
+
+	if len(n.topDestroys) > 0 {
+		lines := make([]string, 0, len(n.topDestroys)+1)
+		for _, entry := range n.topDestroys {
+			lines = append(lines, "• "+escapeSlack(entry.describe("`")))
+		}
+		if n.moreDestroys > 0 {
+			lines = append(lines, fmt.Sprintf("_… and %d more_", n.moreDestroys))
+		}
+		message.Blocks = append(message.Blocks, slackSection("*Top destroys*\n"+strings.Join(lines, "\n")))
+	}
+
+	if n.reportURL != "" {
+		message.Blocks = append(message.Blocks, slackBlock{
+			Type:     "actions",
+			Elements: []interface{}{slackButton{"button", slackText{"plain_text", "View full report"}, n.reportURL}},
+		})
+	}
+
+	jsonBytes, err := json.MarshalIndent(message, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonBytes) + "\n", nil
+}
+
+// slackSection returns a section block with Markdown text
+func slackSection(text string) slackBlock {
+	return slackBlock{Type: "section", Text: &slackText{"mrkdwn", text}}
+}
+
+// escapeSlack escapes the characters Slack treats as control characters in message text
+func escapeSlack(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
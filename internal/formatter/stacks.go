@@ -0,0 +1,234 @@
+package formatter
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+	"github.com/marc-poljak/terraform-plan-filter/internal/util"
+)
+
+// FormatStacksText formats a report of many stacks as colored text: an overview with the
+// changes of each stack and the grand total, followed by the full summary of each stack
+func FormatStacksText(report *model.StackReport, opts Options) (string, error) {
+	var sb strings.Builder
+
+	formatTextHeader(&sb, opts)
+
+	sb.WriteString(util.BoldText(fmt.Sprintf("STACKS (%d):", len(report.Stacks)), opts.UseColors))
+	sb.WriteString("\n")
+
+	width := 0
+	for _, stack := range report.Stacks {
+		if len(stack.Name) > width {
+			width = len(stack.Name)
+		}
+	}
+
+	for _, stack := range report.Stacks {
+		if stack.Err != nil {
+			fmt.Fprintf(&sb, "  %-*s  %s\n", width, stack.Name, util.ColorizeText("✗ failed: "+stack.Err.Error(), util.ColorRed, opts.UseColors))
+			continue
+		}
+
+		resources := stack.Resources
+		fmt.Fprintf(&sb, "  %-*s  %s", width, stack.Name, formatStackCounts(resources.SummaryAdds, resources.SummaryChanges, resources.SummaryDestroys))
+		if resources.RiskLevel != "" {
+			score := fmt.Sprintf("risk %d (%s)", resources.RiskScore, resources.RiskLevel)
+			fmt.Fprintf(&sb, "  %s", util.ColorizeText(score, riskColor(resources.RiskLevel), opts.UseColors))
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
+
+	adds, changes, destroys := report.Totals()
+	failed := len(report.Failed())
+	parsed := len(report.Stacks) - failed
+	fmt.Fprintf(&sb, "%s %d changes in %d %s (%s)\n", util.BoldText("GRAND TOTAL:", opts.UseColors),
+		report.TotalChanges(), parsed, pluralizeStacks(parsed), formatStackCounts(adds, changes, destroys))
+	if failed > 0 {
+		note := fmt.Sprintf("%d %s failed to parse", failed, pluralizeStacks(failed))
+		sb.WriteString(util.ColorizeText(note, util.ColorRed, opts.UseColors))
+		sb.WriteString("\n")
+	}
+
+	for _, stack := range report.Stacks {
+		if stack.Resources == nil {
+			continue
+		}
+		sb.WriteString("\n")
+		sb.WriteString(util.BoldText(fmt.Sprintf("=== STACK: %s (%s) ===", stack.Name, stack.Path), opts.UseColors))
+		sb.WriteString("\n\n")
+		formatResourcesText(&sb, stack.Resources, opts)
+	}
+
+	return sb.String(), nil
+}
+
+// formatStackCounts describes the changes of a stack like the plan summary line
+func formatStackCounts(adds, changes, destroys int) string {
+	return fmt.Sprintf("%d to add, %d to change, %d to destroy", adds, changes, destroys)
+}
+
+// pluralizeStacks returns the noun for a number of stacks
+func pluralizeStacks(count int) string {
+	if count == 1 {
+		return "stack"
+	}
+	return "stacks"
+}
+
+// jsonStack is the JSON representation of one stack, with the fields of the single plan output
+type jsonStack struct {
+	Name  string `json:"name" desc:"Label of the stack"`
+	Path  string `json:"path" desc:"Path of the plan file"`
+	Error string `json:"error,omitempty" desc:"Why the plan could not be read, in place of the plan fields"`
+	*jsonReport
+}
+
+// jsonStacksDocument is the JSON output for many stacks
+type jsonStacksDocument struct {
+	SchemaVersion int         `json:"schema_version" desc:"Version of the output schema"`
+	Stacks        []jsonStack `json:"stacks" desc:"Plans of the stacks in input order"`
+	Summary       struct {
+		Stacks   int `json:"stacks" desc:"Number of stacks"`
+		Failed   int `json:"failed" desc:"Number of stacks whose plans could not be read"`
+		Total    int `json:"total" desc:"Number of changes in all stacks"`
+		Adds     int `json:"adds" desc:"Number of resources to create in all stacks"`
+		Changes  int `json:"changes" desc:"Number of resources to update in all stacks"`
+		Destroys int `json:"destroys" desc:"Number of resources to destroy in all stacks"`
+	} `json:"summary" desc:"Counts of the changes in all stacks"`
+}
+
+// FormatStacksJSON formats a report of many stacks as JSON
+func FormatStacksJSON(report *model.StackReport) (string, error) {
+	jsonBytes, err := json.MarshalIndent(buildJSONStacksDocument(report), "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonBytes), nil
+}
+
+// buildJSONStacksDocument converts a report of many stacks into its JSON output
+func buildJSONStacksDocument(report *model.StackReport) *jsonStacksDocument {
+	output := &jsonStacksDocument{SchemaVersion: JSONSchemaVersion, Stacks: []jsonStack{}}
+	for _, stack := range report.Stacks {
+		js := jsonStack{Name: stack.Name, Path: stack.Path}
+		if stack.Err != nil {
+			js.Error = stack.Err.Error()
+		} else {
+			js.jsonReport = buildJSONReport(stack.Resources)
+		}
+		output.Stacks = append(output.Stacks, js)
+	}
+
+	output.Summary.Stacks = len(report.Stacks)
+	output.Summary.Failed = len(report.Failed())
+	output.Summary.Total = report.TotalChanges()
+	output.Summary.Adds, output.Summary.Changes, output.Summary.Destroys = report.Totals()
+
+	return output
+}
+
+// FormatStacksHTML formats a report of many stacks as HTML
+func FormatStacksHTML(report *model.StackReport, opts Options) (string, error) {
+	var sb strings.Builder
+
+	writeHTMLHeader(&sb)
+	sb.WriteString("    <h1>Terraform Plan Summary</h1>\n")
+
+	adds, changes, destroys := report.Totals()
+	failed := len(report.Failed())
+	parsed := len(report.Stacks) - failed
+	sb.WriteString("    <div class=\"summary\">\n")
+	fmt.Fprintf(&sb, "        <p><strong>Total changes:</strong> %d in %d %s (%s)</p>\n",
+		report.TotalChanges(), parsed, pluralizeStacks(parsed), formatStackCounts(adds, changes, destroys))
+	if failed > 0 {
+		fmt.Fprintf(&sb, "        <p class=\"hidden-note\">%d %s failed to parse</p>\n", failed, pluralizeStacks(failed))
+	}
+	sb.WriteString("    </div>\n")
+
+	sb.WriteString("    <table class=\"stacks\">\n")
+	sb.WriteString("        <tr><th>Stack</th><th>Add</th><th>Change</th><th>Destroy</th><th>Risk</th></tr>\n")
+	for i, stack := range report.Stacks {
+		if stack.Err != nil {
+			fmt.Fprintf(&sb, "        <tr class=\"failed\"><td>%s</td><td colspan=\"4\">%s</td></tr>\n",
+				html.EscapeString(stack.Name), html.EscapeString(stack.Err.Error()))
+			continue
+		}
+
+		resources := stack.Resources
+		risk := ""
+		if resources.RiskLevel != "" {
+			risk = fmt.Sprintf("<span class=\"risk-%s\">%d (%s)</span>", resources.RiskLevel, resources.RiskScore, resources.RiskLevel)
+		}
+		fmt.Fprintf(&sb, "        <tr><td><a href=\"#stack-%d\">%s</a></td><td>%d</td><td>%d</td><td>%d</td><td>%s</td></tr>\n",
+			i+1, html.EscapeString(stack.Name), resources.SummaryAdds, resources.SummaryChanges, resources.SummaryDestroys, risk)
+	}
+	sb.WriteString("    </table>\n")
+
+	for i, stack := range report.Stacks {
+		if stack.Resources == nil {
+			continue
+		}
+		fmt.Fprintf(&sb, "    <div class=\"stack\" id=\"stack-%d\">\n", i+1)
+		fmt.Fprintf(&sb, "    <h2 class=\"stack-title\">%s <span class=\"stack-path\">%s</span></h2>\n",
+			html.EscapeString(stack.Name), html.EscapeString(stack.Path))
+		writeHTMLResources(&sb, stack.Resources, opts)
+		sb.WriteString("    </div>\n")
+	}
+
+	writeHTMLFooter(&sb)
+
+	return sb.String(), nil
+}
+
+// FormatStacksMarkdown formats a report of many stacks as Markdown, with a table of the
+// changes of each stack followed by the summary of each stack
+func FormatStacksMarkdown(report *model.StackReport, opts Options) (string, error) {
+	b := &markdownBuilder{limit: MaxMarkdownLength}
+
+	b.WriteString("## 📋 Terraform Plan Summary\n\n")
+	b.WriteString("| Stack | 🟢 Create | 🟡 Update | 🔴 Destroy | Risk |\n")
+	b.WriteString("| :--- | ---: | ---: | ---: | :--- |\n")
+	for _, stack := range report.Stacks {
+		if stack.Err != nil {
+			fmt.Fprintf(b, "| %s | ❌ failed: %s | | | |\n", stack.Name, strings.ReplaceAll(html.EscapeString(stack.Err.Error()), "|", "\\|"))
+			continue
+		}
+
+		resources := stack.Resources
+		risk := ""
+		if resources.RiskLevel != "" {
+			risk = fmt.Sprintf("%d (%s)", resources.RiskScore, resources.RiskLevel)
+		}
+		fmt.Fprintf(b, "| %s | %d | %d | %d | %s |\n", stack.Name, resources.SummaryAdds, resources.SummaryChanges, resources.SummaryDestroys, risk)
+	}
+
+	adds, changes, destroys := report.Totals()
+	failed := len(report.Failed())
+	parsed := len(report.Stacks) - failed
+	fmt.Fprintf(b, "\n**Total:** %d changes in %d %s (%s)\n\n",
+		report.TotalChanges(), parsed, pluralizeStacks(parsed), formatStackCounts(adds, changes, destroys))
+	if failed > 0 {
+		fmt.Fprintf(b, "_%d %s failed to parse_\n\n", failed, pluralizeStacks(failed))
+	}
+
+	for _, stack := range report.Stacks {
+		if stack.Resources == nil {
+			continue
+		}
+		if b.truncated() {
+			b.omitted += len(stack.Resources.Addresses())
+			continue
+		}
+		fmt.Fprintf(b, "### %s\n\n", stack.Name)
+		writeMarkdownResources(b, stack.Resources, opts)
+	}
+	b.writeTruncationNote()
+
+	return b.String(), nil
+}
@@ -0,0 +1,137 @@
+package formatter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+// teamsElement is an element of an Adaptive Card body
+type teamsElement struct {
+	Type   string      `json:"type"`
+	Text   string      `json:"text,omitempty"`
+	Size   string      `json:"size,omitempty"`
+	Weight string      `json:"weight,omitempty"`
+	Color  string      `json:"color,omitempty"`
+	Wrap   bool        `json:"wrap,omitempty"`
+	Facts  []teamsFact `json:"facts,omitempty"`
+}
+
+// teamsFact is a title and value pair of a fact set
+type teamsFact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+// teamsAction opens a URL from the card
+type teamsAction struct {
+	Type  string `json:"type"`
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// teamsCard is an Adaptive Card
+type teamsCard struct {
+	Schema  string         `json:"$schema"`
+	Type    string         `json:"type"`
+	Version string         `json:"version"`
+	Body    []teamsElement `json:"body"`
+	Actions []teamsAction  `json:"actions,omitempty"`
+}
+
+// teamsAttachment wraps a card in a message
+type teamsAttachment struct {
+	ContentType string    `json:"contentType"`
+	Content     teamsCard `json:"content"`
+}
+
+// teamsMessage is the payload of a Teams incoming webhook or workflow, carrying one card
+type teamsMessage struct {
+	Type        string            `json:"type"`
+	Summary     string            `json:"summary"`
+	Attachments []teamsAttachment `json:"attachments"`
+}
+
+// FormatTeams formats a summary of the plan as a Microsoft Teams Adaptive Card message
+func FormatTeams(resources *model.ResourceCollection, opts Options) (string, error) {
+	return formatTeamsMessage(newNotification(resources, opts))
+}
+
+// FormatStacksTeams formats a summary of many stacks as a Microsoft Teams Adaptive Card message
+func FormatStacksTeams(report *model.StackReport, opts Options) (string, error) {
+	return formatTeamsMessage(newStacksNotification(report, opts))
+}
+
+// formatTeamsMessage builds the card of a notification: the counts as facts, then the
+// warnings, the stacks, the top destroys and a link to the full report
+func formatTeamsMessage(n *notification) (string, error) {
+	card := teamsCard{
+		Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+		Type:    "AdaptiveCard",
+		Version: "1.4",
+	}
+
+	card.Body = append(card.Body, teamsElement{Type: "TextBlock", Text: n.title, Size: "Large", Weight: "Bolder", Wrap: true})
+
+	facts := []teamsFact{
+		{"🟢 Create", fmt.Sprint(n.adds)},
+		{"🟡 Update", fmt.Sprint(n.changes)},
+		{"🔴 Destroy", fmt.Sprint(n.destroys)},
+	}
+	if n.risk != "" {
+		facts = append(facts, teamsFact{"Risk score", n.risk})
+	}
+	card.Body = append(card.Body, teamsElement{Type: "FactSet", Facts: facts})
+
+	if n.dangerous > 0 {
+		text := fmt.Sprintf("⚠️ **DANGER:** %s with stateful data will be destroyed or replaced", pluralizeResources(n.dangerous))
+		card.Body = append(card.Body, teamsElement{Type: "TextBlock", Text: text, Color: "Attention", Wrap: true})
+	}
+	if n.violations > 0 {
+		card.Body = append(card.Body, teamsElement{Type: "TextBlock", Text: "🚦 **" + pluralizeBlocking(n.violations) + "**", Color: "Attention", Wrap: true})
+	}
+
+	if len(n.stacks) > 0 {
+		card.Body = append(card.Body, teamsHeading("Stacks"), teamsList(n.stacks))
+	}
+
+	if len(n.topDestroys) > 0 {
+		items := make([]string, 0, len(n.topDestroys)+1)
+		for _, entry := range n.topDestroys {
+			items = append(items, entry.describe(""))
+		}
+		if n.moreDestroys > 0 {
+			items = append(items, fmt.Sprintf("… and %d more", n.moreDestroys))
+		}
+		card.Body = append(card.Body, teamsHeading("Top destroys"), teamsList(items))
+	}
+
+	if n.reportURL != "" {
+		card.Actions = []teamsAction{{Type: "Action.OpenUrl", Title: "View full report", URL: n.reportURL}}
+	}
+
+	message := teamsMessage{
+		Type:        "message",
+		Summary:     n.summary(),
+		Attachments: []teamsAttachment{{"application/vnd.microsoft.card.adaptive", card}},
+	}
+
+	jsonBytes, err := json.MarshalIndent(message, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return string(jsonBytes) + "\n", nil
+}
+
+// teamsHeading returns a bold text block
+func teamsHeading(text string) teamsElement {
+	return teamsElement{Type: "TextBlock", Text: text, Weight: "Bolder", Wrap: true}
+}
+
+// teamsList returns a text block with a bulleted list
+func teamsList(items []string) teamsElement {
+	return teamsElement{Type: "TextBlock", Text: "- " + strings.Join(items, "\n- "), Wrap: true}
+}
@@ -0,0 +1,170 @@
+package formatter
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	texttemplate "text/template"
+	"unicode/utf8"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+	"github.com/marc-poljak/terraform-plan-filter/internal/util"
+)
+
+// Template is a user-supplied Go template for custom output
+type Template struct {
+	execute func(w io.Writer, data interface{}) error
+}
+
+// templateColour is a colour of the colour helper as ANSI code and CSS style
+type templateColour struct {
+	ansi string
+	css  string
+}
+
+// templateColours lists the colours of the colour helper. The action names use the
+// colours of the text and HTML output.
+var templateColours = map[string]templateColour{
+	"red":     {util.ColorRed, "color: #e76f51"},
+	"green":   {util.ColorGreen, "color: #2a9d8f"},
+	"yellow":  {util.ColorYellow, "color: #e9c46a"},
+	"blue":    {util.ColorBlue, "color: #0366d6"},
+	"purple":  {util.ColorPurple, "color: #6f42c1"},
+	"cyan":    {util.ColorCyan, "color: #1b7c83"},
+	"white":   {util.ColorWhite, "color: #ffffff"},
+	"bold":    {util.ColorBold, "font-weight: bold"},
+	"create":  {util.ColorGreen, "color: #2a9d8f"},
+	"update":  {util.ColorYellow, "color: #e9c46a"},
+	"destroy": {util.ColorRed, "color: #e76f51"},
+	"replace": {util.ColorRed, "color: #e76f51"},
+}
+
+// ParseTemplate parses a user-supplied template. HTML templates are parsed with
+// html/template, which escapes the values they insert; all others with text/template.
+func ParseTemplate(name, text string, html bool, opts Options) (*Template, error) {
+	funcs := templateFuncs(html, opts)
+
+	if html {
+		tmpl, err := htmltemplate.New(name).Funcs(htmltemplate.FuncMap(funcs)).Parse(text)
+		if err != nil {
+			return nil, err
+		}
+		return &Template{execute: tmpl.Execute}, nil
+	}
+
+	tmpl, err := texttemplate.New(name).Funcs(funcs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return &Template{execute: tmpl.Execute}, nil
+}
+
+// FormatTemplate renders the resource collection through a user-supplied template. The
+// template sees the values of sensitive attributes as model.SensitiveValue.
+func FormatTemplate(resources *model.ResourceCollection, tmpl *Template) (string, error) {
+	return executeTemplate(tmpl, resources.Redacted())
+}
+
+// FormatStacksTemplate renders a report of many stacks through a user-supplied template,
+// with the values of sensitive attributes redacted
+func FormatStacksTemplate(report *model.StackReport, tmpl *Template) (string, error) {
+	redacted := &model.StackReport{Stacks: make([]model.Stack, len(report.Stacks))}
+	for i, stack := range report.Stacks {
+		redacted.Stacks[i] = stack
+		if stack.Resources != nil {
+			redacted.Stacks[i].Resources = stack.Resources.Redacted()
+		}
+	}
+	return executeTemplate(tmpl, redacted)
+}
+
+// executeTemplate renders the data through the template
+func executeTemplate(tmpl *Template, data interface{}) (string, error) {
+	var sb strings.Builder
+	if err := tmpl.execute(&sb, data); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// templateFuncs returns the helper functions available in templates
+func templateFuncs(html bool, opts Options) texttemplate.FuncMap {
+	return texttemplate.FuncMap{
+		"colour": func(name, text interface{}) (interface{}, error) {
+			colour, ok := templateColours[fmt.Sprint(name)]
+			if !ok {
+				return nil, fmt.Errorf("unknown colour %q", fmt.Sprint(name))
+			}
+			if html {
+				return htmltemplate.HTML(fmt.Sprintf(`<span style="%s">%s</span>`,
+					colour.css, htmltemplate.HTMLEscapeString(fmt.Sprint(text)))), nil
+			}
+			return util.ColorizeText(fmt.Sprint(text), colour.ansi, opts.UseColors), nil
+		},
+		"symbol":        templateSymbol,
+		"groupByType":   groupByType,
+		"groupByModule": groupByModule,
+		"count":         countAction,
+		"truncate":      truncateText,
+	}
+}
+
+// templateSymbol returns the symbol of an action as in the text output, -/+ for replacements
+func templateSymbol(action interface{}) string {
+	if fmt.Sprint(action) == "replace" {
+		return "-/+"
+	}
+	return util.GetSymbolForAction(model.Action(fmt.Sprint(action)))
+}
+
+// groupByType groups resource addresses by resource type
+func groupByType(addresses []string) map[string][]string {
+	groups := make(map[string][]string)
+	for _, address := range addresses {
+		resourceType := model.NewResourceChangeFromAddress(address).Type
+		groups[resourceType] = append(groups[resourceType], address)
+	}
+	return groups
+}
+
+// groupByModule groups resource addresses by module address, with an empty key for the
+// root module
+func groupByModule(addresses []string) map[string][]string {
+	groups := make(map[string][]string)
+	for _, address := range addresses {
+		module := model.NewResourceChangeFromAddress(address).ModuleAddress
+		groups[module] = append(groups[module], address)
+	}
+	return groups
+}
+
+// countAction returns the number of resources to create, update, destroy or replace
+func countAction(action interface{}, resources *model.ResourceCollection) (int, error) {
+	switch name := fmt.Sprint(action); name {
+	case "create", "update", "destroy":
+		return resources.CountResourcesForAction(model.Action(name)), nil
+	case "replace":
+		count := 0
+		for _, address := range resources.GetResourcesForAction(model.ActionDestroy) {
+			if resources.GetResourceChange(address).IsReplacement() {
+				count++
+			}
+		}
+		return count, nil
+	default:
+		return 0, fmt.Errorf("unknown action %q (expected create, update, destroy or replace)", name)
+	}
+}
+
+// truncateText shortens text to at most length characters, ending in … when cut
+func truncateText(length int, text string) string {
+	if utf8.RuneCountInString(text) <= length {
+		return text
+	}
+	if length <= 0 {
+		return ""
+	}
+	runes := []rune(text)
+	return string(runes[:length-1]) + "…"
+}
@@ -0,0 +1,171 @@
+package formatter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+	"github.com/marc-poljak/terraform-plan-filter/internal/util"
+)
+
+func TestFormatTemplate(t *testing.T) {
+	text := `{{ count "create" . }} to create, {{ count "replace" . }} to replace
+{{ range $type, $addresses := groupByType (.GetResourcesForAction "destroy") }}{{ $type }}:{{ range $addresses }} {{ symbol "destroy" }}{{ . }}{{ end }}
+{{ end }}{{ "aws_db_instance.main" | truncate 10 }} {{ colour "destroy" "gone" }}`
+
+	tmpl, err := ParseTemplate("report.tmpl", text, false, Options{UseColors: true})
+	if err != nil {
+		t.Fatalf("ParseTemplate returned an error: %v", err)
+	}
+	result, err := FormatTemplate(junitPlan(), tmpl)
+	if err != nil {
+		t.Fatalf("FormatTemplate returned an error: %v", err)
+	}
+
+	expected := "2 to create, 1 to replace\n" +
+		"aws_db_instance: -aws_db_instance.main\n" +
+		"aws_s3_bucket: -aws_s3_bucket.logs\n" +
+		"aws_db_in… " + util.ColorRed + "gone" + util.ColorReset
+	if result != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, result)
+	}
+
+	tmpl, err = ParseTemplate("report.tmpl", `{{ colour "destroy" "gone" }}`, false, Options{})
+	if err != nil {
+		t.Fatalf("ParseTemplate returned an error: %v", err)
+	}
+	if result, _ := FormatTemplate(junitPlan(), tmpl); result != "gone" {
+		t.Errorf("Expected no color codes without colors, got %q", result)
+	}
+}
+
+func TestFormatTemplateHTML(t *testing.T) {
+	resources := model.NewResourceCollection()
+	resources.AddResourceChange(&model.ResourceChange{Address: `aws_s3_bucket.b["<script>"]`, Actions: []model.Action{model.ActionCreate}})
+
+	text := `{{ range .GetResourcesForAction "create" }}<li>{{ colour "create" . }} {{ . }}</li>{{ end }}`
+	tmpl, err := ParseTemplate("report.html.tmpl", text, true, Options{UseColors: true})
+	if err != nil {
+		t.Fatalf("ParseTemplate returned an error: %v", err)
+	}
+	result, err := FormatTemplate(resources, tmpl)
+	if err != nil {
+		t.Fatalf("FormatTemplate returned an error: %v", err)
+	}
+
+	if strings.Contains(result, "<script>") || strings.Contains(result, "\033[") {
+		t.Errorf("Expected escaped addresses without color codes, got %s", result)
+	}
+	if !strings.Contains(result, `<span style="color: #2a9d8f">aws_s3_bucket.b[&#34;&lt;script&gt;&#34;]</span>`) {
+		t.Errorf("Expected a styled span around the address, got %s", result)
+	}
+}
+
+func TestFormatStacksTemplate(t *testing.T) {
+	report := &model.StackReport{Stacks: []model.Stack{
+		{Name: "prod", Resources: junitPlan()},
+		{Name: "dev", Err: errors.New("invalid plan")},
+	}}
+
+	text := `{{ range .Stacks }}{{ .Name }}: {{ if .Err }}{{ .Err }}{{ else }}{{ .Resources.TotalChanges }}{{ end }}
+{{ end }}`
+	tmpl, err := ParseTemplate("stacks.tmpl", text, false, Options{})
+	if err != nil {
+		t.Fatalf("ParseTemplate returned an error: %v", err)
+	}
+	result, err := FormatStacksTemplate(report, tmpl)
+	if err != nil {
+		t.Fatalf("FormatStacksTemplate returned an error: %v", err)
+	}
+
+	if expected := "prod: 4\ndev: invalid plan\n"; result != expected {
+		t.Errorf("Expected %q, got %q", expected, result)
+	}
+}
+
+func TestFormatTemplateRedactsSensitiveValues(t *testing.T) {
+	resources := model.NewResourceCollection()
+	resources.AddResourceChange(&model.ResourceChange{
+		Address: "aws_db_instance.main", Actions: []model.Action{model.ActionUpdate},
+		Before:          map[string]interface{}{"password": "old-secret", "port": 5432.0},
+		After:           map[string]interface{}{"password": "new-secret-123", "port": 5433.0},
+		BeforeSensitive: map[string]interface{}{"password": true},
+		AfterSensitive:  map[string]interface{}{"password": true},
+	})
+	report := &model.StackReport{Stacks: []model.Stack{{Name: "prod", Resources: resources}}}
+
+	text := `{{ range .Changes }}{{ .Before }} {{ .After }} {{ index .After "password" }}{{ end }}`
+	for _, html := range []bool{false, true} {
+		tmpl, err := ParseTemplate("report.tmpl", text, html, Options{})
+		if err != nil {
+			t.Fatalf("ParseTemplate returned an error: %v", err)
+		}
+		result, err := FormatTemplate(resources, tmpl)
+		if err != nil {
+			t.Fatalf("FormatTemplate returned an error: %v", err)
+		}
+		if strings.Contains(result, "secret") || !strings.Contains(result, "(sensitive)") || !strings.Contains(result, "5433") {
+			t.Errorf("Expected only the sensitive values to be redacted (HTML %v), got %s", html, result)
+		}
+	}
+
+	tmpl, err := ParseTemplate("stacks.tmpl", `{{ range .Stacks }}{{ range .Resources.Changes }}{{ .After }}{{ end }}{{ end }}`, false, Options{})
+	if err != nil {
+		t.Fatalf("ParseTemplate returned an error: %v", err)
+	}
+	result, err := FormatStacksTemplate(report, tmpl)
+	if err != nil {
+		t.Fatalf("FormatStacksTemplate returned an error: %v", err)
+	}
+	if strings.Contains(result, "secret") {
+		t.Errorf("Expected sensitive values of stacks to be redacted, got %s", result)
+	}
+
+	if value, _ := resources.Changes["aws_db_instance.main"].AfterAttribute("password"); value != "new-secret-123" {
+		t.Errorf("Expected the plan itself to keep its values, got %v", value)
+	}
+}
+
+func TestTemplateHelperErrors(t *testing.T) {
+	for _, text := range []string{`{{ colour "pink" "x" }}`, `{{ count "delete" . }}`} {
+		tmpl, err := ParseTemplate("report.tmpl", text, false, Options{})
+		if err != nil {
+			t.Fatalf("ParseTemplate returned an error: %v", err)
+		}
+		if _, err := FormatTemplate(junitPlan(), tmpl); err == nil {
+			t.Errorf("Expected an error rendering %s", text)
+		}
+	}
+
+	if _, err := ParseTemplate("report.tmpl", "{{ range }}", false, Options{}); err == nil {
+		t.Error("Expected an error parsing an invalid template")
+	}
+}
+
+func TestGroupByModule(t *testing.T) {
+	groups := groupByModule([]string{"aws_vpc.main", `module.app["a.b"].aws_instance.web`, "module.app.module.db.aws_db_instance.main"})
+
+	if len(groups) != 3 || groups[""][0] != "aws_vpc.main" || len(groups[`module.app["a.b"]`]) != 1 || len(groups["module.app.module.db"]) != 1 {
+		t.Errorf("Unexpected module groups: %v", groups)
+	}
+}
+
+func TestTruncateText(t *testing.T) {
+	tests := []struct {
+		length   int
+		text     string
+		expected string
+	}{
+		{10, "short", "short"},
+		{5, "exactly", "exac…"},
+		{3, "äöüß", "äö…"},
+		{0, "text", ""},
+	}
+
+	for _, tt := range tests {
+		if got := truncateText(tt.length, tt.text); got != tt.expected {
+			t.Errorf("truncateText(%d, %q) = %q, expected %q", tt.length, tt.text, got, tt.expected)
+		}
+	}
+}
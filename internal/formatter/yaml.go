@@ -0,0 +1,55 @@
+package formatter
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+	"gopkg.in/yaml.v3"
+)
+
+// FormatYAML formats the resource collection as YAML, with the same fields as the JSON output
+func FormatYAML(resources *model.ResourceCollection) (string, error) {
+	return formatYAMLDocument(buildJSONDocument(resources))
+}
+
+// FormatStacksYAML formats a report of many stacks as YAML, with the same fields as the JSON output
+func FormatStacksYAML(report *model.StackReport) (string, error) {
+	return formatYAMLDocument(buildJSONStacksDocument(report))
+}
+
+// formatYAMLDocument renders a JSON document as YAML. Going through JSON keeps the field
+// names and order of the JSON output, so both follow the same schema.
+func formatYAMLDocument(document interface{}) (string, error) {
+	jsonBytes, err := json.Marshal(document)
+	if err != nil {
+		return "", err
+	}
+
+	// JSON is valid YAML, so it parses into nodes that keep the key order
+	var node yaml.Node
+	if err := yaml.Unmarshal(jsonBytes, &node); err != nil {
+		return "", err
+	}
+	clearStyle(&node)
+
+	var sb strings.Builder
+	encoder := yaml.NewEncoder(&sb)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return "", err
+	}
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// clearStyle switches the flow style and quoting of the parsed JSON to block style, leaving
+// the encoder to quote only the strings that need it
+func clearStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearStyle(child)
+	}
+}
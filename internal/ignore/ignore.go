@@ -0,0 +1,157 @@
+package ignore
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/filter"
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+	"github.com/marc-poljak/terraform-plan-filter/internal/util"
+)
+
+// FileName is the name of the ignore file discovered from the working directory
+const FileName = ".tfplanfilter-ignore"
+
+// rule is a single line of an ignore file
+type rule struct {
+	pattern    string   // Address glob the rule applies to
+	negate     bool     // Whether the rule re-includes resources matched by earlier rules
+	attributes []string // Attribute paths whose changes are ignored, empty to ignore the whole resource
+}
+
+// Rules holds the parsed rules of an ignore file
+type Rules struct {
+	Source string // Path of the file the rules were loaded from
+	rules  []rule
+}
+
+// Parse reads ignore rules in a gitignore-like format:
+//
+//	# Comments and blank lines are skipped
+//	null_resource.*                  ignore matching resources
+//	!null_resource.keep              re-include a resource ignored by an earlier line
+//	aws_instance.* : tags.LastModified, tags_all.LastModified
+//	                                 ignore resources whose only changes are in these attributes
+//
+// As in .gitignore, the last matching resource rule decides whether a resource is ignored.
+func Parse(reader io.Reader) (*Rules, error) {
+	rules := &Rules{}
+	scanner := bufio.NewScanner(reader)
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		r, err := parseRule(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+		}
+		rules.rules = append(rules.rules, r)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// parseRule parses a single non-empty, non-comment line
+func parseRule(line string) (rule, error) {
+	var r rule
+
+	if strings.HasPrefix(line, "!") {
+		r.negate = true
+		line = strings.TrimSpace(line[1:])
+	}
+
+	if idx := attributeSeparator(line); idx >= 0 {
+		r.attributes = filter.SplitList(line[idx+1:])
+		line = strings.TrimSpace(line[:idx])
+		if len(r.attributes) == 0 {
+			return r, fmt.Errorf("missing attribute paths after \":\"")
+		}
+		if r.negate {
+			return r, fmt.Errorf("attribute rules cannot be negated")
+		}
+	}
+
+	if line == "" {
+		return r, fmt.Errorf("missing address pattern")
+	}
+	r.pattern = line
+
+	return r, nil
+}
+
+// attributeSeparator returns the index of the ':' separating the address pattern from
+// attribute paths, skipping colons inside index brackets, or -1 if there is none
+func attributeSeparator(line string) int {
+	depth := 0
+	for i, c := range line {
+		switch c {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case ':':
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// LoadFile reads ignore rules from a file
+func LoadFile(path string) (*Rules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading ignore file: %v", err)
+	}
+
+	rules, err := Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing ignore file %s: %v", path, err)
+	}
+	rules.Source = path
+
+	return rules, nil
+}
+
+// Discover looks for an ignore file in dir and its parent directories.
+// It returns an empty string if none is found.
+func Discover(dir string) string {
+	return util.FindFileUpwards(dir, FileName)
+}
+
+// Ignores checks if a resource change should be ignored
+func (r *Rules) Ignores(change *model.ResourceChange) bool {
+	ignored := false
+	var ignoredAttributes []string
+
+	for _, rl := range r.rules {
+		if !filter.MatchAddress(rl.pattern, change) {
+			continue
+		}
+		if len(rl.attributes) > 0 {
+			ignoredAttributes = append(ignoredAttributes, rl.attributes...)
+			continue
+		}
+		ignored = !rl.negate
+	}
+
+	if ignored {
+		return true
+	}
+
+	return change.OnlyChangesAttributes(ignoredAttributes)
+}
@@ -0,0 +1,97 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+// sampleRules is an ignore file used across the tests
+const sampleRules = `
+# Resources that change on every plan
+null_resource.*
+random_*
+!random_password.db
+
+# Timestamp tags maintained outside Terraform
+aws_instance.* : tags.LastModified, tags_all.LastModified
+`
+
+func TestIgnores(t *testing.T) {
+	rules, err := Parse(strings.NewReader(sampleRules))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tagsOnly := &model.ResourceChange{
+		Address: "aws_instance.web", Type: "aws_instance", Name: "web",
+		Actions: []model.Action{model.ActionUpdate},
+		Before:  map[string]interface{}{"tags": map[string]interface{}{"LastModified": "monday"}},
+		After:   map[string]interface{}{"tags": map[string]interface{}{"LastModified": "tuesday"}},
+	}
+	tagsAndType := &model.ResourceChange{
+		Address: "aws_instance.api", Type: "aws_instance", Name: "api",
+		Actions: []model.Action{model.ActionUpdate},
+		Before:  map[string]interface{}{"instance_type": "t3.micro", "tags": map[string]interface{}{"LastModified": "monday"}},
+		After:   map[string]interface{}{"instance_type": "m5.large", "tags": map[string]interface{}{"LastModified": "tuesday"}},
+	}
+
+	tests := []struct {
+		name     string
+		change   *model.ResourceChange
+		expected bool
+	}{
+		{"Ignored by glob", model.NewResourceChangeFromAddress("null_resource.trigger"), true},
+		{"Ignored inside module", model.NewResourceChangeFromAddress("module.app.random_id.suffix"), true},
+		{"Re-included by negation", model.NewResourceChangeFromAddress("random_password.db"), false},
+		{"Not matched", model.NewResourceChangeFromAddress("aws_s3_bucket.logs"), false},
+		{"Only ignored attributes change", tagsOnly, true},
+		{"Other attributes change too", tagsAndType, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rules.Ignores(tt.change); got != tt.expected {
+				t.Errorf("Expected Ignores(%s) = %v, got %v", tt.change.Address, tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		"aws_instance.* : ",
+		"!aws_instance.* : tags",
+		" : tags",
+	}
+
+	for _, input := range tests {
+		if _, err := Parse(strings.NewReader(input)); err == nil {
+			t.Errorf("Expected error for %q, but got none", input)
+		}
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "envs", "prod")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if found := Discover(nested); found != "" && strings.HasPrefix(found, root) {
+		t.Errorf("Expected no ignore file, found %s", found)
+	}
+
+	path := filepath.Join(root, FileName)
+	if err := os.WriteFile(path, []byte("null_resource.*\n"), 0o644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if found := Discover(nested); found != path {
+		t.Errorf("Expected to discover %s, got %q", path, found)
+	}
+}
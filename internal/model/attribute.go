@@ -0,0 +1,332 @@
+package model
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParseAttributePath splits an attribute path such as "ingress[0].cidr_blocks" into its segments
+func ParseAttributePath(path string) []string {
+	var segments []string
+	var current strings.Builder
+
+	flush := func() {
+		if current.Len() > 0 {
+			segments = append(segments, current.String())
+			current.Reset()
+		}
+	}
+
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case '.':
+			flush()
+		case '[':
+			flush()
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				current.WriteString(path[i+1:])
+				i = len(path)
+				continue
+			}
+			segments = append(segments, strings.Trim(path[i+1:i+end], `"`))
+			i += end
+		default:
+			current.WriteByte(path[i])
+		}
+	}
+	flush()
+
+	return segments
+}
+
+// LookupAttribute returns the value at the given path segments within a decoded JSON value
+func LookupAttribute(value interface{}, segments []string) (interface{}, bool) {
+	for _, segment := range segments {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			next, ok := v[segment]
+			if !ok {
+				return nil, false
+			}
+			value = next
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, false
+			}
+			value = v[index]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// BeforeAttribute returns the value of an attribute before the change
+func (c *ResourceChange) BeforeAttribute(path string) (interface{}, bool) {
+	return LookupAttribute(c.Before, ParseAttributePath(path))
+}
+
+// AfterAttribute returns the value of an attribute after the change
+func (c *ResourceChange) AfterAttribute(path string) (interface{}, bool) {
+	return LookupAttribute(c.After, ParseAttributePath(path))
+}
+
+// AttributeChanged checks if the attribute, or anything nested below it, differs between before and after
+func (c *ResourceChange) AttributeChanged(path string) bool {
+	segments := ParseAttributePath(path)
+
+	if unknown, ok := LookupAttribute(c.AfterUnknown, segments); ok && containsMark(unknown) {
+		return true
+	}
+
+	before, beforeOK := LookupAttribute(c.Before, segments)
+	after, afterOK := LookupAttribute(c.After, segments)
+	if !beforeOK && !afterOK {
+		return false
+	}
+	return beforeOK != afterOK || !reflect.DeepEqual(before, after)
+}
+
+// ChangedAttributes returns the sorted paths of all leaf attributes that differ between
+// before and after, including attributes only known after apply
+func (c *ResourceChange) ChangedAttributes() []string {
+	changed := make(map[string]struct{})
+	collectChangedPaths(c.Before, c.After, "", changed)
+	collectUnknownPaths(c.AfterUnknown, "", changed)
+
+	paths := make([]string, 0, len(changed))
+	for path := range changed {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// collectChangedPaths walks before and after together and records the leaf paths that differ
+func collectChangedPaths(before, after interface{}, prefix string, changed map[string]struct{}) {
+	beforeMap, beforeIsMap := before.(map[string]interface{})
+	afterMap, afterIsMap := after.(map[string]interface{})
+	if (beforeIsMap || before == nil) && (afterIsMap || after == nil) && (beforeIsMap || afterIsMap) {
+		keys := make(map[string]struct{})
+		for k := range beforeMap {
+			keys[k] = struct{}{}
+		}
+		for k := range afterMap {
+			keys[k] = struct{}{}
+		}
+		for k := range keys {
+			collectChangedPaths(beforeMap[k], afterMap[k], joinAttributePath(prefix, k), changed)
+		}
+		return
+	}
+
+	beforeList, beforeIsList := before.([]interface{})
+	afterList, afterIsList := after.([]interface{})
+	if (beforeIsList || before == nil) && (afterIsList || after == nil) && (beforeIsList || afterIsList) {
+		length := len(beforeList)
+		if len(afterList) > length {
+			length = len(afterList)
+		}
+		for i := 0; i < length; i++ {
+			var b, a interface{}
+			if i < len(beforeList) {
+				b = beforeList[i]
+			}
+			if i < len(afterList) {
+				a = afterList[i]
+			}
+			collectChangedPaths(b, a, prefix+"["+strconv.Itoa(i)+"]", changed)
+		}
+		return
+	}
+
+	if prefix != "" && !reflect.DeepEqual(before, after) {
+		changed[prefix] = struct{}{}
+	}
+}
+
+// collectUnknownPaths records the paths marked as unknown in an after_unknown structure
+func collectUnknownPaths(unknown interface{}, prefix string, changed map[string]struct{}) {
+	switch v := unknown.(type) {
+	case bool:
+		if v && prefix != "" {
+			changed[prefix] = struct{}{}
+		}
+	case map[string]interface{}:
+		for k, nested := range v {
+			collectUnknownPaths(nested, joinAttributePath(prefix, k), changed)
+		}
+	case []interface{}:
+		for i, nested := range v {
+			collectUnknownPaths(nested, prefix+"["+strconv.Itoa(i)+"]", changed)
+		}
+	}
+}
+
+// containsMark checks if an after_unknown or sensitivity value marks anything
+func containsMark(marks interface{}) bool {
+	switch v := marks.(type) {
+	case bool:
+		return v
+	case map[string]interface{}:
+		for _, nested := range v {
+			if containsMark(nested) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, nested := range v {
+			if containsMark(nested) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SensitiveValue replaces the values of sensitive attributes in output
+const SensitiveValue = "(sensitive)"
+
+// AttributeSensitive checks if Terraform marked the attribute, an attribute containing it or
+// anything nested below it as sensitive before or after the change
+func (c *ResourceChange) AttributeSensitive(path string) bool {
+	segments := ParseAttributePath(path)
+	return markedSensitive(c.BeforeSensitive, segments) || markedSensitive(c.AfterSensitive, segments)
+}
+
+// markedSensitive checks a before_sensitive or after_sensitive structure for a mark on the
+// path, its parents or its children
+func markedSensitive(sensitive interface{}, segments []string) bool {
+	for i := 0; i < len(segments); i++ {
+		mark, ok := LookupAttribute(sensitive, segments[:i])
+		if !ok {
+			return false
+		}
+		if mark == true {
+			return true
+		}
+	}
+
+	mark, _ := LookupAttribute(sensitive, segments)
+	return containsMark(mark)
+}
+
+// Redacted returns a copy of the change with the values of sensitive attributes replaced
+// by SensitiveValue
+func (c *ResourceChange) Redacted() *ResourceChange {
+	redacted := *c
+	redacted.Before = RedactSensitive(c.Before, c.BeforeSensitive)
+	redacted.After = RedactSensitive(c.After, c.AfterSensitive)
+	return &redacted
+}
+
+// RedactSensitive returns a copy of a decoded value with every part marked in the
+// before_sensitive or after_sensitive structure replaced by SensitiveValue
+func RedactSensitive(value, sensitive interface{}) interface{} {
+	if sensitive == true {
+		if value == nil {
+			return nil
+		}
+		return SensitiveValue
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		marks, _ := sensitive.(map[string]interface{})
+		result := make(map[string]interface{}, len(v))
+		for k, nested := range v {
+			result[k] = RedactSensitive(nested, marks[k])
+		}
+		return result
+	case []interface{}:
+		marks, _ := sensitive.([]interface{})
+		result := make([]interface{}, len(v))
+		for i, nested := range v {
+			var mark interface{}
+			if i < len(marks) {
+				mark = marks[i]
+			}
+			result[i] = RedactSensitive(nested, mark)
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+// AttributePathMatches checks if path matches the pattern or is nested below a match.
+// Each pattern segment is a glob, so "tags.*" matches every tag and "ebs[*].size" every volume size.
+func AttributePathMatches(pattern, path string) bool {
+	patternSegments := ParseAttributePath(pattern)
+	pathSegments := ParseAttributePath(path)
+	if len(patternSegments) == 0 || len(patternSegments) > len(pathSegments) {
+		return false
+	}
+
+	for i, segment := range patternSegments {
+		if !MatchGlob(segment, pathSegments[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// OnlyChangesAttributes checks if the change is an in-place update whose changed
+// attributes all match one of the attribute path patterns
+func (c *ResourceChange) OnlyChangesAttributes(patterns []string) bool {
+	if len(patterns) == 0 || len(c.Actions) != 1 || c.Actions[0] != ActionUpdate {
+		return false
+	}
+
+	changed := c.ChangedAttributes()
+	if len(changed) == 0 {
+		return false
+	}
+
+	for _, path := range changed {
+		if !anyAttributePathMatches(patterns, path) {
+			return false
+		}
+	}
+	return true
+}
+
+// anyAttributePathMatches checks a path against a list of attribute path patterns
+func anyAttributePathMatches(patterns []string, path string) bool {
+	for _, pattern := range patterns {
+		if AttributePathMatches(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatAttributePath formats a path given as steps, such as the replace_paths of a plan,
+// in the notation of ParseAttributePath: strings are attribute names and numbers are indexes
+func FormatAttributePath(steps []interface{}) string {
+	path := ""
+	for _, step := range steps {
+		switch v := step.(type) {
+		case float64:
+			path += "[" + strconv.Itoa(int(v)) + "]"
+		case int:
+			path += "[" + strconv.Itoa(v) + "]"
+		default:
+			path = joinAttributePath(path, fmt.Sprint(v))
+		}
+	}
+	return path
+}
+
+// joinAttributePath appends a map key to an attribute path
+func joinAttributePath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
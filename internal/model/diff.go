@@ -0,0 +1,44 @@
+package model
+
+// PlanDiff describes how a plan moved between two versions
+type PlanDiff struct {
+	OldSource string // Name of the old plan file
+	NewSource string // Name of the new plan file
+
+	Added             []DiffEntry // Resources only changed by the new plan
+	Removed           []DiffEntry // Resources only changed by the old plan
+	ActionChanged     []DiffEntry // Resources whose actions differ between the plans
+	AttributesChanged []DiffEntry // Resources with the same actions but different attribute changes
+}
+
+// IsEmpty checks if the plans make the same changes
+func (d *PlanDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.ActionChanged) == 0 && len(d.AttributesChanged) == 0
+}
+
+// DiffEntry is a resource whose planned change differs between two plans
+type DiffEntry struct {
+	Address    string
+	OldAction  string          // Action label in the old plan, empty when not in it
+	NewAction  string          // Action label in the new plan, empty when not in it
+	Attributes []AttributeDiff // Differences in the planned attribute changes
+}
+
+// Kinds of attribute differences
+const (
+	AttributeNewlyChanged    = "added"   // Changed by the new plan only
+	AttributeNoLongerChanged = "removed" // Changed by the old plan only
+	AttributeValueChanged    = "changed" // Changed by both plans, to different values
+)
+
+// AttributeDiff is a difference in how two plans change an attribute
+type AttributeDiff struct {
+	Path         string
+	Kind         string      // One of the Attribute* kinds
+	OldValue     interface{} // Value planned by the old plan
+	NewValue     interface{} // Value planned by the new plan
+	OldUnknown   bool        // Whether the old plan only knows the value after apply
+	NewUnknown   bool        // Whether the new plan only knows the value after apply
+	OldSensitive bool        // Whether the old plan marks the value as sensitive, which is then left out
+	NewSensitive bool        // Whether the new plan marks the value as sensitive, which is then left out
+}
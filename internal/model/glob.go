@@ -0,0 +1,41 @@
+package model
+
+// MatchGlob reports whether value matches the pattern, where '*' matches any
+// sequence of characters and '?' matches a single character
+func MatchGlob(pattern, value string) bool {
+	// Iterative matching with backtracking to the last '*'
+	p, v := 0, 0
+	star, match := -1, 0
+	for v < len(value) {
+		switch {
+		case p < len(pattern) && (pattern[p] == '?' || pattern[p] == value[v]):
+			p++
+			v++
+		case p < len(pattern) && pattern[p] == '*':
+			star = p
+			match = v
+			p++
+		case star >= 0:
+			p = star + 1
+			match++
+			v = match
+		default:
+			return false
+		}
+	}
+
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+// MatchAnyGlob reports whether value matches one of the patterns
+func MatchAnyGlob(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if MatchGlob(pattern, value) {
+			return true
+		}
+	}
+	return false
+}
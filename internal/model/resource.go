@@ -14,14 +14,155 @@ const (
 	ActionDestroy Action = "destroy"
 )
 
+// ResourceChange holds the details of a single resource change from the plan
+type ResourceChange struct {
+	Address       string   // Full resource address (e.g. module.network.aws_vpc.main)
+	ModuleAddress string   // Address of the containing module, empty for the root module
+	Type          string   // Resource type (e.g. aws_vpc)
+	Name          string   // Resource name (e.g. main)
+	ProviderName  string   // Provider source address (e.g. registry.terraform.io/hashicorp/aws)
+	Actions       []Action // Actions that will be applied to the resource
+	ActionReason  string   // Why Terraform chose the actions (e.g. replace_because_cannot_update)
+	ReplacePaths  []string // Attribute paths that force the replacement
+
+	Before          interface{} // Decoded attribute values before the change, nil when created
+	After           interface{} // Decoded attribute values after the change, nil when destroyed
+	AfterUnknown    interface{} // Attributes that will only be known after apply
+	BeforeSensitive interface{} // Attributes marked as sensitive before the change
+	AfterSensitive  interface{} // Attributes marked as sensitive after the change
+}
+
+// HasAction checks if the change includes the given action
+func (c *ResourceChange) HasAction(action Action) bool {
+	for _, a := range c.Actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// ActionLabel returns a single label for the actions of the change: create, update,
+// destroy or replace
+func (c *ResourceChange) ActionLabel() string {
+	if c.IsReplacement() {
+		return "replace"
+	}
+	if len(c.Actions) == 0 {
+		return ""
+	}
+	return string(c.Actions[0])
+}
+
+// IsReplacement checks if the change destroys and re-creates the resource
+func (c *ResourceChange) IsReplacement() bool {
+	return c.HasAction(ActionCreate) && c.HasAction(ActionDestroy)
+}
+
+// Severity represents how serious a policy violation is
+type Severity string
+
+const (
+	SeverityInfo  Severity = "info"
+	SeverityWarn  Severity = "warn"
+	SeverityError Severity = "error"
+)
+
+// PolicyViolation describes a resource change, or the plan as a whole, breaking a policy rule
+type PolicyViolation struct {
+	Rule      string           // Name of the rule that was violated
+	Severity  Severity         // Severity of the rule
+	Address   string           // Address of the offending resource, empty for plan-level violations
+	Message   string           // Human-readable description of the violation
+	ExitCode  int              // Process exit code associated with the rule, zero if it doesn't fail
+	Exemption *PolicyExemption // Exemption covering the violation, if any
+}
+
+// CostReport holds the estimated monthly cost impact of a plan
+type CostReport struct {
+	Currency      string                  // Currency of all amounts
+	Resources     map[string]ResourceCost // Monthly costs of the priced resources by address
+	Unpriced      []UnpricedResource      // Resources that could not be priced
+	MonthlyBefore float64                 // Monthly cost of the priced resources before the plan
+	MonthlyAfter  float64                 // Monthly cost of the priced resources after the plan
+}
+
+// Delta returns the change in monthly cost
+func (r *CostReport) Delta() float64 {
+	return r.MonthlyAfter - r.MonthlyBefore
+}
+
+// ResourceCost is the monthly cost of a resource before and after the change
+type ResourceCost struct {
+	Before float64
+	After  float64
+}
+
+// Delta returns the change in monthly cost
+func (c ResourceCost) Delta() float64 {
+	return c.After - c.Before
+}
+
+// UnpricedResource is a resource that could not be priced
+type UnpricedResource struct {
+	Address string
+	Reason  string
+}
+
+// SecurityFinding is a security-sensitive change detected in a resource's attributes
+type SecurityFinding struct {
+	Detector string // Name of the detector that reported the finding
+	Address  string // Address of the resource
+	Message  string // Human-readable description of the finding
+}
+
+// SourceLocation is the position of a resource block in the Terraform configuration
+type SourceLocation struct {
+	File string // Path of the .tf file, with forward slashes
+	Line int    // Line of the resource block, counted from one
+}
+
+// PolicyExemption describes a time-boxed exemption from policy rules for a resource
+type PolicyExemption struct {
+	Owner   string // Person or team that owns the exemption
+	Reason  string // Why the exemption was granted
+	Expires string // Expiry date in YYYY-MM-DD format
+	Expired bool   // Whether the exemption has expired and no longer applies
+}
+
+// IsBlocking checks if the violation should fail the run
+func (v PolicyViolation) IsBlocking() bool {
+	return v.ExitCode > 0 && (v.Exemption == nil || v.Exemption.Expired)
+}
+
 // ResourceCollection represents resources grouped by action
 type ResourceCollection struct {
 	Resources            map[Action]map[string]struct{} // Maps action to a set of resource identifiers
+	Changes              map[string]*ResourceChange     // Maps resource identifiers to their change details
+	Suppressed           map[string]*ResourceChange     // Updates reclassified as no-op because only ignored attributes changed
+	Acknowledged         map[string]*ResourceChange     // Changes already acknowledged in a baseline
 	FoundSummary         bool                           // Whether a plan summary line was found
 	SummaryAdds          int                            // Count of additions from summary line
 	SummaryChanges       int                            // Count of changes from summary line
 	SummaryDestroys      int                            // Count of deletions from summary line
 	HasDetailedResources bool                           // Whether the plan includes detailed resource info
+	HiddenResources      int                            // Count of resources removed by filters
+	IgnoredResources     int                            // Count of resources skipped by ignore rules
+	Violations           []PolicyViolation              // Policy violations found in the plan
+	Dangerous            map[string]struct{}            // Stateful resources that will be destroyed or replaced
+	RiskScores           map[string]int                 // Risk score of each resource
+	RiskScore            int                            // Aggregate risk score of the plan
+	RiskLevel            string                         // Risk level of the plan (low, medium or high), empty when not scored
+	SecurityFindings     []SecurityFinding              // Security-sensitive changes found in the plan
+	Costs                *CostReport                    // Estimated monthly cost impact, nil when not estimated
+	ModuleDirs           map[string]string              // Directory of each local module relative to the root module, nil without configuration
+	Locations            map[string]SourceLocation      // Source location of the resource blocks, nil unless looked up
+}
+
+// IsDangerous checks if a resource is a stateful resource that will be destroyed or replaced
+func (rc *ResourceCollection) IsDangerous(address string) bool {
+	_, ok := rc.Dangerous[address]
+	return ok
 }
 
 // NewResourceCollection creates a new ResourceCollection
@@ -32,11 +173,38 @@ func NewResourceCollection() *ResourceCollection {
 			ActionUpdate:  {},
 			ActionDestroy: {},
 		},
+		Changes:              map[string]*ResourceChange{},
+		Suppressed:           map[string]*ResourceChange{},
+		Acknowledged:         map[string]*ResourceChange{},
+		Dangerous:            map[string]struct{}{},
+		RiskScores:           map[string]int{},
 		FoundSummary:         false,
 		HasDetailedResources: false,
 	}
 }
 
+// Redacted returns a copy of the collection whose changes have the values of sensitive
+// attributes replaced by SensitiveValue
+func (rc *ResourceCollection) Redacted() *ResourceCollection {
+	redacted := *rc
+	redacted.Changes = redactChanges(rc.Changes)
+	redacted.Suppressed = redactChanges(rc.Suppressed)
+	redacted.Acknowledged = redactChanges(rc.Acknowledged)
+	return &redacted
+}
+
+// redactChanges returns a copy of the changes with sensitive values redacted
+func redactChanges(changes map[string]*ResourceChange) map[string]*ResourceChange {
+	if changes == nil {
+		return nil
+	}
+	redacted := make(map[string]*ResourceChange, len(changes))
+	for address, change := range changes {
+		redacted[address] = change.Redacted()
+	}
+	return redacted
+}
+
 // AddResource adds a resource to the collection for a given action
 func (rc *ResourceCollection) AddResource(action Action, resource string) {
 	if rc.Resources[action] == nil {
@@ -46,6 +214,88 @@ func (rc *ResourceCollection) AddResource(action Action, resource string) {
 	rc.HasDetailedResources = true
 }
 
+// AddResourceChange adds a resource change to the collection for each of its actions
+func (rc *ResourceCollection) AddResourceChange(change *ResourceChange) {
+	if rc.Changes == nil {
+		rc.Changes = make(map[string]*ResourceChange)
+	}
+	rc.Changes[change.Address] = change
+	for _, action := range change.Actions {
+		rc.AddResource(action, change.Address)
+	}
+}
+
+// AddSuppressedChange records an update that was reclassified as no-op
+func (rc *ResourceCollection) AddSuppressedChange(change *ResourceChange) {
+	if rc.Suppressed == nil {
+		rc.Suppressed = make(map[string]*ResourceChange)
+	}
+	rc.Suppressed[change.Address] = change
+}
+
+// GetSuppressedResources returns a sorted slice of suppressed resource identifiers
+func (rc *ResourceCollection) GetSuppressedResources() []string {
+	resources := make([]string, 0, len(rc.Suppressed))
+	for r := range rc.Suppressed {
+		resources = append(resources, r)
+	}
+	sort.Strings(resources)
+	return resources
+}
+
+// RemoveResource removes a resource from all actions and returns its change
+func (rc *ResourceCollection) RemoveResource(resource string) *ResourceChange {
+	change := rc.GetResourceChange(resource)
+	for _, resources := range rc.Resources {
+		delete(resources, resource)
+	}
+	delete(rc.Changes, resource)
+	return change
+}
+
+// GetAcknowledgedResources returns a sorted slice of resources acknowledged in a baseline
+func (rc *ResourceCollection) GetAcknowledgedResources() []string {
+	resources := make([]string, 0, len(rc.Acknowledged))
+	for r := range rc.Acknowledged {
+		resources = append(resources, r)
+	}
+	sort.Strings(resources)
+	return resources
+}
+
+// GetResourceChange returns the change details for a resource.
+// Resources added without details get a change derived from their address.
+func (rc *ResourceCollection) GetResourceChange(resource string) *ResourceChange {
+	if change, ok := rc.Changes[resource]; ok {
+		return change
+	}
+
+	change := NewResourceChangeFromAddress(resource)
+	for _, action := range []Action{ActionCreate, ActionUpdate, ActionDestroy} {
+		if _, ok := rc.Resources[action][resource]; ok {
+			change.Actions = append(change.Actions, action)
+		}
+	}
+	return change
+}
+
+// Addresses returns a sorted slice of all resource identifiers across actions
+func (rc *ResourceCollection) Addresses() []string {
+	seen := make(map[string]struct{})
+	for _, action := range []Action{ActionCreate, ActionUpdate, ActionDestroy} {
+		for r := range rc.Resources[action] {
+			seen[r] = struct{}{}
+		}
+	}
+
+	addresses := make([]string, 0, len(seen))
+	for r := range seen {
+		addresses = append(addresses, r)
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
 // GetResourcesForAction returns a sorted slice of resources for a given action
 func (rc *ResourceCollection) GetResourcesForAction(action Action) []string {
 	var resources []string
@@ -134,3 +384,48 @@ func ExtractResourceType(resource string) string {
 	// Fallback
 	return "unknown"
 }
+
+// NewResourceChangeFromAddress builds a ResourceChange from a resource address alone
+func NewResourceChangeFromAddress(address string) *ResourceChange {
+	change := &ResourceChange{Address: address}
+
+	// Split off module path segments (module.<name>[index]) from the front
+	parts := splitAddress(address)
+	i := 0
+	for i+1 < len(parts) && parts[i] == "module" {
+		i += 2
+	}
+	change.ModuleAddress = strings.Join(parts[:i], ".")
+
+	rest := parts[i:]
+	if len(rest) > 0 && rest[0] == "data" {
+		rest = rest[1:]
+	}
+	if len(rest) >= 2 {
+		change.Type = rest[0]
+		change.Name = rest[1]
+	}
+
+	return change
+}
+
+// splitAddress splits a resource address on dots that are outside index brackets
+func splitAddress(address string) []string {
+	var parts []string
+	depth := 0
+	start := 0
+	for i, r := range address {
+		switch r {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case '.':
+			if depth == 0 {
+				parts = append(parts, address[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, address[start:])
+}
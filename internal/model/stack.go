@@ -0,0 +1,60 @@
+package model
+
+// Stack is the plan of one root module, workspace or Terragrunt unit in a combined report
+type Stack struct {
+	Name      string              // Label of the stack, from the command line or its path
+	Path      string              // Path of the plan file
+	Resources *ResourceCollection // Parsed plan, nil when Err is set
+	Err       error               // Error reading or parsing the plan
+}
+
+// StackReport combines the plans of many stacks into one report
+type StackReport struct {
+	Stacks []Stack
+}
+
+// Totals returns the changes of all stacks that were parsed
+func (r *StackReport) Totals() (adds, changes, destroys int) {
+	for _, stack := range r.Stacks {
+		if stack.Resources == nil {
+			continue
+		}
+		adds += stack.Resources.SummaryAdds
+		changes += stack.Resources.SummaryChanges
+		destroys += stack.Resources.SummaryDestroys
+	}
+	return adds, changes, destroys
+}
+
+// TotalChanges returns the number of changes across all stacks that were parsed
+func (r *StackReport) TotalChanges() int {
+	total := 0
+	for _, stack := range r.Stacks {
+		if stack.Resources != nil {
+			total += stack.Resources.TotalChanges()
+		}
+	}
+	return total
+}
+
+// Failed returns the stacks whose plans could not be read or parsed
+func (r *StackReport) Failed() []Stack {
+	var failed []Stack
+	for _, stack := range r.Stacks {
+		if stack.Err != nil {
+			failed = append(failed, stack)
+		}
+	}
+	return failed
+}
+
+// Violations returns the policy violations of all stacks
+func (r *StackReport) Violations() []PolicyViolation {
+	var violations []PolicyViolation
+	for _, stack := range r.Stacks {
+		if stack.Resources != nil {
+			violations = append(violations, stack.Resources.Violations...)
+		}
+	}
+	return violations
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"path"
 	"strings"
 
 	"github.com/marc-poljak/terraform-plan-filter/internal/model"
@@ -39,24 +40,9 @@ type TerraformPlanJSON struct {
 			} `json:"child_modules"`
 		} `json:"root_module"`
 	} `json:"planned_values"`
-	ResourceChanges []struct {
-		Address      string `json:"address"`
-		Mode         string `json:"mode"`
-		Type         string `json:"type"`
-		Name         string `json:"name"`
-		ProviderName string `json:"provider_name"`
-		Change       struct {
-			Actions []string    `json:"actions"`
-			Before  interface{} `json:"before"`
-			After   interface{} `json:"after"`
-		} `json:"change"`
-	} `json:"resource_changes"`
-	OutputChanges map[string]struct {
-		Change struct {
-			Actions []string    `json:"actions"`
-			Before  interface{} `json:"before"`
-			After   interface{} `json:"after"`
-		} `json:"change"`
+	ResourceChanges []ResourceChangeJSON `json:"resource_changes"`
+	OutputChanges   map[string]struct {
+		Change ChangeJSON `json:"change"`
 	} `json:"output_changes"`
 	PriorState struct {
 		FormatVersion string `json:"format_version"`
@@ -70,11 +56,64 @@ type TerraformPlanJSON struct {
 				ConstantValue json.RawMessage `json:"constant_value"`
 			} `json:"expressions"`
 		} `json:"provider_config"`
+		RootModule *ConfigModuleJSON `json:"root_module"`
 	} `json:"configuration"`
 }
 
+// ConfigModuleJSON represents a module of the configuration in a Terraform JSON plan
+type ConfigModuleJSON struct {
+	ModuleCalls map[string]struct {
+		Source string           `json:"source"`
+		Module ConfigModuleJSON `json:"module"`
+	} `json:"module_calls"`
+}
+
+// ResourceChangeJSON represents a single entry of resource_changes in a Terraform JSON plan
+type ResourceChangeJSON struct {
+	Address       string     `json:"address"`
+	ModuleAddress string     `json:"module_address"`
+	Mode          string     `json:"mode"`
+	Type          string     `json:"type"`
+	Name          string     `json:"name"`
+	ProviderName  string     `json:"provider_name"`
+	ActionReason  string     `json:"action_reason"`
+	Change        ChangeJSON `json:"change"`
+}
+
+// ChangeJSON represents the change block of a resource or output change
+type ChangeJSON struct {
+	Actions         []string        `json:"actions"`
+	Before          interface{}     `json:"before"`
+	After           interface{}     `json:"after"`
+	AfterUnknown    interface{}     `json:"after_unknown"`
+	BeforeSensitive interface{}     `json:"before_sensitive"`
+	AfterSensitive  interface{}     `json:"after_sensitive"`
+	ReplacePaths    [][]interface{} `json:"replace_paths"`
+}
+
+// Ignorer decides whether a resource change should be left out of the collection
+type Ignorer interface {
+	Ignores(change *model.ResourceChange) bool
+}
+
+// Suppressor decides whether a resource change should be reclassified as no-op
+type Suppressor interface {
+	Suppresses(change *model.ResourceChange) bool
+}
+
+// Options configures how a plan is parsed
+type Options struct {
+	Ignore   Ignorer    // Rules for resources to skip, nil to keep everything
+	Suppress Suppressor // Rules for updates to treat as no-op, nil to keep every update
+}
+
 // ParseTerraformPlan parses a Terraform plan in JSON format
 func ParseTerraformPlan(reader io.Reader) (*model.ResourceCollection, error) {
+	return ParseTerraformPlanWithOptions(reader, Options{})
+}
+
+// ParseTerraformPlanWithOptions parses a Terraform plan in JSON format using the given options
+func ParseTerraformPlanWithOptions(reader io.Reader, opts Options) (*model.ResourceCollection, error) {
 	resources := model.NewResourceCollection()
 
 	// Read the entire input
@@ -92,37 +131,94 @@ func ParseTerraformPlan(reader io.Reader) (*model.ResourceCollection, error) {
 	var plan TerraformPlanJSON
 	if err := json.Unmarshal(data, &plan); err != nil {
 		// Try a more flexible approach if full parsing fails
-		return parseResourceChangesOnly(data)
+		return parseResourceChangesOnly(data, opts)
 	}
 
+	// Drop the changes matched by the ignore rules and suppress insignificant updates
+	resourceChanges := applyIgnoreRules(resources, plan.ResourceChanges, opts.Ignore)
+	resourceChanges = applySuppressRules(resources, resourceChanges, opts.Suppress)
+
 	// Process the resource changes
-	processResourceChanges(resources, plan.ResourceChanges)
+	processResourceChanges(resources, resourceChanges)
 
 	// Set the summary flags and counters
-	calculateSummaryValues(resources, plan.ResourceChanges)
+	calculateSummaryValues(resources, resourceChanges)
+
+	// Record where the configuration of each module lives
+	if plan.Config.RootModule != nil {
+		resources.ModuleDirs = map[string]string{"": "."}
+		collectModuleDirs(resources.ModuleDirs, "", ".", *plan.Config.RootModule)
+	}
 
 	resources.HasDetailedResources = true
 	return resources, nil
 }
 
+// collectModuleDirs records the directory of every module called from a module. Only
+// local sources have a directory; modules from registries and other remote sources are left out.
+func collectModuleDirs(dirs map[string]string, address, dir string, module ConfigModuleJSON) {
+	for name, call := range module.ModuleCalls {
+		if !strings.HasPrefix(call.Source, "./") && !strings.HasPrefix(call.Source, "../") {
+			continue
+		}
+
+		callAddress := "module." + name
+		if address != "" {
+			callAddress = address + "." + callAddress
+		}
+		callDir := path.Join(dir, call.Source)
+		dirs[callAddress] = callDir
+		collectModuleDirs(dirs, callAddress, callDir, call.Module)
+	}
+}
+
+// applyIgnoreRules returns the resource changes not matched by the ignore rules
+// and records how many resources were ignored
+func applyIgnoreRules(resources *model.ResourceCollection, resourceChanges []ResourceChangeJSON, ignorer Ignorer) []ResourceChangeJSON {
+	if ignorer == nil {
+		return resourceChanges
+	}
+
+	kept := make([]ResourceChangeJSON, 0, len(resourceChanges))
+	for _, resource := range resourceChanges {
+		if resource.Mode != "data" && !isNoOpAction(resource.Change.Actions) &&
+			ignorer.Ignores(newResourceChange(resource)) {
+			resources.IgnoredResources++
+			continue
+		}
+		kept = append(kept, resource)
+	}
+	return kept
+}
+
+// applySuppressRules returns the resource changes not reclassified as no-op
+// and records the suppressed updates in the collection
+func applySuppressRules(resources *model.ResourceCollection, resourceChanges []ResourceChangeJSON, suppressor Suppressor) []ResourceChangeJSON {
+	if suppressor == nil {
+		return resourceChanges
+	}
+
+	kept := make([]ResourceChangeJSON, 0, len(resourceChanges))
+	for _, resource := range resourceChanges {
+		if resource.Mode != "data" {
+			change := newResourceChange(resource)
+			if suppressor.Suppresses(change) {
+				resources.AddSuppressedChange(change)
+				continue
+			}
+		}
+		kept = append(kept, resource)
+	}
+	return kept
+}
+
 // isTextPlan checks if the input data is in text format rather than JSON
 func isTextPlan(data []byte) bool {
 	return strings.HasPrefix(string(data), "Terraform will perform")
 }
 
 // processResourceChanges processes the resource changes from the Terraform plan
-func processResourceChanges(resources *model.ResourceCollection, resourceChanges []struct {
-	Address      string `json:"address"`
-	Mode         string `json:"mode"`
-	Type         string `json:"type"`
-	Name         string `json:"name"`
-	ProviderName string `json:"provider_name"`
-	Change       struct {
-		Actions []string    `json:"actions"`
-		Before  interface{} `json:"before"`
-		After   interface{} `json:"after"`
-	} `json:"change"`
-}) {
+func processResourceChanges(resources *model.ResourceCollection, resourceChanges []ResourceChangeJSON) {
 	for _, resource := range resourceChanges {
 		// Skip data resources
 		if resource.Mode == "data" {
@@ -134,16 +230,62 @@ func processResourceChanges(resources *model.ResourceCollection, resourceChanges
 			continue
 		}
 
-		// Process replacement resources specially
-		if isReplacement(resource.Change.Actions) {
-			resources.AddResource(model.ActionCreate, resource.Address)
-			resources.AddResource(model.ActionDestroy, resource.Address)
+		// Record the change with its details for every action it includes
+		change := newResourceChange(resource)
+		if len(change.Actions) == 0 {
 			continue
 		}
+		resources.AddResourceChange(change)
+	}
+}
+
+// newResourceChange builds a model.ResourceChange from a plan resource change
+func newResourceChange(resource ResourceChangeJSON) *model.ResourceChange {
+	change := model.NewResourceChangeFromAddress(resource.Address)
+
+	// Prefer the explicit fields from the plan over what the address implies
+	if resource.ModuleAddress != "" {
+		change.ModuleAddress = resource.ModuleAddress
+	}
+	if resource.Type != "" {
+		change.Type = resource.Type
+	}
+	if resource.Name != "" {
+		change.Name = resource.Name
+	}
+	change.ProviderName = resource.ProviderName
+	change.ActionReason = resource.ActionReason
+	change.Actions = convertActions(resource.Change.Actions)
+	change.Before = resource.Change.Before
+	change.After = resource.Change.After
+	change.AfterUnknown = resource.Change.AfterUnknown
+	change.BeforeSensitive = resource.Change.BeforeSensitive
+	change.AfterSensitive = resource.Change.AfterSensitive
+	for _, steps := range resource.Change.ReplacePaths {
+		change.ReplacePaths = append(change.ReplacePaths, model.FormatAttributePath(steps))
+	}
+
+	return change
+}
 
-		// Process standard resources based on their actions
-		processStandardActions(resources, resource.Address, resource.Change.Actions)
+// convertActions maps plan action names to model actions
+func convertActions(actions []string) []model.Action {
+	if isReplacement(actions) {
+		return []model.Action{model.ActionDestroy, model.ActionCreate}
+	}
+
+	var result []model.Action
+	for _, action := range actions {
+		switch action {
+		case "create":
+			result = append(result, model.ActionCreate)
+		case "update":
+			result = append(result, model.ActionUpdate)
+		case "delete":
+			result = append(result, model.ActionDestroy)
+		}
 	}
+	return result
 }
 
 // isNoOpAction checks if the actions list contains only "no-op"
@@ -161,33 +303,8 @@ func isReplacement(actions []string) bool {
 	return false
 }
 
-// processStandardActions processes standard create/update/delete actions
-func processStandardActions(resources *model.ResourceCollection, address string, actions []string) {
-	for _, action := range actions {
-		switch action {
-		case "create":
-			resources.AddResource(model.ActionCreate, address)
-		case "update":
-			resources.AddResource(model.ActionUpdate, address)
-		case "delete":
-			resources.AddResource(model.ActionDestroy, address)
-		}
-	}
-}
-
 // calculateSummaryValues sets the summary values in the resource collection
-func calculateSummaryValues(resources *model.ResourceCollection, resourceChanges []struct {
-	Address      string `json:"address"`
-	Mode         string `json:"mode"`
-	Type         string `json:"type"`
-	Name         string `json:"name"`
-	ProviderName string `json:"provider_name"`
-	Change       struct {
-		Actions []string    `json:"actions"`
-		Before  interface{} `json:"before"`
-		After   interface{} `json:"after"`
-	} `json:"change"`
-}) {
+func calculateSummaryValues(resources *model.ResourceCollection, resourceChanges []ResourceChangeJSON) {
 	resources.FoundSummary = true
 
 	// Reset summary counters
@@ -229,7 +346,7 @@ func countActionsForSummary(resources *model.ResourceCollection, actions []strin
 
 // parseResourceChangesOnly attempts to extract just the resource changes from the JSON
 // This is a fallback when the full JSON structure doesn't match our expectations
-func parseResourceChangesOnly(data []byte) (*model.ResourceCollection, error) {
+func parseResourceChangesOnly(data []byte, opts Options) (*model.ResourceCollection, error) {
 	resources := model.NewResourceCollection()
 
 	// Try to extract just the resource_changes array
@@ -245,18 +362,16 @@ func parseResourceChangesOnly(data []byte) (*model.ResourceCollection, error) {
 	}
 
 	// Parse the resource changes
-	var resourceChanges []struct {
-		Address string `json:"address"`
-		Mode    string `json:"mode"`
-		Change  struct {
-			Actions []string `json:"actions"`
-		} `json:"change"`
-	}
+	var resourceChanges []ResourceChangeJSON
 
 	if err := json.Unmarshal(resourceChangesJSON, &resourceChanges); err != nil {
 		return nil, fmt.Errorf("error parsing resource changes: %w", err)
 	}
 
+	// Drop the changes matched by the ignore rules and suppress insignificant updates
+	resourceChanges = applyIgnoreRules(resources, resourceChanges, opts.Ignore)
+	resourceChanges = applySuppressRules(resources, resourceChanges, opts.Suppress)
+
 	// Process the resource changes
 	processSimplifiedResourceChanges(resources, resourceChanges)
 
@@ -266,39 +381,27 @@ func parseResourceChangesOnly(data []byte) (*model.ResourceCollection, error) {
 }
 
 // processSimplifiedResourceChanges processes the simplified resource changes structure
-func processSimplifiedResourceChanges(resources *model.ResourceCollection, resourceChanges []struct {
-	Address string `json:"address"`
-	Mode    string `json:"mode"`
-	Change  struct {
-		Actions []string `json:"actions"`
-	} `json:"change"`
-}) {
+func processSimplifiedResourceChanges(resources *model.ResourceCollection, resourceChanges []ResourceChangeJSON) {
 	for _, resource := range resourceChanges {
 		// Skip data resources
 		if resource.Mode == "data" {
 			continue
 		}
 
-		// Check for replacements
-		if isReplacement(resource.Change.Actions) {
-			resources.AddResource(model.ActionCreate, resource.Address)
-			resources.AddResource(model.ActionDestroy, resource.Address)
-			resources.SummaryAdds++
-			resources.SummaryDestroys++
+		change := newResourceChange(resource)
+		if len(change.Actions) == 0 {
 			continue
 		}
+		resources.AddResourceChange(change)
 
-		// Process standard actions
-		for _, action := range resource.Change.Actions {
+		// Count the actions for the summary
+		for _, action := range change.Actions {
 			switch action {
-			case "create":
-				resources.AddResource(model.ActionCreate, resource.Address)
+			case model.ActionCreate:
 				resources.SummaryAdds++
-			case "update":
-				resources.AddResource(model.ActionUpdate, resource.Address)
+			case model.ActionUpdate:
 				resources.SummaryChanges++
-			case "delete":
-				resources.AddResource(model.ActionDestroy, resource.Address)
+			case model.ActionDestroy:
 				resources.SummaryDestroys++
 			}
 		}
@@ -278,3 +278,268 @@ func TestExtractResourceType(t *testing.T) {
 		})
 	}
 }
+
+func TestParseTerraformPlanResourceDetails(t *testing.T) {
+	jsonPlan := `{
+		"format_version": "1.0",
+		"resource_changes": [
+			{
+				"address": "module.network.aws_vpc.main",
+				"module_address": "module.network",
+				"mode": "managed",
+				"type": "aws_vpc",
+				"name": "main",
+				"provider_name": "registry.terraform.io/hashicorp/aws",
+				"change": {
+					"actions": ["delete", "create"],
+					"before": {},
+					"after": {}
+				}
+			}
+		]
+	}`
+
+	resources, err := ParseTerraformPlan(strings.NewReader(jsonPlan))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	change := resources.GetResourceChange("module.network.aws_vpc.main")
+	if change.ModuleAddress != "module.network" {
+		t.Errorf("Expected module address module.network, got %q", change.ModuleAddress)
+	}
+	if change.Type != "aws_vpc" || change.Name != "main" {
+		t.Errorf("Expected type aws_vpc and name main, got %q and %q", change.Type, change.Name)
+	}
+	if change.ProviderName != "registry.terraform.io/hashicorp/aws" {
+		t.Errorf("Expected AWS provider, got %q", change.ProviderName)
+	}
+	if !change.IsReplacement() {
+		t.Errorf("Expected delete+create to be a replacement, got actions %v", change.Actions)
+	}
+}
+
+func TestParseTerraformPlanAttributes(t *testing.T) {
+	jsonPlan := `{
+		"format_version": "1.0",
+		"resource_changes": [
+			{
+				"address": "aws_instance.web",
+				"mode": "managed",
+				"type": "aws_instance",
+				"name": "web",
+				"change": {
+					"actions": ["update"],
+					"before": {"instance_type": "t3.micro", "tags": {"Owner": "ops", "Team": "web"}, "ebs": [{"size": 10}]},
+					"after": {"instance_type": "m5.large", "tags": {"Owner": "ops", "Team": "platform"}, "ebs": [{"size": 10}]},
+					"after_unknown": {"arn": true, "tags": {}}
+				}
+			}
+		]
+	}`
+
+	resources, err := ParseTerraformPlan(strings.NewReader(jsonPlan))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	change := resources.GetResourceChange("aws_instance.web")
+
+	expected := []string{"arn", "instance_type", "tags.Team"}
+	if got := change.ChangedAttributes(); strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected changed attributes %v, got %v", expected, got)
+	}
+
+	changedTests := map[string]bool{
+		"instance_type": true,
+		"tags":          true,
+		"tags.Team":     true,
+		"tags.Owner":    false,
+		"ebs[0].size":   false,
+		"arn":           true,
+		"missing":       false,
+	}
+	for path, want := range changedTests {
+		if got := change.AttributeChanged(path); got != want {
+			t.Errorf("AttributeChanged(%q) = %v, expected %v", path, got, want)
+		}
+	}
+
+	if value, ok := change.BeforeAttribute("ebs[0].size"); !ok || value != float64(10) {
+		t.Errorf("Expected before ebs[0].size to be 10, got %v", value)
+	}
+}
+
+// ignoreNullResources is an Ignorer that skips null_resource changes
+type ignoreNullResources struct{}
+
+func (ignoreNullResources) Ignores(change *model.ResourceChange) bool {
+	return change.Type == "null_resource"
+}
+
+func TestParseTerraformPlanWithIgnore(t *testing.T) {
+	jsonPlan := `{
+		"format_version": "1.0",
+		"resource_changes": [
+			{"address": "null_resource.trigger", "mode": "managed", "type": "null_resource", "name": "trigger",
+			 "change": {"actions": ["delete", "create"]}},
+			{"address": "aws_s3_bucket.logs", "mode": "managed", "type": "aws_s3_bucket", "name": "logs",
+			 "change": {"actions": ["create"]}}
+		]
+	}`
+
+	resources, err := ParseTerraformPlanWithOptions(strings.NewReader(jsonPlan), Options{Ignore: ignoreNullResources{}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if resources.IgnoredResources != 1 {
+		t.Errorf("Expected 1 ignored resource, got %d", resources.IgnoredResources)
+	}
+	if resources.SummaryAdds != 1 || resources.SummaryDestroys != 0 {
+		t.Errorf("Expected ignored resources to be left out of the summary, got %d adds and %d destroys",
+			resources.SummaryAdds, resources.SummaryDestroys)
+	}
+	if hasResource(resources.GetResourcesForAction(model.ActionCreate), "null_resource.trigger") {
+		t.Errorf("Expected null_resource.trigger to be ignored")
+	}
+}
+
+// suppressTagUpdates is a Suppressor that treats tag-only updates as no-op
+type suppressTagUpdates struct{}
+
+func (suppressTagUpdates) Suppresses(change *model.ResourceChange) bool {
+	return change.OnlyChangesAttributes([]string{"tags.*"})
+}
+
+func TestParseTerraformPlanWithSuppress(t *testing.T) {
+	jsonPlan := `{
+		"format_version": "1.0",
+		"resource_changes": [
+			{"address": "aws_instance.web", "mode": "managed", "type": "aws_instance", "name": "web",
+			 "change": {"actions": ["update"], "before": {"tags": {"Build": "1"}}, "after": {"tags": {"Build": "2"}}}},
+			{"address": "aws_instance.api", "mode": "managed", "type": "aws_instance", "name": "api",
+			 "change": {"actions": ["update"], "before": {"ami": "a"}, "after": {"ami": "b"}}}
+		]
+	}`
+
+	resources, err := ParseTerraformPlanWithOptions(strings.NewReader(jsonPlan), Options{Suppress: suppressTagUpdates{}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if suppressed := resources.GetSuppressedResources(); len(suppressed) != 1 || suppressed[0] != "aws_instance.web" {
+		t.Errorf("Expected aws_instance.web to be suppressed, got %v", suppressed)
+	}
+	if resources.SummaryChanges != 1 {
+		t.Errorf("Expected 1 change in the summary, got %d", resources.SummaryChanges)
+	}
+}
+
+func TestParseTerraformPlanModuleDirs(t *testing.T) {
+	jsonPlan := `{
+		"format_version": "1.2",
+		"resource_changes": [],
+		"configuration": {
+			"root_module": {
+				"module_calls": {
+					"network": {
+						"source": "./modules/network",
+						"module": {
+							"module_calls": {
+								"subnets": {"source": "../subnets", "module": {}}
+							}
+						}
+					},
+					"vpc": {"source": "terraform-aws-modules/vpc/aws", "module": {}}
+				}
+			}
+		}
+	}`
+
+	resources, err := ParseTerraformPlan(strings.NewReader(jsonPlan))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := map[string]string{
+		"":                              ".",
+		"module.network":                "modules/network",
+		"module.network.module.subnets": "modules/subnets",
+	}
+	if len(resources.ModuleDirs) != len(expected) {
+		t.Errorf("Expected module dirs %v, got %v", expected, resources.ModuleDirs)
+	}
+	for address, dir := range expected {
+		if resources.ModuleDirs[address] != dir {
+			t.Errorf("Expected %q to be in %q, got %q", address, dir, resources.ModuleDirs[address])
+		}
+	}
+}
+
+func TestParseTerraformPlanReplacePaths(t *testing.T) {
+	jsonPlan := `{
+		"format_version": "1.2",
+		"resource_changes": [{
+			"address": "aws_instance.web",
+			"mode": "managed",
+			"type": "aws_instance",
+			"name": "web",
+			"action_reason": "replace_because_cannot_update",
+			"change": {
+				"actions": ["delete", "create"],
+				"replace_paths": [["ami"], ["ebs_block_device", 0, "volume_size"]]
+			}
+		}]
+	}`
+
+	resources, err := ParseTerraformPlan(strings.NewReader(jsonPlan))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	paths := resources.GetResourceChange("aws_instance.web").ReplacePaths
+	if len(paths) != 2 || paths[0] != "ami" || paths[1] != "ebs_block_device[0].volume_size" {
+		t.Errorf("Expected the replace paths ami and ebs_block_device[0].volume_size, got %v", paths)
+	}
+}
+
+func TestParseTerraformPlanSensitive(t *testing.T) {
+	jsonPlan := `{
+		"format_version": "1.2",
+		"resource_changes": [{
+			"address": "aws_db_instance.main",
+			"mode": "managed",
+			"type": "aws_db_instance",
+			"name": "main",
+			"change": {
+				"actions": ["update"],
+				"before": {"password": "old-secret", "tags": {"Owner": "data"}},
+				"after": {"password": "new-secret", "tags": {"Owner": "platform"}},
+				"before_sensitive": {"password": true, "tags": {}},
+				"after_sensitive": {"password": true, "tags": {}}
+			}
+		}]
+	}`
+
+	resources, err := ParseTerraformPlan(strings.NewReader(jsonPlan))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	change := resources.GetResourceChange("aws_db_instance.main")
+	if !change.AttributeSensitive("password") || change.AttributeSensitive("tags.Owner") {
+		t.Errorf("Expected only password to be sensitive, got before %v and after %v", change.BeforeSensitive, change.AfterSensitive)
+	}
+
+	redacted := change.Redacted()
+	if value, _ := redacted.AfterAttribute("password"); value != "(sensitive)" {
+		t.Errorf("Expected the redacted password to be (sensitive), got %v", value)
+	}
+	if value, _ := redacted.AfterAttribute("tags.Owner"); value != "platform" {
+		t.Errorf("Expected tags.Owner to be kept, got %v", value)
+	}
+	if value, _ := change.AfterAttribute("password"); value != "new-secret" {
+		t.Errorf("Expected Redacted to leave the original change untouched, got %v", value)
+	}
+}
@@ -0,0 +1,141 @@
+package plandiff
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+// Compare reports how the changes of the new plan differ from those of the old plan
+func Compare(oldPlan, newPlan *model.ResourceCollection) *model.PlanDiff {
+	diff := &model.PlanDiff{}
+
+	for _, address := range unionAddresses(oldPlan, newPlan) {
+		oldChange := changeFor(oldPlan, address)
+		newChange := changeFor(newPlan, address)
+
+		switch {
+		case oldChange == nil:
+			diff.Added = append(diff.Added, model.DiffEntry{Address: address, NewAction: newChange.ActionLabel()})
+		case newChange == nil:
+			diff.Removed = append(diff.Removed, model.DiffEntry{Address: address, OldAction: oldChange.ActionLabel()})
+		case oldChange.ActionLabel() != newChange.ActionLabel():
+			diff.ActionChanged = append(diff.ActionChanged, model.DiffEntry{
+				Address:   address,
+				OldAction: oldChange.ActionLabel(),
+				NewAction: newChange.ActionLabel(),
+			})
+		default:
+			if attributes := compareAttributes(oldChange, newChange); len(attributes) > 0 {
+				diff.AttributesChanged = append(diff.AttributesChanged, model.DiffEntry{
+					Address:    address,
+					OldAction:  oldChange.ActionLabel(),
+					NewAction:  newChange.ActionLabel(),
+					Attributes: attributes,
+				})
+			}
+		}
+	}
+
+	return diff
+}
+
+// unionAddresses returns the sorted addresses changed by either plan
+func unionAddresses(oldPlan, newPlan *model.ResourceCollection) []string {
+	seen := make(map[string]struct{})
+	for _, address := range oldPlan.Addresses() {
+		seen[address] = struct{}{}
+	}
+	for _, address := range newPlan.Addresses() {
+		seen[address] = struct{}{}
+	}
+
+	addresses := make([]string, 0, len(seen))
+	for address := range seen {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
+// changeFor returns the change a plan makes to a resource, nil if it doesn't change it
+func changeFor(plan *model.ResourceCollection, address string) *model.ResourceChange {
+	for _, action := range []model.Action{model.ActionCreate, model.ActionUpdate, model.ActionDestroy} {
+		if _, ok := plan.Resources[action][address]; ok {
+			return plan.GetResourceChange(address)
+		}
+	}
+	return nil
+}
+
+// compareAttributes returns the differences between the attribute changes of two changes,
+// leaving out the values of sensitive attributes
+func compareAttributes(oldChange, newChange *model.ResourceChange) []model.AttributeDiff {
+	oldPaths := toSet(oldChange.ChangedAttributes())
+	newPaths := toSet(newChange.ChangedAttributes())
+
+	paths := make([]string, 0, len(oldPaths)+len(newPaths))
+	for path := range oldPaths {
+		paths = append(paths, path)
+	}
+	for path := range newPaths {
+		if _, ok := oldPaths[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	var diffs []model.AttributeDiff
+	for _, path := range paths {
+		_, inOld := oldPaths[path]
+		_, inNew := newPaths[path]
+
+		d := model.AttributeDiff{Path: path}
+		if inOld {
+			d.OldValue, _ = oldChange.AfterAttribute(path)
+			d.OldUnknown = isUnknown(oldChange, path)
+		}
+		if inNew {
+			d.NewValue, _ = newChange.AfterAttribute(path)
+			d.NewUnknown = isUnknown(newChange, path)
+		}
+
+		switch {
+		case !inOld:
+			d.Kind = model.AttributeNewlyChanged
+		case !inNew:
+			d.Kind = model.AttributeNoLongerChanged
+		case d.OldUnknown != d.NewUnknown || !reflect.DeepEqual(d.OldValue, d.NewValue):
+			d.Kind = model.AttributeValueChanged
+		default:
+			continue
+		}
+
+		// Only report that a sensitive value changed, never the value itself
+		if inOld && oldChange.AttributeSensitive(path) {
+			d.OldValue, d.OldSensitive = nil, true
+		}
+		if inNew && newChange.AttributeSensitive(path) {
+			d.NewValue, d.NewSensitive = nil, true
+		}
+		diffs = append(diffs, d)
+	}
+
+	return diffs
+}
+
+// isUnknown checks if an attribute is only known after apply
+func isUnknown(change *model.ResourceChange, path string) bool {
+	v, ok := model.LookupAttribute(change.AfterUnknown, model.ParseAttributePath(path))
+	return ok && v == true
+}
+
+// toSet converts a list of strings to a set
+func toSet(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+	return set
+}
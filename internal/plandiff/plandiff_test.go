@@ -0,0 +1,124 @@
+package plandiff
+
+import (
+	"testing"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+func TestCompare(t *testing.T) {
+	oldPlan := model.NewResourceCollection()
+	oldPlan.AddResourceChange(&model.ResourceChange{
+		Address: "aws_iam_role.ci", Type: "aws_iam_role", Actions: []model.Action{model.ActionCreate},
+		After: map[string]interface{}{"name": "ci"},
+	})
+	oldPlan.AddResourceChange(&model.ResourceChange{
+		Address: "aws_db_instance.main", Type: "aws_db_instance", Actions: []model.Action{model.ActionUpdate},
+		Before: map[string]interface{}{"instance_class": "db.t3.micro"},
+		After:  map[string]interface{}{"instance_class": "db.t3.small"},
+	})
+	oldPlan.AddResourceChange(&model.ResourceChange{
+		Address: "aws_instance.web", Type: "aws_instance", Actions: []model.Action{model.ActionUpdate},
+		Before: map[string]interface{}{"instance_type": "t3.micro", "ami": "a", "tags": map[string]interface{}{"Owner": "x"}},
+		After:  map[string]interface{}{"instance_type": "m5.large", "ami": "b", "tags": map[string]interface{}{"Owner": "x"}},
+	})
+	oldPlan.AddResourceChange(&model.ResourceChange{
+		Address: "aws_vpc.main", Type: "aws_vpc", Actions: []model.Action{model.ActionUpdate},
+		Before: map[string]interface{}{"cidr_block": "10.0.0.0/16"},
+		After:  map[string]interface{}{"cidr_block": "10.1.0.0/16"},
+	})
+
+	newPlan := model.NewResourceCollection()
+	newPlan.AddResourceChange(&model.ResourceChange{
+		Address: "aws_s3_bucket.logs", Type: "aws_s3_bucket", Actions: []model.Action{model.ActionCreate},
+		After: map[string]interface{}{"bucket": "logs"},
+	})
+	newPlan.AddResourceChange(&model.ResourceChange{
+		Address: "aws_db_instance.main", Type: "aws_db_instance", Actions: []model.Action{model.ActionDestroy, model.ActionCreate},
+		Before: map[string]interface{}{"instance_class": "db.t3.micro"},
+		After:  map[string]interface{}{"instance_class": "db.t3.small"},
+	})
+	newPlan.AddResourceChange(&model.ResourceChange{
+		Address: "aws_instance.web", Type: "aws_instance", Actions: []model.Action{model.ActionUpdate},
+		Before:       map[string]interface{}{"instance_type": "t3.micro", "ami": "a", "tags": map[string]interface{}{"Owner": "x"}},
+		After:        map[string]interface{}{"instance_type": "m6i.large", "ami": "a", "tags": map[string]interface{}{"Owner": "y"}},
+		AfterUnknown: map[string]interface{}{"arn": true},
+	})
+	newPlan.AddResourceChange(&model.ResourceChange{
+		Address: "aws_vpc.main", Type: "aws_vpc", Actions: []model.Action{model.ActionUpdate},
+		Before: map[string]interface{}{"cidr_block": "10.0.0.0/16"},
+		After:  map[string]interface{}{"cidr_block": "10.1.0.0/16"},
+	})
+
+	diff := Compare(oldPlan, newPlan)
+
+	if len(diff.Added) != 1 || diff.Added[0].Address != "aws_s3_bucket.logs" || diff.Added[0].NewAction != "create" {
+		t.Errorf("Unexpected added resources: %+v", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0].Address != "aws_iam_role.ci" || diff.Removed[0].OldAction != "create" {
+		t.Errorf("Unexpected removed resources: %+v", diff.Removed)
+	}
+	if len(diff.ActionChanged) != 1 || diff.ActionChanged[0].OldAction != "update" || diff.ActionChanged[0].NewAction != "replace" {
+		t.Errorf("Unexpected action changes: %+v", diff.ActionChanged)
+	}
+
+	if len(diff.AttributesChanged) != 1 || diff.AttributesChanged[0].Address != "aws_instance.web" {
+		t.Fatalf("Expected only aws_instance.web to have attribute changes, got %+v", diff.AttributesChanged)
+	}
+	expected := []model.AttributeDiff{
+		{Path: "ami", Kind: model.AttributeNoLongerChanged, OldValue: "b"},
+		{Path: "arn", Kind: model.AttributeNewlyChanged, NewUnknown: true},
+		{Path: "instance_type", Kind: model.AttributeValueChanged, OldValue: "m5.large", NewValue: "m6i.large"},
+		{Path: "tags.Owner", Kind: model.AttributeNewlyChanged, NewValue: "y"},
+	}
+	attributes := diff.AttributesChanged[0].Attributes
+	if len(attributes) != len(expected) {
+		t.Fatalf("Expected %d attribute differences, got %+v", len(expected), attributes)
+	}
+	for i, e := range expected {
+		if attributes[i] != e {
+			t.Errorf("Attribute difference %d: expected %+v, got %+v", i, e, attributes[i])
+		}
+	}
+
+	if Compare(oldPlan, oldPlan).IsEmpty() != true {
+		t.Errorf("Expected no differences when comparing a plan with itself")
+	}
+}
+
+func TestCompareSensitive(t *testing.T) {
+	oldPlan := model.NewResourceCollection()
+	oldPlan.AddResourceChange(&model.ResourceChange{
+		Address: "aws_db_instance.main", Type: "aws_db_instance", Actions: []model.Action{model.ActionUpdate},
+		Before:         map[string]interface{}{"password": "old-secret", "port": 5432.0},
+		After:          map[string]interface{}{"password": "new-secret-123", "port": 5432.0},
+		AfterSensitive: map[string]interface{}{"password": true},
+	})
+
+	newPlan := model.NewResourceCollection()
+	newPlan.AddResourceChange(&model.ResourceChange{
+		Address: "aws_db_instance.main", Type: "aws_db_instance", Actions: []model.Action{model.ActionUpdate},
+		Before:         map[string]interface{}{"password": "old-secret", "port": 5432.0},
+		After:          map[string]interface{}{"password": "third-secret", "port": 5433.0},
+		AfterSensitive: map[string]interface{}{"password": true},
+	})
+
+	diff := Compare(oldPlan, newPlan)
+	if len(diff.AttributesChanged) != 1 {
+		t.Fatalf("Expected attribute changes on aws_db_instance.main, got %+v", diff.AttributesChanged)
+	}
+
+	expected := []model.AttributeDiff{
+		{Path: "password", Kind: model.AttributeValueChanged, OldSensitive: true, NewSensitive: true},
+		{Path: "port", Kind: model.AttributeNewlyChanged, NewValue: 5433.0},
+	}
+	attributes := diff.AttributesChanged[0].Attributes
+	if len(attributes) != len(expected) {
+		t.Fatalf("Expected %d attribute differences, got %+v", len(expected), attributes)
+	}
+	for i, e := range expected {
+		if attributes[i] != e {
+			t.Errorf("Attribute difference %d: expected %+v, got %+v", i, e, attributes[i])
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package policy
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/filter"
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+	"github.com/marc-poljak/terraform-plan-filter/internal/query"
+)
+
+// expiryLayout is the date format of exemption expiry dates
+const expiryLayout = "2006-01-02"
+
+// File is the declarative policy document read from YAML or JSON
+type File struct {
+	Rules      []FileRule  `yaml:"rules" json:"rules"`
+	Tags       *TagPolicy  `yaml:"tags" json:"tags"`
+	Exemptions []Exemption `yaml:"exemptions" json:"exemptions"`
+}
+
+// TagPolicy declares the tags required on created and updated resources
+type TagPolicy struct {
+	Required      []string            `yaml:"required" json:"required"`
+	AllowedValues map[string][]string `yaml:"allowed_values" json:"allowed_values"`
+	Types         []string            `yaml:"types" json:"types"`
+	Exclude       []string            `yaml:"exclude" json:"exclude"`
+	Severity      string              `yaml:"severity" json:"severity"`
+}
+
+// FileRule is a rule declared in a policy file
+type FileRule struct {
+	Name        string    `yaml:"name" json:"name"`
+	Description string    `yaml:"description" json:"description"`
+	Severity    string    `yaml:"severity" json:"severity"`
+	Match       RuleMatch `yaml:"match" json:"match"`
+}
+
+// RuleMatch selects the resource changes a rule applies to. All criteria must match;
+// within a list, any entry may match.
+type RuleMatch struct {
+	Actions           []string `yaml:"actions" json:"actions"`
+	Types             []string `yaml:"types" json:"types"`
+	Addresses         []string `yaml:"addresses" json:"addresses"`
+	Modules           []string `yaml:"modules" json:"modules"`
+	Providers         []string `yaml:"providers" json:"providers"`
+	ChangedAttributes []string `yaml:"changed_attributes" json:"changed_attributes"`
+	Where             string   `yaml:"where" json:"where"`
+}
+
+// Exemption exempts resources from some or all rules until an expiry date
+type Exemption struct {
+	Address string   `yaml:"address" json:"address"` // Address glob of the exempted resources
+	Rules   []string `yaml:"rules" json:"rules"`     // Exempted rule names, all rules when empty
+	Owner   string   `yaml:"owner" json:"owner"`
+	Reason  string   `yaml:"reason" json:"reason"`
+	Expires string   `yaml:"expires" json:"expires"` // Last day the exemption applies, YYYY-MM-DD
+
+	expiresAt time.Time
+}
+
+// ParseFile reads a policy document and builds the policy it declares
+func ParseFile(reader io.Reader) (*Policy, error) {
+	var file File
+
+	decoder := yaml.NewDecoder(reader)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	p := &Policy{}
+	names := make(map[string]bool)
+	for i, fileRule := range file.Rules {
+		rule, err := fileRule.build()
+		if err != nil {
+			return nil, fmt.Errorf("rule %d (%s): %v", i+1, fileRule.Name, err)
+		}
+		if names[rule.name] {
+			return nil, fmt.Errorf("rule %d: duplicate rule name %q", i+1, rule.name)
+		}
+		names[rule.name] = true
+		p.Rules = append(p.Rules, rule)
+	}
+
+	if file.Tags != nil {
+		rule, err := file.Tags.build()
+		if err != nil {
+			return nil, fmt.Errorf("tags: %v", err)
+		}
+		if names[rule.Name()] {
+			return nil, fmt.Errorf("tags: rule name %q is already used by a rule", rule.Name())
+		}
+		p.Rules = append(p.Rules, rule)
+	}
+
+	for i, exemption := range file.Exemptions {
+		if err := exemption.validate(); err != nil {
+			return nil, fmt.Errorf("exemption %d (%s): %v", i+1, exemption.Address, err)
+		}
+		p.Exemptions = append(p.Exemptions, exemption)
+	}
+
+	return p, nil
+}
+
+// LoadFile reads a policy document from a file
+func LoadFile(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading policy file: %v", err)
+	}
+
+	p, err := ParseFile(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing policy file %s: %v", path, err)
+	}
+
+	return p, nil
+}
+
+// build validates a declared rule and converts it into a MatchRule
+func (r FileRule) build() (*MatchRule, error) {
+	if r.Name == "" {
+		return nil, fmt.Errorf("missing name")
+	}
+
+	severity, err := parseSeverity(r.Severity)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &filter.Filter{
+		Include:   r.Match.Addresses,
+		Types:     r.Match.Types,
+		Modules:   r.Match.Modules,
+		Providers: r.Match.Providers,
+		Changed:   r.Match.ChangedAttributes,
+	}
+	if err := f.ParseActions(r.Match.Actions); err != nil {
+		return nil, err
+	}
+	if r.Match.Where != "" {
+		expr, err := query.Parse(r.Match.Where)
+		if err != nil {
+			return nil, err
+		}
+		f.Where = expr
+	}
+	if f.IsEmpty() {
+		return nil, fmt.Errorf("match must have at least one criterion")
+	}
+
+	return &MatchRule{name: r.Name, description: r.Description, severity: severity, filter: f}, nil
+}
+
+// build validates the tag policy and converts it into a TagRule
+func (t TagPolicy) build() (*TagRule, error) {
+	if len(t.Required) == 0 && len(t.AllowedValues) == 0 {
+		return nil, fmt.Errorf("at least one required tag or allowed value list is needed")
+	}
+
+	severity, err := parseSeverity(t.Severity)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TagRule{
+		Required: t.Required,
+		Allowed:  t.AllowedValues,
+		Types:    t.Types,
+		Exclude:  t.Exclude,
+		Severity: severity,
+	}, nil
+}
+
+// parseSeverity parses a severity level, defaulting to error
+func parseSeverity(value string) (model.Severity, error) {
+	switch strings.ToLower(value) {
+	case "", "error":
+		return model.SeverityError, nil
+	case "warn", "warning":
+		return model.SeverityWarn, nil
+	case "info":
+		return model.SeverityInfo, nil
+	default:
+		return "", fmt.Errorf("unknown severity %q (expected info, warn or error)", value)
+	}
+}
+
+// validate checks the exemption fields and parses its expiry date
+func (e *Exemption) validate() error {
+	if e.Address == "" {
+		return fmt.Errorf("missing address")
+	}
+	if e.Owner == "" {
+		return fmt.Errorf("missing owner")
+	}
+	if e.Expires == "" {
+		return fmt.Errorf("missing expiry date")
+	}
+
+	expiresAt, err := time.Parse(expiryLayout, e.Expires)
+	if err != nil {
+		return fmt.Errorf("invalid expiry date %q (expected YYYY-MM-DD)", e.Expires)
+	}
+	e.expiresAt = expiresAt
+
+	return nil
+}
+
+// covers checks if the exemption applies to a resource change and rule
+func (e Exemption) covers(change *model.ResourceChange, rule string) bool {
+	if !filter.MatchAddress(e.Address, change) {
+		return false
+	}
+	if len(e.Rules) == 0 {
+		return true
+	}
+	for _, r := range e.Rules {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// toModel converts the exemption for a violation report, checking expiry against now.
+// An exemption applies through the whole of its expiry day.
+func (e Exemption) toModel(now time.Time) *model.PolicyExemption {
+	y, m, d := now.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+
+	return &model.PolicyExemption{
+		Owner:   e.Owner,
+		Reason:  e.Reason,
+		Expires: e.Expires,
+		Expired: today.After(e.expiresAt),
+	}
+}
+
+// MatchRule is a policy file rule that reports every resource matched by its criteria
+type MatchRule struct {
+	name        string
+	description string
+	severity    model.Severity
+	filter      *filter.Filter
+}
+
+// Name returns the rule name used in violation reports
+func (r *MatchRule) Name() string {
+	return r.name
+}
+
+// Check reports every matching resource
+func (r *MatchRule) Check(resources *model.ResourceCollection) []model.PolicyViolation {
+	var violations []model.PolicyViolation
+
+	for _, address := range resources.Addresses() {
+		change := resources.GetResourceChange(address)
+		if !r.filter.Matches(change) {
+			continue
+		}
+
+		message := fmt.Sprintf("%s violates %s", address, r.name)
+		if r.description != "" {
+			message = fmt.Sprintf("%s: %s", address, r.description)
+		}
+
+		violation := model.PolicyViolation{
+			Rule:     r.name,
+			Severity: r.severity,
+			Address:  address,
+			Message:  message,
+		}
+		if r.severity == model.SeverityError {
+			violation.ExitCode = ExitCodePolicyFile
+		}
+		violations = append(violations, violation)
+	}
+
+	return violations
+}
@@ -0,0 +1,118 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+const samplePolicyFile = `
+rules:
+  - name: protect-databases
+    description: databases must not be replaced
+    match:
+      actions: [replace]
+      types: ["aws_db_*"]
+  - name: review-iam
+    severity: warn
+    match:
+      types: [aws_iam_role]
+exemptions:
+  - address: aws_db_instance.main
+    rules: [protect-databases]
+    owner: dba-team
+    reason: planned engine upgrade
+    expires: "2026-03-31"
+`
+
+func TestParseFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		now      time.Time
+		exitCode int
+		expired  bool
+	}{
+		{
+			name:     "Valid exemption",
+			now:      time.Date(2026, 3, 31, 23, 0, 0, 0, time.UTC),
+			exitCode: 0,
+		},
+		{
+			name:     "Expired exemption",
+			now:      time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+			exitCode: ExitCodePolicyFile,
+			expired:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p, err := ParseFile(strings.NewReader(samplePolicyFile))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			p.Now = func() time.Time { return test.now }
+
+			violations := p.Evaluate(createSampleCollection())
+			if len(violations) != 2 {
+				t.Fatalf("Expected 2 violations, got %d: %+v", len(violations), violations)
+			}
+
+			db, iam := violations[0], violations[1]
+			if db.Rule != "protect-databases" || db.Severity != model.SeverityError || db.Exemption == nil {
+				t.Errorf("Unexpected database violation: %+v", db)
+			} else if db.Exemption.Expired != test.expired || db.Exemption.Owner != "dba-team" {
+				t.Errorf("Unexpected exemption: %+v", db.Exemption)
+			}
+			if iam.Rule != "review-iam" || iam.Severity != model.SeverityWarn || iam.ExitCode != 0 {
+				t.Errorf("Unexpected IAM violation: %+v", iam)
+			}
+
+			if code := ExitCode(violations); code != test.exitCode {
+				t.Errorf("Expected exit code %d, got %d", test.exitCode, code)
+			}
+		})
+	}
+}
+
+func TestParseFileJSON(t *testing.T) {
+	p, err := ParseFile(strings.NewReader(`{"rules": [{"name": "no-bucket-destroy", "match": {"actions": ["destroy"], "addresses": ["aws_s3_bucket.*"]}}]}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	violations := p.Evaluate(createSampleCollection())
+	if len(violations) != 1 || violations[0].Address != "aws_s3_bucket.old" {
+		t.Errorf("Expected a violation for aws_s3_bucket.old, got %+v", violations)
+	}
+	if violations[0].Message != "aws_s3_bucket.old violates no-bucket-destroy" {
+		t.Errorf("Unexpected message %q", violations[0].Message)
+	}
+}
+
+func TestParseFileErrors(t *testing.T) {
+	tests := map[string]string{
+		"Missing name":     "rules:\n  - match: {actions: [destroy]}\n",
+		"Empty match":      "rules:\n  - name: empty\n",
+		"Unknown severity": "rules:\n  - name: r\n    severity: fatal\n    match: {actions: [destroy]}\n",
+		"Unknown action":   "rules:\n  - name: r\n    match: {actions: [explode]}\n",
+		"Invalid where":    "rules:\n  - name: r\n    match: {where: 'type =='}\n",
+		"Duplicate name":   "rules:\n  - name: r\n    match: {actions: [destroy]}\n  - name: r\n    match: {actions: [update]}\n",
+		"Unknown field":    "rules:\n  - name: r\n    level: warn\n    match: {actions: [destroy]}\n",
+		"Tags name":        "rules:\n  - name: tags\n    match: {actions: [destroy]}\ntags:\n  required: [Owner]\n",
+		"Missing owner":    "exemptions:\n  - address: a.b\n    expires: 2026-01-01\n",
+		"Missing expiry":   "exemptions:\n  - address: a.b\n    owner: me\n",
+		"Invalid expiry":   "exemptions:\n  - address: a.b\n    owner: me\n    expires: next week\n",
+		"Missing address":  "exemptions:\n  - owner: me\n    expires: 2026-01-01\n",
+	}
+
+	for name, source := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseFile(strings.NewReader(source)); err == nil {
+				t.Errorf("Expected an error for:\n%s", source)
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package policy
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/filter"
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+	"github.com/marc-poljak/terraform-plan-filter/internal/query"
+)
+
+// Exit codes for policy violations. When several rules are violated the highest code is used.
+const (
+	ExitCodeMaxChanges = 3
+	ExitCodeWhere      = 4
+	ExitCodeDestroy    = 5
+	ExitCodeReplace    = 6
+	ExitCodePolicyFile = 7
+	ExitCodeTags       = 8
+)
+
+// Rule checks a resource collection for policy violations
+type Rule interface {
+	Name() string
+	Check(resources *model.ResourceCollection) []model.PolicyViolation
+}
+
+// Policy is a set of rules evaluated together
+type Policy struct {
+	Rules      []Rule
+	Exemptions []Exemption
+	Now        func() time.Time // Clock used to check exemption expiry, time.Now when nil
+}
+
+// Merge adds the -fail-on rules to the rules of a policy file. A -fail-on rule may not
+// share its name with a rule of the file, as their violations could not be told apart.
+func (p *Policy) Merge(rules []Rule) error {
+	names := make(map[string]bool)
+	for _, rule := range p.Rules {
+		names[rule.Name()] = true
+	}
+
+	for _, rule := range rules {
+		if names[rule.Name()] {
+			return fmt.Errorf("-fail-on rule %q has the same name as a rule of the policy file", rule.Name())
+		}
+	}
+	p.Rules = append(p.Rules, rules...)
+	return nil
+}
+
+// Evaluate checks all rules and returns the violations sorted by address and rule
+func (p *Policy) Evaluate(resources *model.ResourceCollection) []model.PolicyViolation {
+	var violations []model.PolicyViolation
+	for _, rule := range p.Rules {
+		violations = append(violations, rule.Check(resources)...)
+	}
+
+	// Attach the exemptions covering each violation
+	now := time.Now
+	if p.Now != nil {
+		now = p.Now
+	}
+	for i := range violations {
+		violations[i].Exemption = p.findExemption(resources, violations[i], now())
+	}
+
+	sort.SliceStable(violations, func(i, j int) bool {
+		if violations[i].Address != violations[j].Address {
+			return violations[i].Address < violations[j].Address
+		}
+		return violations[i].Rule < violations[j].Rule
+	})
+
+	return violations
+}
+
+// findExemption returns the first exemption covering a violation, preferring ones that are still valid
+func (p *Policy) findExemption(resources *model.ResourceCollection, violation model.PolicyViolation, now time.Time) *model.PolicyExemption {
+	if violation.Address == "" {
+		return nil
+	}
+
+	change := resources.GetResourceChange(violation.Address)
+	var expired *model.PolicyExemption
+	for _, exemption := range p.Exemptions {
+		if !exemption.covers(change, violation.Rule) {
+			continue
+		}
+		result := exemption.toModel(now)
+		if !result.Expired {
+			return result
+		}
+		if expired == nil {
+			expired = result
+		}
+	}
+	return expired
+}
+
+// ExitCode returns the process exit code for a list of violations, zero if none of them
+// fail the run. Exempted and non-error violations don't fail the run.
+func ExitCode(violations []model.PolicyViolation) int {
+	code := 0
+	for _, v := range violations {
+		if v.IsBlocking() && v.ExitCode > code {
+			code = v.ExitCode
+		}
+	}
+	return code
+}
+
+// ParseRule parses a -fail-on rule specification:
+//
+//	destroy[:patterns]    fail on destroyed resources (including replacements)
+//	replace[:patterns]    fail on replaced resources
+//	max-changes:N         fail when the plan has more than N changes
+//	where:EXPR            fail on resources matching a query expression
+//	tags:KEYS             fail on created or updated resources missing required tags
+//
+// Patterns are comma-separated resource type or address globs limiting the rule.
+func ParseRule(spec string) (Rule, error) {
+	name, arg, hasArg := strings.Cut(spec, ":")
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	switch name {
+	case "destroy":
+		return &ActionRule{Action: model.ActionDestroy, Patterns: filter.SplitList(arg)}, nil
+	case "replace":
+		return &ActionRule{Replace: true, Patterns: filter.SplitList(arg)}, nil
+	case "max-changes":
+		limit, err := strconv.Atoi(strings.TrimSpace(arg))
+		if !hasArg || err != nil || limit < 0 {
+			return nil, fmt.Errorf("max-changes requires a non-negative number, e.g. max-changes:50")
+		}
+		return &MaxChangesRule{Limit: limit}, nil
+	case "where":
+		if !hasArg || strings.TrimSpace(arg) == "" {
+			return nil, fmt.Errorf("where requires a query expression, e.g. where:'type == \"aws_iam_role\"'")
+		}
+		expr, err := query.Parse(arg)
+		if err != nil {
+			return nil, err
+		}
+		return &WhereRule{Expr: expr}, nil
+	case "tags":
+		return parseTagRule(arg)
+	default:
+		return nil, fmt.Errorf("unknown rule %q (expected destroy, replace, max-changes, where or tags)", name)
+	}
+}
+
+// ActionRule fails on resources that are destroyed or replaced, optionally limited to
+// resources whose type or address matches one of the patterns
+type ActionRule struct {
+	Action   model.Action // Action to fail on, ignored when Replace is set
+	Replace  bool         // Fail on replacements instead of an action
+	Patterns []string     // Resource type or address globs, all resources when empty
+}
+
+// Name returns the rule name used in violation reports
+func (r *ActionRule) Name() string {
+	if r.Replace {
+		return "no-replace"
+	}
+	return "no-" + string(r.Action)
+}
+
+// Check reports every matching resource
+func (r *ActionRule) Check(resources *model.ResourceCollection) []model.PolicyViolation {
+	var violations []model.PolicyViolation
+
+	for _, address := range resources.Addresses() {
+		change := resources.GetResourceChange(address)
+		if !r.matches(change) {
+			continue
+		}
+
+		violation := model.PolicyViolation{Rule: r.Name(), Severity: model.SeverityError, Address: address}
+		if r.Replace {
+			violation.Message = fmt.Sprintf("%s will be replaced", address)
+			violation.ExitCode = ExitCodeReplace
+		} else {
+			violation.Message = fmt.Sprintf("%s will be destroyed", address)
+			if change.IsReplacement() {
+				violation.Message = fmt.Sprintf("%s will be destroyed and re-created", address)
+			}
+			violation.ExitCode = ExitCodeDestroy
+		}
+		violations = append(violations, violation)
+	}
+
+	return violations
+}
+
+// matches checks the action and patterns of the rule against a change
+func (r *ActionRule) matches(change *model.ResourceChange) bool {
+	if r.Replace && !change.IsReplacement() {
+		return false
+	}
+	if !r.Replace && !change.HasAction(r.Action) {
+		return false
+	}
+
+	if len(r.Patterns) == 0 {
+		return true
+	}
+	for _, pattern := range r.Patterns {
+		if model.MatchGlob(pattern, change.Type) || filter.MatchAddress(pattern, change) {
+			return true
+		}
+	}
+	return false
+}
+
+// MaxChangesRule fails when the plan has more changes than the limit
+type MaxChangesRule struct {
+	Limit int
+}
+
+// Name returns the rule name used in violation reports
+func (r *MaxChangesRule) Name() string {
+	return "max-changes"
+}
+
+// Check reports a plan-level violation when the limit is exceeded
+func (r *MaxChangesRule) Check(resources *model.ResourceCollection) []model.PolicyViolation {
+	total := resources.TotalChanges()
+	if total <= r.Limit {
+		return nil
+	}
+
+	return []model.PolicyViolation{{
+		Rule:     r.Name(),
+		Severity: model.SeverityError,
+		Message:  fmt.Sprintf("%d changes exceed the maximum of %d", total, r.Limit),
+		ExitCode: ExitCodeMaxChanges,
+	}}
+}
+
+// WhereRule fails on resources matching a query expression
+type WhereRule struct {
+	Expr *query.Expr
+}
+
+// Name returns the rule name used in violation reports
+func (r *WhereRule) Name() string {
+	return "where"
+}
+
+// Check reports every resource matching the expression
+func (r *WhereRule) Check(resources *model.ResourceCollection) []model.PolicyViolation {
+	var violations []model.PolicyViolation
+
+	for _, address := range resources.Addresses() {
+		if !r.Expr.Match(resources.GetResourceChange(address)) {
+			continue
+		}
+		violations = append(violations, model.PolicyViolation{
+			Rule:     r.Name(),
+			Severity: model.SeverityError,
+			Address:  address,
+			Message:  fmt.Sprintf("%s matches %s", address, r.Expr),
+			ExitCode: ExitCodeWhere,
+		})
+	}
+
+	return violations
+}
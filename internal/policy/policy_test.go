@@ -0,0 +1,143 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+// createSampleCollection returns a collection with a destroy, a replacement and an update
+func createSampleCollection() *model.ResourceCollection {
+	resources := model.NewResourceCollection()
+	resources.AddResourceChange(&model.ResourceChange{
+		Address: "aws_db_instance.main", Type: "aws_db_instance", Name: "main",
+		Actions: []model.Action{model.ActionDestroy, model.ActionCreate},
+	})
+	resources.AddResourceChange(&model.ResourceChange{
+		Address: "aws_instance.web", Type: "aws_instance", Name: "web",
+		Actions: []model.Action{model.ActionDestroy, model.ActionCreate},
+	})
+	resources.AddResourceChange(&model.ResourceChange{
+		Address: "aws_s3_bucket.old", Type: "aws_s3_bucket", Name: "old",
+		Actions: []model.Action{model.ActionDestroy},
+	})
+	resources.AddResourceChange(&model.ResourceChange{
+		Address: "aws_iam_role.ci", Type: "aws_iam_role", Name: "ci",
+		Actions: []model.Action{model.ActionUpdate},
+	})
+	return resources
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name      string
+		specs     []string
+		addresses []string
+		exitCode  int
+	}{
+		{
+			name:      "No destroys",
+			specs:     []string{"destroy"},
+			addresses: []string{"aws_db_instance.main", "aws_instance.web", "aws_s3_bucket.old"},
+			exitCode:  ExitCodeDestroy,
+		},
+		{
+			name:      "No replacement of databases",
+			specs:     []string{"replace:aws_db_instance,aws_rds_cluster"},
+			addresses: []string{"aws_db_instance.main"},
+			exitCode:  ExitCodeReplace,
+		},
+		{
+			name:      "Protected address glob",
+			specs:     []string{"destroy:aws_s3_bucket.*"},
+			addresses: []string{"aws_s3_bucket.old"},
+			exitCode:  ExitCodeDestroy,
+		},
+		{
+			name:      "Max changes exceeded",
+			specs:     []string{"max-changes:3"},
+			addresses: []string{""},
+			exitCode:  ExitCodeMaxChanges,
+		},
+		{
+			name:     "Max changes within limit",
+			specs:    []string{"max-changes:50"},
+			exitCode: 0,
+		},
+		{
+			name:      "Where expression",
+			specs:     []string{`where:type == "aws_iam_role" && action == "update"`},
+			addresses: []string{"aws_iam_role.ci"},
+			exitCode:  ExitCodeWhere,
+		},
+		{
+			name:      "Highest exit code wins",
+			specs:     []string{"max-changes:0", "replace:aws_db_*"},
+			addresses: []string{"", "aws_db_instance.main"},
+			exitCode:  ExitCodeReplace,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Policy{}
+			for _, spec := range tt.specs {
+				rule, err := ParseRule(spec)
+				if err != nil {
+					t.Fatalf("Unexpected error parsing %q: %v", spec, err)
+				}
+				p.Rules = append(p.Rules, rule)
+			}
+
+			violations := p.Evaluate(createSampleCollection())
+			if len(violations) != len(tt.addresses) {
+				t.Fatalf("Expected %d violations, got %d: %v", len(tt.addresses), len(violations), violations)
+			}
+			for i, v := range violations {
+				if v.Address != tt.addresses[i] {
+					t.Errorf("Expected violation %d for %q, got %q", i, tt.addresses[i], v.Address)
+				}
+				if v.Message == "" {
+					t.Errorf("Expected violation %d to have a message", i)
+				}
+			}
+
+			if code := ExitCode(violations); code != tt.exitCode {
+				t.Errorf("Expected exit code %d, got %d", tt.exitCode, code)
+			}
+		})
+	}
+}
+
+func TestParseRuleErrors(t *testing.T) {
+	specs := []string{"explode", "max-changes", "max-changes:-1", "max-changes:many", "where:", `where:type ==`}
+
+	for _, spec := range specs {
+		if _, err := ParseRule(spec); err == nil {
+			t.Errorf("Expected error for %q, but got none", spec)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	filePolicy, err := ParseFile(strings.NewReader("rules:\n  - name: no-destroy\n    match: {actions: [destroy]}\ntags:\n  required: [Owner]\n"))
+	if err != nil {
+		t.Fatalf("ParseFile returned an error: %v", err)
+	}
+
+	for _, spec := range []string{"tags:CostCenter", "destroy:aws_db_*"} {
+		rule, err := ParseRule(spec)
+		if err != nil {
+			t.Fatalf("ParseRule returned an error: %v", err)
+		}
+		if err := filePolicy.Merge([]Rule{rule}); err == nil {
+			t.Errorf("Expected an error merging %s into a policy with a rule of the same name", spec)
+		}
+	}
+
+	rule, _ := ParseRule("max-changes:50")
+	if err := filePolicy.Merge([]Rule{rule}); err != nil || len(filePolicy.Rules) != 3 {
+		t.Errorf("Expected the rule to be added, got %v with %d rules", err, len(filePolicy.Rules))
+	}
+}
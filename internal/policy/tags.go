@@ -0,0 +1,199 @@
+package policy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/filter"
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+// TagRule reports created and updated resources that are missing required tags or
+// carry tag values outside an allowed set
+type TagRule struct {
+	Required []string            // Tag keys every taggable resource must have
+	Allowed  map[string][]string // Allowed values by tag key, checked when the tag is set
+	Types    []string            // Resource type globs to check, all taggable resources when empty
+	Exclude  []string            // Resource type globs never checked
+	Severity model.Severity      // Severity of the violations, error when empty
+}
+
+// Name returns the rule name used in violation reports
+func (r *TagRule) Name() string {
+	return "tags"
+}
+
+// parseTagRule parses the patterns of a -fail-on tags rule. Each entry is a required
+// tag key, optionally with its allowed values: Owner,Environment=dev|staging|prod
+func parseTagRule(arg string) (*TagRule, error) {
+	rule := &TagRule{Allowed: map[string][]string{}}
+	for _, entry := range filter.SplitList(arg) {
+		key, values, hasValues := strings.Cut(entry, "=")
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return nil, fmt.Errorf("empty tag key in %q", entry)
+		}
+		rule.Required = append(rule.Required, key)
+		if hasValues {
+			rule.Allowed[key] = strings.Split(values, "|")
+		}
+	}
+
+	if len(rule.Required) == 0 {
+		return nil, fmt.Errorf("tags requires tag keys, e.g. tags:Owner,CostCenter,Environment=dev|prod")
+	}
+	return rule, nil
+}
+
+// Check reports every created or updated resource that breaks the tag policy
+func (r *TagRule) Check(resources *model.ResourceCollection) []model.PolicyViolation {
+	var violations []model.PolicyViolation
+
+	for _, address := range resources.Addresses() {
+		change, ok := resources.Changes[address]
+		if !ok || !r.applies(change) {
+			continue
+		}
+
+		tags, attribute, taggable := resourceTags(change)
+		if !taggable {
+			continue
+		}
+
+		for _, message := range r.problems(tags, attribute, isLabelProvider(change.Type)) {
+			violations = append(violations, r.violation(address, fmt.Sprintf("%s %s", address, message)))
+		}
+	}
+
+	return violations
+}
+
+// applies checks if the rule covers the action and type of a change
+func (r *TagRule) applies(change *model.ResourceChange) bool {
+	if !change.HasAction(model.ActionCreate) && !change.HasAction(model.ActionUpdate) {
+		return false
+	}
+	if model.MatchAnyGlob(r.Exclude, change.Type) {
+		return false
+	}
+	return len(r.Types) == 0 || model.MatchAnyGlob(r.Types, change.Type)
+}
+
+// problems returns the missing required tags and the tags with values that aren't allowed
+func (r *TagRule) problems(tags map[string]string, attribute string, lowercaseKeys bool) []string {
+	var problems []string
+
+	var missing []string
+	for _, key := range r.Required {
+		if _, ok := tags[tagKey(key, lowercaseKeys)]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		problems = append(problems, fmt.Sprintf("is missing required %s: %s", attribute, strings.Join(missing, ", ")))
+	}
+
+	keys := make([]string, 0, len(r.Allowed))
+	for key := range r.Allowed {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value, ok := tags[tagKey(key, lowercaseKeys)]
+		if !ok || containsString(r.Allowed[key], value) {
+			continue
+		}
+		problems = append(problems, fmt.Sprintf("has %s %s=%q outside the allowed values: %s",
+			attribute, key, value, strings.Join(r.Allowed[key], ", ")))
+	}
+
+	return problems
+}
+
+// violation creates a violation with the severity and exit code of the rule
+func (r *TagRule) violation(address, message string) model.PolicyViolation {
+	severity := r.Severity
+	if severity == "" {
+		severity = model.SeverityError
+	}
+
+	violation := model.PolicyViolation{Rule: r.Name(), Severity: severity, Address: address, Message: message}
+	if severity == model.SeverityError {
+		violation.ExitCode = ExitCodeTags
+	}
+	return violation
+}
+
+// tagAttributes lists where each provider keeps the tags of a resource, in order of preference.
+// tags_all and effective_labels include the defaults set on the provider.
+var tagAttributes = map[string][]string{
+	"aws":     {"tags_all", "tags"},
+	"azurerm": {"tags"},
+	"google":  {"effective_labels", "labels"},
+}
+
+// resourceTags returns the tags a resource will have after the change, the name of the
+// attribute they come from, and whether the resource supports tags at all
+func resourceTags(change *model.ResourceChange) (map[string]string, string, bool) {
+	attributes, ok := tagAttributes[providerPrefix(change.Type)]
+	if !ok {
+		attributes = []string{"tags"}
+	}
+
+	after, ok := change.After.(map[string]interface{})
+	if !ok {
+		return nil, "", false
+	}
+
+	for _, attribute := range attributes {
+		value, exists := after[attribute]
+		if !exists {
+			continue
+		}
+		// Tags only known after apply can't be checked, so try the next attribute
+		if unknown, _ := model.LookupAttribute(change.AfterUnknown, []string{attribute}); unknown == true {
+			continue
+		}
+
+		tags := map[string]string{}
+		if m, ok := value.(map[string]interface{}); ok {
+			for k, v := range m {
+				tags[k] = fmt.Sprint(v)
+			}
+		}
+		return tags, strings.TrimSuffix(strings.TrimPrefix(attribute, "effective_"), "_all"), true
+	}
+
+	return nil, "", false
+}
+
+// providerPrefix returns the provider part of a resource type, e.g. "aws" for aws_instance
+func providerPrefix(resourceType string) string {
+	prefix, _, _ := strings.Cut(resourceType, "_")
+	return prefix
+}
+
+// isLabelProvider checks if the resource uses GCP labels, whose keys are always lowercase
+func isLabelProvider(resourceType string) bool {
+	return providerPrefix(resourceType) == "google"
+}
+
+// tagKey returns the key under which a required tag is stored
+func tagKey(key string, lowercase bool) string {
+	if lowercase {
+		return strings.ToLower(key)
+	}
+	return key
+}
+
+// containsString checks if a list contains a value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+// createTaggedCollection returns a collection with resources of several providers
+func createTaggedCollection() *model.ResourceCollection {
+	resources := model.NewResourceCollection()
+	resources.AddResourceChange(&model.ResourceChange{
+		Address: "aws_instance.web", Type: "aws_instance", Name: "web",
+		Actions: []model.Action{model.ActionCreate},
+		After: map[string]interface{}{
+			"tags":     map[string]interface{}{"Owner": "web-team"},
+			"tags_all": map[string]interface{}{"Owner": "web-team", "Environment": "qa"},
+		},
+	})
+	resources.AddResourceChange(&model.ResourceChange{
+		Address: "google_storage_bucket.data", Type: "google_storage_bucket", Name: "data",
+		Actions: []model.Action{model.ActionUpdate},
+		After: map[string]interface{}{
+			"labels": map[string]interface{}{"owner": "data", "costcenter": "42", "environment": "prod"},
+		},
+	})
+	resources.AddResourceChange(&model.ResourceChange{
+		Address: "azurerm_resource_group.main", Type: "azurerm_resource_group", Name: "main",
+		Actions: []model.Action{model.ActionCreate},
+		After:   map[string]interface{}{"tags": nil},
+	})
+	resources.AddResourceChange(&model.ResourceChange{
+		Address: "aws_iam_role_policy_attachment.ci", Type: "aws_iam_role_policy_attachment", Name: "ci",
+		Actions: []model.Action{model.ActionCreate},
+		After:   map[string]interface{}{"role": "ci"},
+	})
+	resources.AddResourceChange(&model.ResourceChange{
+		Address: "aws_s3_bucket.old", Type: "aws_s3_bucket", Name: "old",
+		Actions: []model.Action{model.ActionDestroy},
+	})
+	return resources
+}
+
+func TestTagRule(t *testing.T) {
+	rule, err := ParseRule("tags:Owner,CostCenter,Environment=dev|staging|prod")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	violations := rule.Check(createTaggedCollection())
+	expected := []string{
+		"aws_instance.web is missing required tags: CostCenter",
+		`aws_instance.web has tags Environment="qa" outside the allowed values: dev, staging, prod`,
+		"azurerm_resource_group.main is missing required tags: Owner, CostCenter, Environment",
+	}
+
+	var messages []string
+	for _, v := range violations {
+		messages = append(messages, v.Message)
+		if v.ExitCode != ExitCodeTags || v.Severity != model.SeverityError {
+			t.Errorf("Unexpected exit code or severity: %+v", v)
+		}
+	}
+	if strings.Join(messages, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected violations:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(messages, "\n"))
+	}
+}
+
+func TestTagPolicyFile(t *testing.T) {
+	p, err := ParseFile(strings.NewReader(`
+tags:
+  required: [Owner]
+  types: ["aws_*"]
+  severity: warn
+`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	violations := p.Evaluate(createTaggedCollection())
+	if len(violations) != 0 {
+		t.Errorf("Expected no violations, got %+v", violations)
+	}
+
+	if _, err := ParseFile(strings.NewReader("tags:\n  severity: warn\n")); err == nil {
+		t.Errorf("Expected an error for a tag policy without tags")
+	}
+	if _, err := ParseRule("tags:"); err == nil {
+		t.Errorf("Expected an error for a tags rule without keys")
+	}
+}
@@ -0,0 +1,270 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+// fieldResolvers maps the resource fields available in expressions to their lookup functions.
+// Fields can resolve to several values (a replacement has both "create" and "destroy"
+// actions), in which case a comparison matches if any value matches.
+var fieldResolvers = map[string]func(change *model.ResourceChange) []interface{}{
+	"address": func(c *model.ResourceChange) []interface{} { return []interface{}{c.Address} },
+	"module":  func(c *model.ResourceChange) []interface{} { return []interface{}{c.ModuleAddress} },
+	"type":    func(c *model.ResourceChange) []interface{} { return []interface{}{c.Type} },
+	"name":    func(c *model.ResourceChange) []interface{} { return []interface{}{c.Name} },
+	"provider": func(c *model.ResourceChange) []interface{} {
+		return []interface{}{c.ProviderName}
+	},
+	"action": func(c *model.ResourceChange) []interface{} {
+		values := make([]interface{}, 0, len(c.Actions)+1)
+		for _, action := range c.Actions {
+			values = append(values, string(action))
+		}
+		if c.IsReplacement() {
+			values = append(values, "replace")
+		}
+		return values
+	},
+	"changed": func(c *model.ResourceChange) []interface{} {
+		paths := c.ChangedAttributes()
+		values := make([]interface{}, 0, len(paths))
+		for _, path := range paths {
+			values = append(values, path)
+		}
+		return values
+	},
+}
+
+// attributePrefixes maps the prefixes of attribute path fields to their lookup functions.
+// A field such as after.instance_type resolves to the decoded attribute value, or null when unset.
+var attributePrefixes = map[string]func(change *model.ResourceChange, path string) (interface{}, bool){
+	"before.": (*model.ResourceChange).BeforeAttribute,
+	"after.":  (*model.ResourceChange).AfterAttribute,
+}
+
+// FieldNames returns the sorted names of the fields available in expressions
+func FieldNames() []string {
+	names := make([]string, 0, len(fieldResolvers))
+	for name := range fieldResolvers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateField checks that a field name can be resolved
+func validateField(field string) error {
+	if _, ok := fieldResolvers[field]; ok {
+		return nil
+	}
+
+	for prefix := range attributePrefixes {
+		if strings.HasPrefix(field, prefix) {
+			if len(model.ParseAttributePath(strings.TrimPrefix(field, prefix))) == 0 {
+				return fmt.Errorf("missing attribute path after %q", prefix)
+			}
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown field %q (available: %s, before.<path>, after.<path>)", field, strings.Join(FieldNames(), ", "))
+}
+
+// resolveField returns the values of a field for a resource change
+func resolveField(change *model.ResourceChange, field string) []interface{} {
+	if resolver, ok := fieldResolvers[field]; ok {
+		return resolver(change)
+	}
+
+	for prefix, lookup := range attributePrefixes {
+		if strings.HasPrefix(field, prefix) {
+			value, _ := lookup(change, strings.TrimPrefix(field, prefix))
+			return []interface{}{value}
+		}
+	}
+	return nil
+}
+
+// Match evaluates the expression against a resource change
+func (e *Expr) Match(change *model.ResourceChange) bool {
+	return e.root.evaluate(change)
+}
+
+// node is an element of a parsed expression tree
+type node interface {
+	evaluate(change *model.ResourceChange) bool
+}
+
+// andNode matches when both operands match
+type andNode struct {
+	left, right node
+}
+
+func (n *andNode) evaluate(change *model.ResourceChange) bool {
+	return n.left.evaluate(change) && n.right.evaluate(change)
+}
+
+// orNode matches when either operand matches
+type orNode struct {
+	left, right node
+}
+
+func (n *orNode) evaluate(change *model.ResourceChange) bool {
+	return n.left.evaluate(change) || n.right.evaluate(change)
+}
+
+// notNode inverts its operand
+type notNode struct {
+	operand node
+}
+
+func (n *notNode) evaluate(change *model.ResourceChange) bool {
+	return !n.operand.evaluate(change)
+}
+
+// existsNode matches when a field has a value that is set and not false or empty
+type existsNode struct {
+	field string
+}
+
+func (n *existsNode) evaluate(change *model.ResourceChange) bool {
+	for _, value := range resolveField(change, n.field) {
+		if isTruthy(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// compareNode compares a field against a literal value
+type compareNode struct {
+	field   string
+	op      string
+	literal interface{}
+	re      *regexp.Regexp
+}
+
+func (n *compareNode) evaluate(change *model.ResourceChange) bool {
+	values := resolveField(change, n.field)
+
+	// Negated operators match when no value matches the positive form
+	switch n.op {
+	case "!=":
+		return !anyValue(values, func(v interface{}) bool { return valuesEqual(v, n.literal) })
+	case "!~":
+		return !anyValue(values, func(v interface{}) bool { return n.re.MatchString(stringValue(v)) })
+	}
+
+	return anyValue(values, func(v interface{}) bool { return n.compare(v) })
+}
+
+// compare applies the positive form of the operator to a single value
+func (n *compareNode) compare(value interface{}) bool {
+	switch n.op {
+	case "==":
+		return valuesEqual(value, n.literal)
+	case "=~":
+		return value != nil && n.re.MatchString(stringValue(value))
+	case "startswith":
+		return value != nil && strings.HasPrefix(stringValue(value), n.literal.(string))
+	case "endswith":
+		return value != nil && strings.HasSuffix(stringValue(value), n.literal.(string))
+	case "contains":
+		return containsValue(value, n.literal.(string))
+	case "<", "<=", ">", ">=":
+		number, ok := value.(float64)
+		if !ok {
+			return false
+		}
+		return compareNumbers(number, n.op, n.literal.(float64))
+	}
+	return false
+}
+
+// anyValue checks if the predicate holds for any of the values
+func anyValue(values []interface{}, predicate func(interface{}) bool) bool {
+	for _, value := range values {
+		if predicate(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// valuesEqual compares a resolved value with a literal
+func valuesEqual(value, literal interface{}) bool {
+	switch lit := literal.(type) {
+	case nil:
+		return value == nil
+	case string:
+		s, ok := value.(string)
+		return ok && s == lit
+	default:
+		return value == literal
+	}
+}
+
+// containsValue checks if a string contains a substring, a list holds an equal string element
+// or a map has the key
+func containsValue(value interface{}, needle string) bool {
+	switch v := value.(type) {
+	case string:
+		return strings.Contains(v, needle)
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok && s == needle {
+				return true
+			}
+		}
+	case map[string]interface{}:
+		_, ok := v[needle]
+		return ok
+	}
+	return false
+}
+
+// compareNumbers applies a numeric comparison operator
+func compareNumbers(left float64, op string, right float64) bool {
+	switch op {
+	case "<":
+		return left < right
+	case "<=":
+		return left <= right
+	case ">":
+		return left > right
+	case ">=":
+		return left >= right
+	}
+	return false
+}
+
+// stringValue converts a resolved value to a string for pattern matching
+func stringValue(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	return fmt.Sprint(value)
+}
+
+// isTruthy checks if a value is set and not false, zero-length or null
+func isTruthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case []interface{}:
+		return len(v) > 0
+	case map[string]interface{}:
+		return len(v) > 0
+	default:
+		return true
+	}
+}
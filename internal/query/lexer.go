@@ -0,0 +1,201 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// tokenKind identifies the type of a lexical token
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokAnd
+	tokOr
+	tokNot
+	tokLParen
+	tokRParen
+	tokEq
+	tokNeq
+	tokMatch
+	tokNotMatch
+	tokLt
+	tokLte
+	tokGt
+	tokGte
+)
+
+// tokenNames holds the display names of tokens used in error messages
+var tokenNames = map[tokenKind]string{
+	tokEOF:      "end of expression",
+	tokIdent:    "identifier",
+	tokString:   "string",
+	tokNumber:   "number",
+	tokAnd:      "\"&&\"",
+	tokOr:       "\"||\"",
+	tokNot:      "\"!\"",
+	tokLParen:   "\"(\"",
+	tokRParen:   "\")\"",
+	tokEq:       "\"==\"",
+	tokNeq:      "\"!=\"",
+	tokMatch:    "\"=~\"",
+	tokNotMatch: "\"!~\"",
+	tokLt:       "\"<\"",
+	tokLte:      "\"<=\"",
+	tokGt:       "\">\"",
+	tokGte:      "\">=\"",
+}
+
+// token is a single lexical token with its position in the source
+type token struct {
+	kind  tokenKind
+	text  string
+	value interface{}
+	pos   int
+}
+
+// describe returns a human-readable description of the token for error messages
+func (t token) describe() string {
+	switch t.kind {
+	case tokIdent, tokNumber:
+		return fmt.Sprintf("%q", t.text)
+	case tokString:
+		return "string " + t.text
+	default:
+		return tokenNames[t.kind]
+	}
+}
+
+// keywordTokens maps word operators to their symbolic equivalents
+var keywordTokens = map[string]tokenKind{
+	"and": tokAnd,
+	"or":  tokOr,
+	"not": tokNot,
+}
+
+// lex splits an expression into tokens
+func lex(source string) ([]token, error) {
+	var tokens []token
+	i := 0
+
+	for i < len(source) {
+		c := source[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"':
+			tok, next, err := lexString(source, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
+			i = next
+		case isDigit(c) || (c == '-' && i+1 < len(source) && isDigit(source[i+1])):
+			start := i
+			i++
+			for i < len(source) && (isDigit(source[i]) || source[i] == '.') {
+				i++
+			}
+			text := source[start:i]
+			value, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, newSyntaxError(source, start, "invalid number %q", text)
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: text, value: value, pos: start})
+		case isIdentStart(c):
+			start := i
+			for i < len(source) && isIdentChar(source[i]) {
+				i++
+			}
+			text := source[start:i]
+			if kind, ok := keywordTokens[strings.ToLower(text)]; ok {
+				tokens = append(tokens, token{kind: kind, text: text, pos: start})
+			} else {
+				tokens = append(tokens, token{kind: tokIdent, text: text, pos: start})
+			}
+		default:
+			kind, width := lexOperator(source[i:])
+			if width == 0 {
+				return nil, newSyntaxError(source, i, "unexpected character %q", string(c))
+			}
+			tokens = append(tokens, token{kind: kind, text: source[i : i+width], pos: i})
+			i += width
+		}
+	}
+
+	return append(tokens, token{kind: tokEOF, pos: len(source)}), nil
+}
+
+// lexString reads a double-quoted string literal starting at pos
+func lexString(source string, pos int) (token, int, error) {
+	i := pos + 1
+	for i < len(source) {
+		switch source[i] {
+		case '\\':
+			i += 2
+			continue
+		case '"':
+			text := source[pos : i+1]
+			value, err := strconv.Unquote(text)
+			if err != nil {
+				return token{}, 0, newSyntaxError(source, pos, "invalid string literal %s", text)
+			}
+			return token{kind: tokString, text: text, value: value, pos: pos}, i + 1, nil
+		}
+		i++
+	}
+	return token{}, 0, newSyntaxError(source, pos, "unterminated string")
+}
+
+// lexOperator returns the operator at the start of s and its width, or zero width if none
+func lexOperator(s string) (tokenKind, int) {
+	twoChar := map[string]tokenKind{
+		"&&": tokAnd,
+		"||": tokOr,
+		"==": tokEq,
+		"!=": tokNeq,
+		"=~": tokMatch,
+		"!~": tokNotMatch,
+		"<=": tokLte,
+		">=": tokGte,
+	}
+	if len(s) >= 2 {
+		if kind, ok := twoChar[s[:2]]; ok {
+			return kind, 2
+		}
+	}
+
+	switch s[0] {
+	case '!':
+		return tokNot, 1
+	case '(':
+		return tokLParen, 1
+	case ')':
+		return tokRParen, 1
+	case '<':
+		return tokLt, 1
+	case '>':
+		return tokGt, 1
+	}
+	return tokEOF, 0
+}
+
+// isDigit checks if c is an ASCII digit
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// isIdentStart checks if c can start an identifier
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// isIdentChar checks if c can appear in an identifier, including attribute paths like tags.Owner or rule[0]
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || isDigit(c) || c == '.' || c == '[' || c == ']'
+}
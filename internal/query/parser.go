@@ -0,0 +1,247 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// SyntaxError describes a problem in a query expression and where it occurred
+type SyntaxError struct {
+	Source string // The full expression
+	Pos    int    // Byte offset of the problem in the expression
+	Msg    string // Description of the problem
+}
+
+// Error formats the syntax error with a caret pointing at the problem
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at position %d: %s\n  %s\n  %s^",
+		e.Pos+1, e.Msg, e.Source, strings.Repeat(" ", e.Pos))
+}
+
+// newSyntaxError creates a SyntaxError with a formatted message
+func newSyntaxError(source string, pos int, format string, args ...interface{}) *SyntaxError {
+	return &SyntaxError{Source: source, Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// Expr is a parsed query expression that can be evaluated against resources
+type Expr struct {
+	source string
+	root   node
+}
+
+// String returns the source text of the expression
+func (e *Expr) String() string {
+	return e.source
+}
+
+// wordOperators lists the comparison operators that are spelled as words
+var wordOperators = map[string]bool{
+	"startswith": true,
+	"endswith":   true,
+	"contains":   true,
+}
+
+// Parse parses a query expression.
+//
+// Grammar:
+//
+//	expr       = or
+//	or         = and { ("||" | "or") and }
+//	and        = unary { ("&&" | "and") unary }
+//	unary      = ("!" | "not") unary | primary
+//	primary    = "(" expr ")" | comparison
+//	comparison = field [ operator literal ]
+//	operator   = "==" | "!=" | "=~" | "!~" | "<" | "<=" | ">" | ">=" | "startswith" | "endswith" | "contains"
+//	literal    = string | number | "true" | "false" | "null"
+func Parse(source string) (*Expr, error) {
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{source: source, tokens: tokens}
+	if p.peek().kind == tokEOF {
+		return nil, newSyntaxError(source, 0, "empty expression")
+	}
+
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, newSyntaxError(source, tok.pos, "unexpected %s, expected \"&&\", \"||\" or end of expression", tok.describe())
+	}
+
+	return &Expr{source: source, root: root}, nil
+}
+
+// parser is a recursive descent parser over a token list
+type parser struct {
+	source string
+	tokens []token
+	pos    int
+}
+
+// peek returns the current token without consuming it
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+// next consumes and returns the current token
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// parseOr parses a sequence of and-expressions joined by ||
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == tokOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseAnd parses a sequence of unary expressions joined by &&
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == tokAnd {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &andNode{left: left, right: right}
+	}
+	return left, nil
+}
+
+// parseUnary parses an optionally negated primary expression
+func (p *parser) parseUnary() (node, error) {
+	if p.peek().kind == tokNot {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+// parsePrimary parses a parenthesised expression or a comparison
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.next()
+
+	switch tok.kind {
+	case tokLParen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, newSyntaxError(p.source, closing.pos, "expected \")\" to close \"(\" at position %d, got %s", tok.pos+1, closing.describe())
+		}
+		return inner, nil
+	case tokIdent:
+		return p.parseComparison(tok)
+	default:
+		return nil, newSyntaxError(p.source, tok.pos, "expected field name or \"(\", got %s", tok.describe())
+	}
+}
+
+// parseComparison parses a field optionally followed by an operator and a literal
+func (p *parser) parseComparison(field token) (node, error) {
+	if err := validateField(field.text); err != nil {
+		return nil, newSyntaxError(p.source, field.pos, "%v", err)
+	}
+
+	opTok := p.peek()
+	op, ok := comparisonOperator(opTok)
+	if !ok {
+		// A bare field tests whether the value is set
+		return &existsNode{field: field.text}, nil
+	}
+	p.next()
+
+	litTok := p.next()
+	literal, err := p.literalValue(litTok, opTok)
+	if err != nil {
+		return nil, err
+	}
+
+	cmp := &compareNode{field: field.text, op: op, literal: literal}
+
+	switch op {
+	case "=~", "!~":
+		pattern, ok := literal.(string)
+		if !ok {
+			return nil, newSyntaxError(p.source, litTok.pos, "%s requires a string pattern", opTok.text)
+		}
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return nil, newSyntaxError(p.source, litTok.pos, "invalid regular expression: %v", err)
+		}
+		cmp.re = re
+	case "<", "<=", ">", ">=":
+		if _, ok := literal.(float64); !ok {
+			return nil, newSyntaxError(p.source, litTok.pos, "%s requires a number", opTok.text)
+		}
+	case "startswith", "endswith", "contains":
+		if _, ok := literal.(string); !ok {
+			return nil, newSyntaxError(p.source, litTok.pos, "%s requires a string", opTok.text)
+		}
+	}
+
+	return cmp, nil
+}
+
+// comparisonOperator returns the operator spelled by a token, if it is one
+func comparisonOperator(tok token) (string, bool) {
+	switch tok.kind {
+	case tokEq, tokNeq, tokMatch, tokNotMatch, tokLt, tokLte, tokGt, tokGte:
+		return tok.text, true
+	case tokIdent:
+		op := strings.ToLower(tok.text)
+		return op, wordOperators[op]
+	}
+	return "", false
+}
+
+// literalValue converts a literal token into its value
+func (p *parser) literalValue(tok, opTok token) (interface{}, error) {
+	switch tok.kind {
+	case tokString, tokNumber:
+		return tok.value, nil
+	case tokIdent:
+		switch tok.text {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		return nil, newSyntaxError(p.source, tok.pos, "expected a value after %s, got %s (strings must be double-quoted)", opTok.text, tok.describe())
+	default:
+		return nil, newSyntaxError(p.source, tok.pos, "expected a value after %s, got %s", opTok.text, tok.describe())
+	}
+}
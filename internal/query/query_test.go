@@ -0,0 +1,127 @@
+package query
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+// sampleChanges returns resource changes used across the query tests
+func sampleChanges() map[string]*model.ResourceChange {
+	return map[string]*model.ResourceChange{
+		"db": {
+			Address: "module.prod.aws_db_instance.main", ModuleAddress: "module.prod",
+			Type: "aws_db_instance", Name: "main", ProviderName: "registry.terraform.io/hashicorp/aws",
+			Actions: []model.Action{model.ActionDestroy, model.ActionCreate},
+			Before:  map[string]interface{}{"instance_class": "db.t3.micro", "allocated_storage": float64(20)},
+			After:   map[string]interface{}{"instance_class": "db.m5.large", "allocated_storage": float64(100)},
+		},
+		"bucket": {
+			Address: "aws_s3_bucket.logs", Type: "aws_s3_bucket", Name: "logs",
+			ProviderName: "registry.terraform.io/hashicorp/aws",
+			Actions:      []model.Action{model.ActionUpdate},
+			Before: map[string]interface{}{
+				"versioning": []interface{}{map[string]interface{}{"enabled": true}},
+				"tags":       map[string]interface{}{"Owner": "ops"},
+			},
+			After: map[string]interface{}{
+				"versioning": []interface{}{},
+				"tags":       map[string]interface{}{"Owner": "ops"},
+			},
+		},
+		"role": {
+			Address: "module.staging.aws_iam_role.ci", ModuleAddress: "module.staging",
+			Type: "aws_iam_role", Name: "ci", ProviderName: "registry.terraform.io/hashicorp/aws",
+			Actions: []model.Action{model.ActionDestroy},
+		},
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected []string
+	}{
+		{`action == "destroy" && type =~ "aws_db_.*" && module startswith "module.prod"`, []string{"db"}},
+		{`action == "destroy"`, []string{"db", "role"}},
+		{`action == "replace"`, []string{"db"}},
+		{`action != "destroy"`, []string{"bucket"}},
+		{`type =~ "aws_db"`, nil},
+		{`type !~ "aws_(db|iam)_.*"`, []string{"bucket"}},
+		{`name == "logs" || name == "ci"`, []string{"bucket", "role"}},
+		{`!(module startswith "module.")`, []string{"bucket"}},
+		{`not module`, []string{"bucket"}},
+		{`address endswith ".ci" and provider contains "hashicorp/aws"`, []string{"role"}},
+		{`(action == "update" || action == "create") && type contains "s3"`, []string{"bucket"}},
+		{`after.instance_class == "db.m5.large"`, []string{"db"}},
+		{`after.allocated_storage > 50 && before.allocated_storage <= 20`, []string{"db"}},
+		{`before.versioning[0].enabled && !after.versioning`, []string{"bucket"}},
+		{`before.tags contains "Owner"`, []string{"bucket"}},
+		{`after.missing == null && action == "update"`, []string{"bucket"}},
+		{`changed == "instance_class"`, []string{"db"}},
+		{`changed startswith "versioning"`, []string{"bucket"}},
+		{`!changed`, []string{"role"}},
+	}
+
+	changes := sampleChanges()
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			expr, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var matched []string
+			for _, key := range []string{"bucket", "db", "role"} {
+				if expr.Match(changes[key]) {
+					matched = append(matched, key)
+				}
+			}
+
+			if strings.Join(matched, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("Expected %v to match, got %v", tt.expected, matched)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		expr    string
+		message string
+		pos     int
+	}{
+		{``, "empty expression", 1},
+		{`action ==`, "expected a value after ==", 10},
+		{`action == destroy`, "strings must be double-quoted", 11},
+		{`colour == "red"`, "unknown field \"colour\"", 1},
+		{`type =~ "aws_(db"`, "invalid regular expression", 9},
+		{`(action == "create"`, "expected \")\"", 20},
+		{`action == "create" type == "x"`, "unexpected \"type\"", 20},
+		{`name == "unterminated`, "unterminated string", 9},
+		{`name == "a" & type == "b"`, "unexpected character \"&\"", 13},
+		{`name > "a"`, "> requires a number", 8},
+		{`after. == "x"`, "missing attribute path", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := Parse(tt.expr)
+			if err == nil {
+				t.Fatalf("Expected error, but got none")
+			}
+
+			syntaxErr, ok := err.(*SyntaxError)
+			if !ok {
+				t.Fatalf("Expected *SyntaxError, got %T", err)
+			}
+			if !strings.Contains(syntaxErr.Msg, tt.message) {
+				t.Errorf("Expected message to contain %q, got %q", tt.message, syntaxErr.Msg)
+			}
+			if syntaxErr.Pos+1 != tt.pos {
+				t.Errorf("Expected error at position %d, got %d", tt.pos, syntaxErr.Pos+1)
+			}
+		})
+	}
+}
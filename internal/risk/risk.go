@@ -0,0 +1,217 @@
+package risk
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+// Plan risk levels
+const (
+	LevelLow    = "low"
+	LevelMedium = "medium"
+	LevelHigh   = "high"
+)
+
+// IAMTypes are the resource type globs that grant or change access
+var IAMTypes = []string{
+	"aws_iam_*",
+	"aws_kms_grant",
+	"aws_*_policy",
+	"azurerm_role_*",
+	"azurerm_user_assigned_identity",
+	"azuread_*",
+	"google_*_iam_*",
+	"google_service_account*",
+	"google_project_iam_custom_role",
+	"kubernetes_*role*",
+	"kubernetes_service_account*",
+}
+
+// NetworkTypes are the resource type globs that change network reachability
+var NetworkTypes = []string{
+	"aws_security_group*",
+	"aws_vpc*",
+	"aws_subnet",
+	"aws_route*",
+	"aws_network_acl*",
+	"aws_internet_gateway",
+	"aws_nat_gateway",
+	"aws_lb*",
+	"aws_alb*",
+	"azurerm_network_*",
+	"azurerm_virtual_network*",
+	"azurerm_subnet*",
+	"azurerm_firewall*",
+	"azurerm_public_ip",
+	"google_compute_firewall",
+	"google_compute_network",
+	"google_compute_subnetwork",
+	"google_compute_route",
+	"google_compute_router*",
+	"kubernetes_network_policy*",
+	"kubernetes_ingress*",
+}
+
+// Weights are the points each risk factor adds to a resource score, and the plan
+// score thresholds for the medium and high levels
+type Weights struct {
+	Create               int // Resource is created
+	Update               int // Resource is updated in place
+	Destroy              int // Resource is destroyed
+	Replace              int // Resource is destroyed and re-created
+	Stateful             int // Stateful resource is destroyed or replaced
+	ForcedReplace        int // Replacement forced by a change that can't be applied in place
+	ChangedAttribute     int // Each changed attribute of an update or replacement
+	MaxChangedAttributes int // Maximum number of changed attributes counted
+	IAM                  int // Resource grants or changes access
+	Network              int // Resource changes network reachability
+	MediumThreshold      int // Plan score from which the plan is medium risk
+	HighThreshold        int // Plan score from which the plan is high risk
+}
+
+// DefaultWeights returns the built-in weights
+func DefaultWeights() Weights {
+	return Weights{
+		Create:               1,
+		Update:               2,
+		Destroy:              8,
+		Replace:              10,
+		Stateful:             15,
+		ForcedReplace:        3,
+		ChangedAttribute:     1,
+		MaxChangedAttributes: 10,
+		IAM:                  5,
+		Network:              4,
+		MediumThreshold:      20,
+		HighThreshold:        50,
+	}
+}
+
+// Override replaces the weights given by name, as used in the project configuration
+func (w *Weights) Override(values map[string]int) error {
+	fields := map[string]*int{
+		"create":                 &w.Create,
+		"update":                 &w.Update,
+		"destroy":                &w.Destroy,
+		"replace":                &w.Replace,
+		"stateful":               &w.Stateful,
+		"forced_replace":         &w.ForcedReplace,
+		"changed_attribute":      &w.ChangedAttribute,
+		"max_changed_attributes": &w.MaxChangedAttributes,
+		"iam":                    &w.IAM,
+		"network":                &w.Network,
+		"medium":                 &w.MediumThreshold,
+		"high":                   &w.HighThreshold,
+	}
+
+	// Report unknown names in a stable order
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		field, ok := fields[name]
+		if !ok {
+			return fmt.Errorf("unknown risk weight %q", name)
+		}
+		if values[name] < 0 {
+			return fmt.Errorf("risk weight %q must not be negative", name)
+		}
+		*field = values[name]
+	}
+
+	if w.HighThreshold < w.MediumThreshold {
+		return fmt.Errorf("high risk threshold %d is below the medium threshold %d", w.HighThreshold, w.MediumThreshold)
+	}
+
+	return nil
+}
+
+// Scorer computes risk scores with a set of weights
+type Scorer struct {
+	Weights Weights
+}
+
+// NewScorer creates a scorer from the default weights with the given overrides
+func NewScorer(overrides map[string]int) (*Scorer, error) {
+	weights := DefaultWeights()
+	if err := weights.Override(overrides); err != nil {
+		return nil, err
+	}
+	return &Scorer{Weights: weights}, nil
+}
+
+// Score computes the risk score of a single resource change. Stateful resources
+// are recognized by their DANGER mark in the collection.
+func (s *Scorer) Score(resources *model.ResourceCollection, change *model.ResourceChange) int {
+	w := s.Weights
+	score := 0
+
+	switch {
+	case change.IsReplacement():
+		score += w.Replace
+		if change.ActionReason == "replace_because_cannot_update" {
+			score += w.ForcedReplace
+		}
+	case change.HasAction(model.ActionDestroy):
+		score += w.Destroy
+	case change.HasAction(model.ActionUpdate):
+		score += w.Update
+	case change.HasAction(model.ActionCreate):
+		score += w.Create
+	default:
+		return 0
+	}
+
+	if resources.IsDangerous(change.Address) {
+		score += w.Stateful
+	}
+
+	// Every attribute of a new resource is "changed", so only count updates and replacements
+	if change.HasAction(model.ActionUpdate) || change.IsReplacement() {
+		changed := len(change.ChangedAttributes())
+		if changed > w.MaxChangedAttributes {
+			changed = w.MaxChangedAttributes
+		}
+		score += changed * w.ChangedAttribute
+	}
+
+	if model.MatchAnyGlob(IAMTypes, change.Type) {
+		score += w.IAM
+	}
+	if model.MatchAnyGlob(NetworkTypes, change.Type) {
+		score += w.Network
+	}
+
+	return score
+}
+
+// Apply scores every resource of the collection and the plan as a whole.
+// The plan score is the sum of the resource scores.
+func (s *Scorer) Apply(resources *model.ResourceCollection) {
+	total := 0
+	for _, address := range resources.Addresses() {
+		score := s.Score(resources, resources.GetResourceChange(address))
+		resources.RiskScores[address] = score
+		total += score
+	}
+
+	resources.RiskScore = total
+	resources.RiskLevel = s.Level(total)
+}
+
+// Level returns the risk level of a plan score
+func (s *Scorer) Level(score int) string {
+	switch {
+	case score >= s.Weights.HighThreshold:
+		return LevelHigh
+	case score >= s.Weights.MediumThreshold:
+		return LevelMedium
+	default:
+		return LevelLow
+	}
+}
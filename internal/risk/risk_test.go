@@ -0,0 +1,81 @@
+package risk
+
+import (
+	"testing"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+func TestScore(t *testing.T) {
+	resources := model.NewResourceCollection()
+	db := &model.ResourceChange{
+		Address: "aws_db_instance.main", Type: "aws_db_instance", Name: "main",
+		Actions:      []model.Action{model.ActionDestroy, model.ActionCreate},
+		ActionReason: "replace_because_cannot_update",
+		Before:       map[string]interface{}{"engine_version": "13"},
+		After:        map[string]interface{}{"engine_version": "15"},
+	}
+	role := &model.ResourceChange{
+		Address: "aws_iam_role.ci", Type: "aws_iam_role", Name: "ci",
+		Actions: []model.Action{model.ActionUpdate},
+		Before:  map[string]interface{}{"a": 1.0, "b": 1.0},
+		After:   map[string]interface{}{"a": 2.0, "b": 2.0},
+	}
+	bucket := &model.ResourceChange{
+		Address: "aws_s3_bucket.logs", Type: "aws_s3_bucket", Name: "logs",
+		Actions: []model.Action{model.ActionCreate},
+		After:   map[string]interface{}{"bucket": "logs", "acl": "private"},
+	}
+	resources.AddResourceChange(db)
+	resources.AddResourceChange(role)
+	resources.AddResourceChange(bucket)
+	resources.Dangerous["aws_db_instance.main"] = struct{}{}
+
+	scorer, err := NewScorer(nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	w := DefaultWeights()
+	tests := []struct {
+		change   *model.ResourceChange
+		expected int
+	}{
+		{db, w.Replace + w.ForcedReplace + w.Stateful + w.ChangedAttribute},
+		{role, w.Update + 2*w.ChangedAttribute + w.IAM},
+		{bucket, w.Create},
+	}
+	for _, test := range tests {
+		if got := scorer.Score(resources, test.change); got != test.expected {
+			t.Errorf("Score(%s) = %d, expected %d", test.change.Address, got, test.expected)
+		}
+	}
+
+	scorer.Apply(resources)
+	if resources.RiskScore != 39 || resources.RiskLevel != LevelMedium {
+		t.Errorf("Expected plan score 39 (medium), got %d (%s)", resources.RiskScore, resources.RiskLevel)
+	}
+}
+
+func TestOverride(t *testing.T) {
+	scorer, err := NewScorer(map[string]int{"create": 0, "medium": 5, "high": 10})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if scorer.Weights.Create != 0 || scorer.Weights.HighThreshold != 10 || scorer.Weights.Destroy != DefaultWeights().Destroy {
+		t.Errorf("Unexpected weights after override: %+v", scorer.Weights)
+	}
+	if scorer.Level(12) != LevelHigh {
+		t.Errorf("Expected score 12 to be high risk with a threshold of 10")
+	}
+
+	for name, overrides := range map[string]map[string]int{
+		"Unknown weight":      {"delete": 5},
+		"Negative weight":     {"iam": -1},
+		"Inverted thresholds": {"medium": 60, "high": 40},
+	} {
+		if _, err := NewScorer(overrides); err == nil {
+			t.Errorf("%s: expected an error for %v", name, overrides)
+		}
+	}
+}
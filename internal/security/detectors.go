@@ -0,0 +1,391 @@
+package security
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+// openCIDRs are the address ranges that expose a port to the whole internet
+var openCIDRs = map[string]bool{
+	"0.0.0.0/0": true,
+	"::/0":      true,
+	"*":         true,
+	"Internet":  true,
+	"Any":       true,
+}
+
+// OpenIngressDetector reports ingress rules newly opened to the whole internet
+type OpenIngressDetector struct{}
+
+// Name returns the detector name used in findings
+func (OpenIngressDetector) Name() string {
+	return "open-ingress"
+}
+
+// Detect compares the open ingress rules before and after the change
+func (OpenIngressDetector) Detect(change *model.ResourceChange) []string {
+	var messages []string
+	for _, rule := range added(openIngress(change.Type, change.Before), openIngress(change.Type, change.After)) {
+		messages = append(messages, "opens ingress "+rule)
+	}
+	return messages
+}
+
+// openIngress describes the ingress rules of a resource that are open to the internet
+func openIngress(resourceType string, value interface{}) []string {
+	if value == nil {
+		return nil
+	}
+
+	switch resourceType {
+	case "aws_security_group":
+		var rules []string
+		for i := range listAttribute(value, "ingress") {
+			prefix := fmt.Sprintf("ingress[%d].", i)
+			rules = append(rules, openCIDRRules(value, prefix, "cidr_blocks", "ipv6_cidr_blocks")...)
+		}
+		return rules
+	case "aws_security_group_rule":
+		if stringAttribute(value, "type") != "ingress" {
+			return nil
+		}
+		return openCIDRRules(value, "", "cidr_blocks", "ipv6_cidr_blocks")
+	case "aws_vpc_security_group_ingress_rule":
+		var rules []string
+		for _, path := range []string{"cidr_ipv4", "cidr_ipv6"} {
+			if cidr := stringAttribute(value, path); openCIDRs[cidr] {
+				rules = append(rules, fmt.Sprintf("%s to %s", awsPortRange(value, "", "ip_protocol"), cidr))
+			}
+		}
+		return rules
+	case "google_compute_firewall":
+		if direction := stringAttribute(value, "direction"); direction != "" && direction != "INGRESS" {
+			return nil
+		}
+		if len(listAttribute(value, "allow")) == 0 {
+			return nil
+		}
+		var rules []string
+		for _, cidr := range stringList(value, "source_ranges") {
+			if openCIDRs[cidr] {
+				rules = append(rules, "to "+cidr)
+			}
+		}
+		return rules
+	case "azurerm_network_security_rule":
+		if stringAttribute(value, "direction") != "Inbound" || stringAttribute(value, "access") != "Allow" {
+			return nil
+		}
+		prefix := stringAttribute(value, "source_address_prefix")
+		if !openCIDRs[prefix] {
+			return nil
+		}
+		return []string{fmt.Sprintf("port %s to %s", stringAttribute(value, "destination_port_range"), prefix)}
+	}
+
+	return nil
+}
+
+// openCIDRRules describes an AWS ingress rule for each open CIDR block it allows
+func openCIDRRules(value interface{}, prefix string, cidrPaths ...string) []string {
+	var rules []string
+	for _, path := range cidrPaths {
+		for _, cidr := range stringList(value, prefix+path) {
+			if openCIDRs[cidr] {
+				rules = append(rules, fmt.Sprintf("%s to %s", awsPortRange(value, prefix, "protocol"), cidr))
+			}
+		}
+	}
+	return rules
+}
+
+// awsPortRange describes the port range of an AWS ingress rule
+func awsPortRange(value interface{}, prefix, protocolPath string) string {
+	if protocol := stringAttribute(value, prefix+protocolPath); protocol == "-1" || protocol == "all" {
+		return "all ports"
+	}
+
+	from, _ := model.LookupAttribute(value, model.ParseAttributePath(prefix+"from_port"))
+	to, _ := model.LookupAttribute(value, model.ParseAttributePath(prefix+"to_port"))
+	if fmt.Sprint(from) == fmt.Sprint(to) {
+		return fmt.Sprintf("port %v", from)
+	}
+	return fmt.Sprintf("ports %v-%v", from, to)
+}
+
+// IAMWildcardDetector reports AWS policies that gain wildcard actions
+type IAMWildcardDetector struct{}
+
+// Name returns the detector name used in findings
+func (IAMWildcardDetector) Name() string {
+	return "iam-wildcard"
+}
+
+// Detect compares the wildcard actions allowed before and after the change
+func (IAMWildcardDetector) Detect(change *model.ResourceChange) []string {
+	if !strings.HasPrefix(change.Type, "aws_") {
+		return nil
+	}
+
+	actions := added(wildcardActions(change.Before), wildcardActions(change.After))
+	if len(actions) == 0 {
+		return nil
+	}
+	return []string{"grants wildcard actions: " + joinList(actions)}
+}
+
+// wildcardActions returns the wildcard actions allowed by the policy documents of a resource
+func wildcardActions(value interface{}) []string {
+	documents := []string{stringAttribute(value, "policy")}
+	for i := range listAttribute(value, "inline_policy") {
+		documents = append(documents, stringAttribute(value, fmt.Sprintf("inline_policy[%d].policy", i)))
+	}
+
+	var actions []string
+	for _, document := range documents {
+		actions = append(actions, policyWildcardActions(document)...)
+	}
+	return actions
+}
+
+// policyWildcardActions parses an IAM policy document and returns the wildcard actions its
+// Allow statements grant, such as "*" or "s3:*"
+func policyWildcardActions(document string) []string {
+	if document == "" {
+		return nil
+	}
+
+	var policy struct {
+		Statement json.RawMessage
+	}
+	if err := json.Unmarshal([]byte(document), &policy); err != nil {
+		return nil
+	}
+
+	// A policy can hold a single statement or a list of them
+	var statements []map[string]interface{}
+	if err := json.Unmarshal(policy.Statement, &statements); err != nil {
+		var statement map[string]interface{}
+		if err := json.Unmarshal(policy.Statement, &statement); err != nil {
+			return nil
+		}
+		statements = append(statements, statement)
+	}
+
+	var actions []string
+	for _, statement := range statements {
+		if statement["Effect"] != "Allow" {
+			continue
+		}
+		for _, action := range stringOrList(statement["Action"]) {
+			if action == "*" || strings.HasSuffix(action, ":*") {
+				actions = append(actions, action)
+			}
+		}
+	}
+	return actions
+}
+
+// stringOrList returns the strings of a policy element that may be a string or a list
+func stringOrList(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var result []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+// publicAccessBlockSettings are the settings of an S3 public access block
+var publicAccessBlockSettings = []string{
+	"block_public_acls",
+	"block_public_policy",
+	"ignore_public_acls",
+	"restrict_public_buckets",
+}
+
+// PublicAccessBlockDetector reports S3 public access blocks that are removed or weakened
+type PublicAccessBlockDetector struct{}
+
+// Name returns the detector name used in findings
+func (PublicAccessBlockDetector) Name() string {
+	return "s3-public-access"
+}
+
+// Detect compares the enabled public access block settings before and after the change
+func (PublicAccessBlockDetector) Detect(change *model.ResourceChange) []string {
+	if change.Type != "aws_s3_bucket_public_access_block" && change.Type != "aws_s3_account_public_access_block" {
+		return nil
+	}
+
+	var dropped []string
+	for _, setting := range publicAccessBlockSettings {
+		before, _ := boolAttribute(change.Before, setting)
+		after, _ := boolAttribute(change.After, setting)
+		if before && !after {
+			dropped = append(dropped, setting)
+		}
+	}
+	if len(dropped) == 0 {
+		return nil
+	}
+
+	if isDestroyed(change) {
+		return []string{"removes the public access block"}
+	}
+	return []string{"disables public access block settings: " + joinList(dropped)}
+}
+
+// encryptionFlags are boolean attributes that enable encryption at rest
+var encryptionFlags = []string{"encrypted", "storage_encrypted"}
+
+// EncryptionDetector reports encryption at rest being removed or disabled
+type EncryptionDetector struct{}
+
+// Name returns the detector name used in findings
+func (EncryptionDetector) Name() string {
+	return "encryption"
+}
+
+// Detect checks for removed S3 default encryption and disabled encryption flags
+func (EncryptionDetector) Detect(change *model.ResourceChange) []string {
+	if change.Type == "aws_s3_bucket_server_side_encryption_configuration" && isDestroyed(change) {
+		return []string{"removes the bucket's default encryption"}
+	}
+	if isDestroyed(change) {
+		return nil
+	}
+
+	var messages []string
+	if change.Type == "aws_s3_bucket" && blockRemoved(change, "server_side_encryption_configuration") {
+		messages = append(messages, "removes the bucket's default encryption")
+	}
+	for _, flag := range encryptionFlags {
+		before, _ := boolAttribute(change.Before, flag)
+		after, afterSet := boolAttribute(change.After, flag)
+		if before && afterSet && !after {
+			messages = append(messages, fmt.Sprintf("disables encryption (%s)", flag))
+		}
+	}
+	return messages
+}
+
+// kmsKeyTypes are the key resources whose destruction makes encrypted data unreadable
+var kmsKeyTypes = map[string]bool{
+	"aws_kms_key":           true,
+	"aws_kms_external_key":  true,
+	"aws_kms_replica_key":   true,
+	"google_kms_crypto_key": true,
+	"azurerm_key_vault_key": true,
+}
+
+// KMSDeletionDetector reports encryption keys that are scheduled for deletion or disabled
+type KMSDeletionDetector struct{}
+
+// Name returns the detector name used in findings
+func (KMSDeletionDetector) Name() string {
+	return "kms-deletion"
+}
+
+// Detect checks for destroyed and disabled keys
+func (KMSDeletionDetector) Detect(change *model.ResourceChange) []string {
+	if !kmsKeyTypes[change.Type] {
+		return nil
+	}
+
+	if change.HasAction(model.ActionDestroy) {
+		return []string{"schedules the key for deletion; data encrypted with it becomes unreadable"}
+	}
+	if boolTurnedOff(change, "is_enabled") || boolTurnedOff(change, "enabled") {
+		return []string{"disables the key"}
+	}
+	return nil
+}
+
+// loggingCheck describes a change that turns off audit or access logging
+type loggingCheck struct {
+	resourceType string
+	path         string // Boolean attribute turned off or block removed, empty to check destruction
+	block        bool   // Whether path is a block that is removed rather than a boolean
+	message      string
+}
+
+// loggingChecks are the changes reported by the logging detector
+var loggingChecks = []loggingCheck{
+	{resourceType: "aws_cloudtrail", message: "removes the CloudTrail trail"},
+	{resourceType: "aws_cloudtrail", path: "enable_logging", message: "disables CloudTrail logging"},
+	{resourceType: "aws_flow_log", message: "removes VPC flow logs"},
+	{resourceType: "aws_s3_bucket_logging", message: "removes bucket access logging"},
+	{resourceType: "aws_s3_bucket", path: "logging", block: true, message: "removes bucket access logging"},
+	{resourceType: "aws_lb", path: "access_logs[0].enabled", message: "disables load balancer access logs"},
+	{resourceType: "aws_alb", path: "access_logs[0].enabled", message: "disables load balancer access logs"},
+	{resourceType: "aws_cloudfront_distribution", path: "logging_config", block: true, message: "removes CloudFront access logging"},
+	{resourceType: "aws_config_configuration_recorder_status", path: "is_enabled", message: "disables AWS Config recording"},
+	{resourceType: "aws_guardduty_detector", path: "enable", message: "disables GuardDuty"},
+	{resourceType: "google_storage_bucket", path: "logging", block: true, message: "removes bucket access logging"},
+	{resourceType: "google_logging_project_sink", message: "removes the log sink"},
+	{resourceType: "azurerm_monitor_diagnostic_setting", message: "removes diagnostic settings"},
+}
+
+// LoggingDetector reports audit and access logging being disabled or removed
+type LoggingDetector struct{}
+
+// Name returns the detector name used in findings
+func (LoggingDetector) Name() string {
+	return "logging-disabled"
+}
+
+// Detect runs the logging checks for the resource type
+func (LoggingDetector) Detect(change *model.ResourceChange) []string {
+	var messages []string
+	for _, check := range loggingChecks {
+		if check.resourceType != change.Type {
+			continue
+		}
+
+		var matched bool
+		switch {
+		case check.path == "":
+			matched = isDestroyed(change)
+		case check.block:
+			matched = !isDestroyed(change) && blockRemoved(change, check.path)
+		default:
+			matched = !isDestroyed(change) && boolTurnedOff(change, check.path)
+		}
+		if matched {
+			messages = append(messages, check.message)
+		}
+	}
+	return messages
+}
+
+// boolTurnedOff checks if a boolean attribute changes from true to false
+func boolTurnedOff(change *model.ResourceChange, path string) bool {
+	before, _ := boolAttribute(change.Before, path)
+	after, afterSet := boolAttribute(change.After, path)
+	return before && afterSet && !after
+}
+
+// blockRemoved checks if a nested block present before the change is empty afterwards.
+// Blocks only known after apply are not reported.
+func blockRemoved(change *model.ResourceChange, path string) bool {
+	if len(listAttribute(change.Before, path)) == 0 {
+		return false
+	}
+	after, ok := model.LookupAttribute(change.After, model.ParseAttributePath(path))
+	if !ok {
+		return false
+	}
+	list, isList := after.([]interface{})
+	return after == nil || (isList && len(list) == 0)
+}
@@ -0,0 +1,122 @@
+package security
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+// Detector inspects the before and after values of a resource change for a risky change
+type Detector interface {
+	Name() string
+	Detect(change *model.ResourceChange) []string
+}
+
+// DefaultDetectors returns the built-in detectors
+func DefaultDetectors() []Detector {
+	return []Detector{
+		OpenIngressDetector{},
+		IAMWildcardDetector{},
+		PublicAccessBlockDetector{},
+		EncryptionDetector{},
+		KMSDeletionDetector{},
+		LoggingDetector{},
+	}
+}
+
+// Scan runs the detectors against every resource change and returns the findings
+// sorted by address and detector
+func Scan(resources *model.ResourceCollection, detectors []Detector) []model.SecurityFinding {
+	var findings []model.SecurityFinding
+
+	for _, address := range resources.Addresses() {
+		change, ok := resources.Changes[address]
+		if !ok {
+			// Without attribute values there is nothing to inspect
+			continue
+		}
+		for _, detector := range detectors {
+			for _, message := range detector.Detect(change) {
+				findings = append(findings, model.SecurityFinding{
+					Detector: detector.Name(),
+					Address:  address,
+					Message:  fmt.Sprintf("%s %s", address, message),
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Address != findings[j].Address {
+			return findings[i].Address < findings[j].Address
+		}
+		return findings[i].Detector < findings[j].Detector
+	})
+
+	return findings
+}
+
+// boolAttribute returns a boolean attribute value and whether it is set
+func boolAttribute(value interface{}, path string) (bool, bool) {
+	v, ok := model.LookupAttribute(value, model.ParseAttributePath(path))
+	if !ok {
+		return false, false
+	}
+	b, ok := v.(bool)
+	return b, ok
+}
+
+// stringAttribute returns a string attribute value, empty when it is not set
+func stringAttribute(value interface{}, path string) string {
+	v, _ := model.LookupAttribute(value, model.ParseAttributePath(path))
+	s, _ := v.(string)
+	return s
+}
+
+// listAttribute returns a list attribute value, nil when it is not set
+func listAttribute(value interface{}, path string) []interface{} {
+	v, _ := model.LookupAttribute(value, model.ParseAttributePath(path))
+	list, _ := v.([]interface{})
+	return list
+}
+
+// stringList returns the string elements of a list attribute
+func stringList(value interface{}, path string) []string {
+	var result []string
+	for _, item := range listAttribute(value, path) {
+		if s, ok := item.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// added returns the values of after that are not in before, sorted and without duplicates
+func added(before, after []string) []string {
+	existing := make(map[string]bool, len(before))
+	for _, v := range before {
+		existing[v] = true
+	}
+
+	var result []string
+	for _, v := range after {
+		if !existing[v] {
+			existing[v] = true
+			result = append(result, v)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// isDestroyed checks if the resource is destroyed without being re-created
+func isDestroyed(change *model.ResourceChange) bool {
+	return change.HasAction(model.ActionDestroy) && !change.HasAction(model.ActionCreate)
+}
+
+// joinList formats values as a comma-separated list
+func joinList(values []string) string {
+	return strings.Join(values, ", ")
+}
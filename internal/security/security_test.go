@@ -0,0 +1,146 @@
+package security
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+// change builds a resource change for detector tests
+func change(resourceType string, actions []model.Action, before, after interface{}) *model.ResourceChange {
+	return &model.ResourceChange{
+		Address: resourceType + ".test", Type: resourceType, Name: "test",
+		Actions: actions, Before: before, After: after,
+	}
+}
+
+var (
+	update  = []model.Action{model.ActionUpdate}
+	destroy = []model.Action{model.ActionDestroy}
+)
+
+func TestDetectors(t *testing.T) {
+	tests := []struct {
+		name     string
+		detector Detector
+		change   *model.ResourceChange
+		expected []string
+	}{
+		{
+			name:     "Security group opens SSH",
+			detector: OpenIngressDetector{},
+			change: change("aws_security_group", update,
+				map[string]interface{}{"ingress": []interface{}{
+					map[string]interface{}{"from_port": 22.0, "to_port": 22.0, "protocol": "tcp", "cidr_blocks": []interface{}{"10.0.0.0/8"}},
+				}},
+				map[string]interface{}{"ingress": []interface{}{
+					map[string]interface{}{"from_port": 22.0, "to_port": 22.0, "protocol": "tcp", "cidr_blocks": []interface{}{"10.0.0.0/8", "0.0.0.0/0"}},
+				}}),
+			expected: []string{"opens ingress port 22 to 0.0.0.0/0"},
+		},
+		{
+			name:     "Already open rule",
+			detector: OpenIngressDetector{},
+			change: change("aws_security_group_rule", update,
+				map[string]interface{}{"type": "ingress", "from_port": 443.0, "to_port": 443.0, "cidr_blocks": []interface{}{"0.0.0.0/0"}, "description": "a"},
+				map[string]interface{}{"type": "ingress", "from_port": 443.0, "to_port": 443.0, "cidr_blocks": []interface{}{"0.0.0.0/0"}, "description": "b"}),
+		},
+		{
+			name:     "New firewall rule",
+			detector: OpenIngressDetector{},
+			change: change("google_compute_firewall", []model.Action{model.ActionCreate}, nil,
+				map[string]interface{}{"allow": []interface{}{map[string]interface{}{"protocol": "tcp"}}, "source_ranges": []interface{}{"0.0.0.0/0"}}),
+			expected: []string{"opens ingress to 0.0.0.0/0"},
+		},
+		{
+			name:     "Policy gains wildcard",
+			detector: IAMWildcardDetector{},
+			change: change("aws_iam_policy", update,
+				map[string]interface{}{"policy": `{"Statement":[{"Effect":"Allow","Action":"s3:GetObject","Resource":"*"}]}`},
+				map[string]interface{}{"policy": `{"Statement":{"Effect":"Allow","Action":["s3:*","*"],"Resource":"*"}}`}),
+			expected: []string{"grants wildcard actions: *, s3:*"},
+		},
+		{
+			name:     "Deny wildcard",
+			detector: IAMWildcardDetector{},
+			change: change("aws_iam_policy", update, nil,
+				map[string]interface{}{"policy": `{"Statement":[{"Effect":"Deny","Action":"*","Resource":"*"}]}`}),
+		},
+		{
+			name:     "Public access block weakened",
+			detector: PublicAccessBlockDetector{},
+			change: change("aws_s3_bucket_public_access_block", update,
+				map[string]interface{}{"block_public_acls": true, "block_public_policy": true},
+				map[string]interface{}{"block_public_acls": true, "block_public_policy": false}),
+			expected: []string{"disables public access block settings: block_public_policy"},
+		},
+		{
+			name:     "Public access block removed",
+			detector: PublicAccessBlockDetector{},
+			change: change("aws_s3_bucket_public_access_block", destroy,
+				map[string]interface{}{"block_public_acls": true}, nil),
+			expected: []string{"removes the public access block"},
+		},
+		{
+			name:     "Encryption configuration removed",
+			detector: EncryptionDetector{},
+			change:   change("aws_s3_bucket_server_side_encryption_configuration", destroy, map[string]interface{}{}, nil),
+			expected: []string{"removes the bucket's default encryption"},
+		},
+		{
+			name:     "Storage encryption disabled",
+			detector: EncryptionDetector{},
+			change: change("aws_db_instance", []model.Action{model.ActionDestroy, model.ActionCreate},
+				map[string]interface{}{"storage_encrypted": true},
+				map[string]interface{}{"storage_encrypted": false}),
+			expected: []string{"disables encryption (storage_encrypted)"},
+		},
+		{
+			name:     "KMS key destroyed",
+			detector: KMSDeletionDetector{},
+			change:   change("aws_kms_key", destroy, map[string]interface{}{"is_enabled": true}, nil),
+			expected: []string{"schedules the key for deletion; data encrypted with it becomes unreadable"},
+		},
+		{
+			name:     "CloudTrail logging disabled",
+			detector: LoggingDetector{},
+			change: change("aws_cloudtrail", update,
+				map[string]interface{}{"enable_logging": true},
+				map[string]interface{}{"enable_logging": false}),
+			expected: []string{"disables CloudTrail logging"},
+		},
+		{
+			name:     "Bucket logging block removed",
+			detector: LoggingDetector{},
+			change: change("aws_s3_bucket", update,
+				map[string]interface{}{"logging": []interface{}{map[string]interface{}{"target_bucket": "logs"}}},
+				map[string]interface{}{"logging": []interface{}{}}),
+			expected: []string{"removes bucket access logging"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.detector.Detect(test.change)
+			if strings.Join(got, "|") != strings.Join(test.expected, "|") {
+				t.Errorf("Expected %q, got %q", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestScan(t *testing.T) {
+	resources := model.NewResourceCollection()
+	resources.AddResourceChange(change("aws_kms_key", destroy, map[string]interface{}{}, nil))
+	resources.AddResourceChange(change("aws_instance", update, map[string]interface{}{"ami": "a"}, map[string]interface{}{"ami": "b"}))
+	resources.AddResource(model.ActionDestroy, "aws_cloudtrail.main")
+
+	findings := Scan(resources, DefaultDetectors())
+	if len(findings) != 1 {
+		t.Fatalf("Expected 1 finding, got %+v", findings)
+	}
+	if findings[0].Detector != "kms-deletion" || !strings.HasPrefix(findings[0].Message, "aws_kms_key.test schedules") {
+		t.Errorf("Unexpected finding: %+v", findings[0])
+	}
+}
@@ -0,0 +1,96 @@
+package source
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+// resourceBlock matches the first line of a resource block
+var resourceBlock = regexp.MustCompile(`^\s*resource\s+"([^"]+)"\s+"([^"]+)"`)
+
+// instanceKey matches the index of a resource or module instance, e.g. [0] or ["a"]
+var instanceKey = regexp.MustCompile(`\[[^\]]*\]`)
+
+// Locate finds the resource blocks of the changes, policy violations and security findings
+// in the .tf files of the configuration. The plan only records the directory of local
+// modules, so resources in remote modules, or without configuration in the plan, are not
+// located. Paths are relative to root, the directory Terraform ran in.
+func Locate(resources *model.ResourceCollection, root string) {
+	if resources.ModuleDirs == nil {
+		return
+	}
+
+	var addresses []string
+	addresses = append(addresses, resources.Addresses()...)
+	for _, v := range resources.Violations {
+		if v.Address != "" {
+			addresses = append(addresses, v.Address)
+		}
+	}
+	for _, finding := range resources.SecurityFindings {
+		addresses = append(addresses, finding.Address)
+	}
+
+	blocks := map[string]map[string]model.SourceLocation{}
+	for _, address := range addresses {
+		change := model.NewResourceChangeFromAddress(address)
+		dir, ok := resources.ModuleDirs[instanceKey.ReplaceAllString(change.ModuleAddress, "")]
+		if !ok || change.Type == "" {
+			continue
+		}
+
+		if _, scanned := blocks[dir]; !scanned {
+			blocks[dir] = scanDir(root, dir)
+		}
+		location, ok := blocks[dir][change.Type+"."+instanceKey.ReplaceAllString(change.Name, "")]
+		if !ok {
+			continue
+		}
+		if resources.Locations == nil {
+			resources.Locations = map[string]model.SourceLocation{}
+		}
+		resources.Locations[address] = location
+	}
+}
+
+// scanDir returns the location of every resource block in the .tf files of a module
+// directory, keyed by type and name. Files that cannot be read are skipped.
+func scanDir(root, dir string) map[string]model.SourceLocation {
+	blocks := map[string]model.SourceLocation{}
+
+	files, _ := filepath.Glob(filepath.Join(root, filepath.FromSlash(dir), "*.tf"))
+	sort.Strings(files)
+	for _, file := range files {
+		name := path.Join(filepath.ToSlash(root), dir, filepath.Base(file))
+		scanFile(file, name, blocks)
+	}
+
+	return blocks
+}
+
+// scanFile adds the resource blocks of a file, recorded under the given name
+func scanFile(file, name string, blocks map[string]model.SourceLocation) {
+	f, err := os.Open(file)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if !strings.Contains(text, "resource") {
+			continue
+		}
+		if match := resourceBlock.FindStringSubmatch(text); match != nil {
+			blocks[match[1]+"."+match[2]] = model.SourceLocation{File: name, Line: line}
+		}
+	}
+}
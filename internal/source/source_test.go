@@ -0,0 +1,59 @@
+package source
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+// writeFile writes a file below dir, creating its parent directories
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLocate(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "main.tf", "terraform {}\n\nresource \"aws_s3_bucket\" \"logs\" {\n  bucket = \"logs\"\n}\n")
+	writeFile(t, root, "modules/db/rds.tf", "# Database\nresource \"aws_db_instance\" \"main\" {\n}\n")
+
+	resources := model.NewResourceCollection()
+	resources.AddResourceChange(&model.ResourceChange{Address: "aws_s3_bucket.logs", Actions: []model.Action{model.ActionDestroy}})
+	resources.AddResourceChange(&model.ResourceChange{Address: "module.vpc.aws_vpc.main", Actions: []model.Action{model.ActionCreate}})
+	resources.Violations = []model.PolicyViolation{{Rule: "no-replace", Address: `module.db["eu"].aws_db_instance.main[0]`}}
+	resources.ModuleDirs = map[string]string{"": ".", "module.db": "modules/db"}
+
+	Locate(resources, root)
+
+	expected := map[string]model.SourceLocation{
+		"aws_s3_bucket.logs":                      {File: filepath.ToSlash(root) + "/main.tf", Line: 3},
+		`module.db["eu"].aws_db_instance.main[0]`: {File: filepath.ToSlash(root) + "/modules/db/rds.tf", Line: 2},
+	}
+	if len(resources.Locations) != len(expected) {
+		t.Errorf("Expected locations %v, got %v", expected, resources.Locations)
+	}
+	for address, location := range expected {
+		if resources.Locations[address] != location {
+			t.Errorf("Expected %s at %v, got %v", address, location, resources.Locations[address])
+		}
+	}
+}
+
+func TestLocateWithoutConfiguration(t *testing.T) {
+	resources := model.NewResourceCollection()
+	resources.AddResourceChange(&model.ResourceChange{Address: "aws_s3_bucket.logs", Actions: []model.Action{model.ActionDestroy}})
+
+	Locate(resources, t.TempDir())
+
+	if resources.Locations != nil {
+		t.Errorf("Expected no locations without module directories, got %v", resources.Locations)
+	}
+}
@@ -0,0 +1,234 @@
+package stack
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+// Input is a plan file and the name of the stack it is reported under
+type Input struct {
+	Name string
+	Path string
+}
+
+// Resolve expands plan arguments into stack inputs. An argument is a plan file, a glob
+// or a directory searched recursively for JSON plans, optionally prefixed with a label:
+//
+//	network=plans/network.json
+//	prod=live/prod
+//
+// Stacks are named after their path below the directory or glob, or after their
+// directory when every plan file has the same name, as with Terragrunt run-all.
+func Resolve(args []string) ([]Input, error) {
+	var inputs []Input
+
+	for _, arg := range args {
+		label, pattern := splitLabel(arg)
+
+		root, files, err := expand(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		names := stackNames(root, files)
+		for i, file := range files {
+			name := names[i]
+			switch {
+			case label != "" && len(files) == 1:
+				name = label
+			case label != "":
+				name = label + "/" + name
+			}
+			inputs = append(inputs, Input{Name: name, Path: file})
+		}
+	}
+
+	disambiguate(inputs)
+	return inputs, nil
+}
+
+// splitLabel splits an optional label from a plan argument, unless the whole argument is an existing path
+func splitLabel(arg string) (string, string) {
+	if _, err := os.Stat(arg); err == nil {
+		return "", arg
+	}
+	if label, pattern, ok := strings.Cut(arg, "="); ok && label != "" {
+		return label, pattern
+	}
+	return "", arg
+}
+
+// expand returns the root the stack names are relative to and the plan files of an argument
+func expand(pattern string) (string, []string, error) {
+	if strings.ContainsAny(pattern, "*?[") {
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid plan pattern %s: %v", pattern, err)
+		}
+		if len(files) == 0 {
+			return "", nil, fmt.Errorf("no plan files match %s", pattern)
+		}
+		sort.Strings(files)
+		return globRoot(pattern), files, nil
+	}
+
+	info, err := os.Stat(pattern)
+	if err != nil {
+		return "", nil, fmt.Errorf("plan file %s does not exist", pattern)
+	}
+	if !info.IsDir() {
+		return filepath.Dir(pattern), []string{pattern}, nil
+	}
+
+	files, err := findPlans(pattern)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(files) == 0 {
+		return "", nil, fmt.Errorf("no JSON plan files in %s", pattern)
+	}
+	return pattern, files, nil
+}
+
+// findPlans returns the JSON plans below a directory, skipping hidden directories such as
+// .terraform and .terragrunt-cache, Terraform configuration and variable files, and JSON
+// files that are not plans
+func findPlans(dir string) ([]string, error) {
+	var files []string
+
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != dir && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		name := entry.Name()
+		if !strings.HasSuffix(name, ".json") || strings.HasSuffix(name, ".tf.json") || strings.HasSuffix(name, ".tfvars.json") {
+			return nil
+		}
+		plan, err := isPlan(path)
+		if err != nil {
+			return err
+		}
+		if plan {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error searching %s for plan files: %v", dir, err)
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// isPlan checks if a JSON file has the top-level fields of a Terraform plan. Files that are
+// not valid JSON count as plans, so a truncated plan is reported instead of skipped.
+func isPlan(path string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	if !json.Valid(data) {
+		return true, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return false, nil
+	}
+	_, format := fields["format_version"]
+	_, changes := fields["resource_changes"]
+	_, planned := fields["planned_values"]
+	return format && (changes || planned), nil
+}
+
+// globRoot returns the directory part of a glob before the first pattern character
+func globRoot(pattern string) string {
+	idx := strings.IndexAny(pattern, "*?[")
+	return filepath.Dir(pattern[:idx] + "x")
+}
+
+// stackNames names the plan files of one argument after their path relative to the root,
+// or after their directory when all files share the same name
+func stackNames(root string, files []string) []string {
+	sameName := len(files) > 1
+	for _, file := range files[1:] {
+		if filepath.Base(file) != filepath.Base(files[0]) {
+			sameName = false
+		}
+	}
+
+	names := make([]string, len(files))
+	for i, file := range files {
+		rel, err := filepath.Rel(root, file)
+		if err != nil {
+			rel = file
+		}
+
+		if sameName && filepath.Dir(rel) != "." {
+			names[i] = filepath.ToSlash(filepath.Dir(rel))
+		} else {
+			names[i] = filepath.ToSlash(strings.TrimSuffix(rel, filepath.Ext(rel)))
+		}
+	}
+	return names
+}
+
+// disambiguate renames stacks that share a name after their full path
+func disambiguate(inputs []Input) {
+	count := map[string]int{}
+	for _, input := range inputs {
+		count[input.Name]++
+	}
+
+	for i, input := range inputs {
+		if count[input.Name] > 1 {
+			inputs[i].Name = filepath.ToSlash(strings.TrimSuffix(input.Path, filepath.Ext(input.Path)))
+		}
+	}
+}
+
+// Load reads the plans of the stacks concurrently with up to workers goroutines. A stack
+// whose plan fails to load is reported with its error instead of stopping the others.
+// The stacks keep the order of the inputs.
+func Load(inputs []Input, workers int, load func(input Input) (*model.ResourceCollection, error)) *model.StackReport {
+	if workers < 1 {
+		workers = 1
+	}
+
+	report := &model.StackReport{Stacks: make([]model.Stack, len(inputs))}
+	slots := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+
+	for i, input := range inputs {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, input Input) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			resources, err := load(input)
+			report.Stacks[i] = model.Stack{Name: input.Name, Path: input.Path, Resources: resources, Err: err}
+			if err != nil {
+				report.Stacks[i].Resources = nil
+			}
+		}(i, input)
+	}
+
+	wg.Wait()
+	return report
+}
@@ -0,0 +1,149 @@
+package stack
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+// emptyPlan is a plan without changes
+const emptyPlan = `{"format_version": "1.2", "resource_changes": []}`
+
+// writePlans creates empty plan files below a temporary directory
+func writePlans(t *testing.T, paths ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, path := range paths {
+		writeFile(t, filepath.Join(dir, filepath.FromSlash(path)), emptyPlan)
+	}
+	return dir
+}
+
+// writeFile creates a file and its parent directories
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// names returns the stack names of the inputs
+func names(inputs []Input) []string {
+	var result []string
+	for _, input := range inputs {
+		result = append(result, input.Name)
+	}
+	return result
+}
+
+// equalNames compares stack names in order
+func equalNames(got, expected []string) bool {
+	if len(got) != len(expected) {
+		return false
+	}
+	for i := range got {
+		if got[i] != expected[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestResolveDirectory(t *testing.T) {
+	dir := writePlans(t, "prod/network/tfplan.json", "prod/app/tfplan.json", "prod/app/.terragrunt-cache/x/tfplan.json", "prod/README.md")
+
+	inputs, err := Resolve([]string{dir})
+	if err != nil {
+		t.Fatalf("Resolve returned an error: %v", err)
+	}
+	if expected := []string{"prod/app", "prod/network"}; !equalNames(names(inputs), expected) {
+		t.Errorf("Expected stacks %v, got %v", expected, names(inputs))
+	}
+}
+
+func TestResolveDirectorySkipsOtherJSON(t *testing.T) {
+	dir := writePlans(t, "network/tfplan.json", "app/tfplan.json", "app/main.tf.json", "app/prod.tfvars.json")
+	writeFile(t, filepath.Join(dir, "app", "main.tf.json"), `{"resource": {"aws_instance": {"web": {}}}}`)
+	writeFile(t, filepath.Join(dir, "app", "package.json"), `{"name": "lambda"}`)
+	writeFile(t, filepath.Join(dir, "app", "policy.json"), `["s3:GetObject"]`)
+	writeFile(t, filepath.Join(dir, "db", "tfplan.json"), `{"format_version": "1.2", "resource_chan`)
+
+	inputs, err := Resolve([]string{dir})
+	if err != nil {
+		t.Fatalf("Resolve returned an error: %v", err)
+	}
+	if expected := []string{"app", "db", "network"}; !equalNames(names(inputs), expected) {
+		t.Errorf("Expected the plans and the truncated plan, got %v", names(inputs))
+	}
+}
+
+func TestResolveGlobAndLabels(t *testing.T) {
+	dir := writePlans(t, "plans/network.json", "plans/app.json", "other/tfplan.json")
+
+	inputs, err := Resolve([]string{
+		filepath.Join(dir, "plans", "*.json"),
+		"shared=" + filepath.Join(dir, "other", "tfplan.json"),
+	})
+	if err != nil {
+		t.Fatalf("Resolve returned an error: %v", err)
+	}
+	if expected := []string{"app", "network", "shared"}; !equalNames(names(inputs), expected) {
+		t.Errorf("Expected stacks %v, got %v", expected, names(inputs))
+	}
+}
+
+func TestResolveDuplicateNames(t *testing.T) {
+	dir := writePlans(t, "a/tfplan.json", "b/tfplan.json")
+
+	inputs, err := Resolve([]string{filepath.Join(dir, "a", "tfplan.json"), filepath.Join(dir, "b", "tfplan.json")})
+	if err != nil {
+		t.Fatalf("Resolve returned an error: %v", err)
+	}
+	if inputs[0].Name == inputs[1].Name {
+		t.Errorf("Expected plans with the same file name to get distinct stack names, got %v", names(inputs))
+	}
+}
+
+func TestResolveNoMatch(t *testing.T) {
+	dir := writePlans(t, "README.md")
+
+	for _, arg := range []string{dir, filepath.Join(dir, "*.json"), filepath.Join(dir, "missing.json")} {
+		if _, err := Resolve([]string{arg}); err == nil {
+			t.Errorf("Expected an error for %s", arg)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	inputs := []Input{{Name: "a", Path: "a.json"}, {Name: "broken", Path: "broken.json"}, {Name: "c", Path: "c.json"}}
+
+	report := Load(inputs, 2, func(input Input) (*model.ResourceCollection, error) {
+		if input.Path == "broken.json" {
+			return nil, errors.New("invalid plan")
+		}
+		resources := model.NewResourceCollection()
+		resources.AddResource(model.ActionCreate, "aws_s3_bucket."+input.Name)
+		return resources, nil
+	})
+
+	if len(report.Stacks) != 3 {
+		t.Fatalf("Expected 3 stacks, got %d", len(report.Stacks))
+	}
+	for i, stack := range report.Stacks {
+		if stack.Name != inputs[i].Name {
+			t.Errorf("Expected stack %d to be %s, got %s", i, inputs[i].Name, stack.Name)
+		}
+	}
+	if failed := report.Failed(); len(failed) != 1 || failed[0].Name != "broken" {
+		t.Errorf("Expected only the broken stack to fail, got %v", failed)
+	}
+	if report.Stacks[2].Resources == nil {
+		t.Errorf("Expected the stacks after a failure to be loaded")
+	}
+}
@@ -0,0 +1,113 @@
+package stateful
+
+import (
+	"sort"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+// Defaults lists the built-in stateful resource types by provider. Destroying or
+// replacing one of these loses data or breaks everything that depends on it.
+var Defaults = map[string][]string{
+	"aws": {
+		"aws_db_instance",
+		"aws_rds_cluster",
+		"aws_docdb_cluster",
+		"aws_neptune_cluster",
+		"aws_redshift_cluster",
+		"aws_dynamodb_table",
+		"aws_elasticache_cluster",
+		"aws_elasticache_replication_group",
+		"aws_opensearch_domain",
+		"aws_elasticsearch_domain",
+		"aws_s3_bucket",
+		"aws_ebs_volume",
+		"aws_efs_file_system",
+		"aws_fsx_*_file_system",
+		"aws_backup_vault",
+		"aws_kms_key",
+		"aws_secretsmanager_secret",
+		"aws_route53_zone",
+	},
+	"azurerm": {
+		"azurerm_mssql_server",
+		"azurerm_mssql_database",
+		"azurerm_postgresql_flexible_server",
+		"azurerm_mysql_flexible_server",
+		"azurerm_cosmosdb_account",
+		"azurerm_redis_cache",
+		"azurerm_storage_account",
+		"azurerm_managed_disk",
+		"azurerm_key_vault",
+		"azurerm_key_vault_key",
+		"azurerm_dns_zone",
+		"azurerm_private_dns_zone",
+	},
+	"google": {
+		"google_sql_database_instance",
+		"google_sql_database",
+		"google_spanner_instance",
+		"google_spanner_database",
+		"google_bigtable_instance",
+		"google_bigquery_dataset",
+		"google_bigquery_table",
+		"google_redis_instance",
+		"google_storage_bucket",
+		"google_compute_disk",
+		"google_filestore_instance",
+		"google_kms_key_ring",
+		"google_kms_crypto_key",
+		"google_dns_managed_zone",
+	},
+	"kubernetes": {
+		"kubernetes_persistent_volume",
+		"kubernetes_persistent_volume_v1",
+		"kubernetes_persistent_volume_claim",
+		"kubernetes_persistent_volume_claim_v1",
+	},
+}
+
+// Catalogue decides which resource types are stateful
+type Catalogue struct {
+	types   []string // Stateful type globs
+	exclude []string // Type globs that are never stateful, overriding types
+}
+
+// DefaultTypes returns the built-in stateful types of all providers, sorted
+func DefaultTypes() []string {
+	var types []string
+	for _, providerTypes := range Defaults {
+		types = append(types, providerTypes...)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// NewCatalogue creates a catalogue from the built-in defaults, unless useDefaults is false,
+// extended with extra type globs and without the excluded ones
+func NewCatalogue(useDefaults bool, extra, exclude []string) *Catalogue {
+	c := &Catalogue{exclude: exclude}
+	if useDefaults {
+		c.types = DefaultTypes()
+	}
+	c.types = append(c.types, extra...)
+	return c
+}
+
+// IsStateful checks if a resource type is in the catalogue
+func (c *Catalogue) IsStateful(resourceType string) bool {
+	if model.MatchAnyGlob(c.exclude, resourceType) {
+		return false
+	}
+	return model.MatchAnyGlob(c.types, resourceType)
+}
+
+// Mark records the stateful resources that the plan destroys or replaces as dangerous
+func (c *Catalogue) Mark(resources *model.ResourceCollection) {
+	for address := range resources.Resources[model.ActionDestroy] {
+		change := resources.GetResourceChange(address)
+		if c.IsStateful(change.Type) {
+			resources.Dangerous[address] = struct{}{}
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package stateful
+
+import (
+	"testing"
+
+	"github.com/marc-poljak/terraform-plan-filter/internal/model"
+)
+
+func TestIsStateful(t *testing.T) {
+	tests := []struct {
+		name         string
+		catalogue    *Catalogue
+		resourceType string
+		expected     bool
+	}{
+		{"Default database", NewCatalogue(true, nil, nil), "aws_db_instance", true},
+		{"Default glob", NewCatalogue(true, nil, nil), "aws_fsx_lustre_file_system", true},
+		{"Stateless type", NewCatalogue(true, nil, nil), "aws_instance", false},
+		{"Extended", NewCatalogue(true, []string{"mycorp_*"}, nil), "mycorp_database", true},
+		{"Excluded", NewCatalogue(true, nil, []string{"aws_s3_bucket"}), "aws_s3_bucket", false},
+		{"Without defaults", NewCatalogue(false, []string{"mycorp_database"}, nil), "aws_db_instance", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.catalogue.IsStateful(test.resourceType); got != test.expected {
+				t.Errorf("IsStateful(%q) = %v, expected %v", test.resourceType, got, test.expected)
+			}
+		})
+	}
+}
+
+func TestMark(t *testing.T) {
+	resources := model.NewResourceCollection()
+	resources.AddResourceChange(&model.ResourceChange{
+		Address: "module.db.aws_db_instance.main", Type: "aws_db_instance", Name: "main",
+		Actions: []model.Action{model.ActionDestroy, model.ActionCreate},
+	})
+	resources.AddResourceChange(&model.ResourceChange{
+		Address: "aws_s3_bucket.logs", Type: "aws_s3_bucket", Name: "logs",
+		Actions: []model.Action{model.ActionUpdate},
+	})
+	resources.AddResourceChange(&model.ResourceChange{
+		Address: "aws_instance.web", Type: "aws_instance", Name: "web",
+		Actions: []model.Action{model.ActionDestroy},
+	})
+
+	NewCatalogue(true, nil, nil).Mark(resources)
+
+	if len(resources.Dangerous) != 1 || !resources.IsDangerous("module.db.aws_db_instance.main") {
+		t.Errorf("Expected only the replaced database to be dangerous, got %v", resources.Dangerous)
+	}
+}
@@ -77,5 +77,11 @@ func PrintDebugInfo(resources *model.ResourceCollection, verbose bool) {
 	}
 
 	fmt.Printf("Total changes detected: %d\n", resources.TotalChanges())
+	if resources.IgnoredResources > 0 {
+		fmt.Printf("Resources ignored by ignore rules: %d\n", resources.IgnoredResources)
+	}
+	if resources.HiddenResources > 0 {
+		fmt.Printf("Resources hidden by filters: %d\n", resources.HiddenResources)
+	}
 	fmt.Println("=================")
 }
@@ -0,0 +1,30 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// FindFileUpwards looks for the first of the given file names in dir and its parent directories.
+// It returns an empty string if none is found.
+func FindFileUpwards(dir string, names ...string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+
+	for {
+		for _, name := range names {
+			candidate := filepath.Join(dir, name)
+			if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+				return candidate
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,114 @@
+package webhook
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Payload formats accepted by chat webhooks
+const (
+	FormatSlack = "slack"
+	FormatTeams = "teams"
+)
+
+// DetectFormat returns the payload format for a webhook URL from its host, or an empty
+// string if the host is not known
+func DetectFormat(webhookURL string) string {
+	parsed, err := url.Parse(webhookURL)
+	if err != nil {
+		return ""
+	}
+
+	host := strings.ToLower(parsed.Hostname())
+	switch {
+	case host == "hooks.slack.com":
+		return FormatSlack
+	case strings.HasSuffix(host, ".webhook.office.com"), strings.HasSuffix(host, ".logic.azure.com"),
+		strings.HasSuffix(host, ".powerplatform.com"):
+		return FormatTeams
+	default:
+		return ""
+	}
+}
+
+// maxRetries is how often a failed delivery is retried
+const maxRetries = 3
+
+// maxWait is the longest wait for a rate limit to reset
+const maxWait = 2 * time.Minute
+
+// Sender posts JSON payloads to a webhook, retrying when the server is unavailable or
+// rate limits the request
+type Sender struct {
+	HTTP    *http.Client
+	Sleep   func(time.Duration) // time.Sleep, replaced in tests
+	Backoff time.Duration       // Wait before the first retry, doubled for each further one
+}
+
+// NewSender creates a sender with a request timeout
+func NewSender() *Sender {
+	return &Sender{HTTP: &http.Client{Timeout: 30 * time.Second}, Sleep: time.Sleep, Backoff: time.Second}
+}
+
+// Send posts the payload to the webhook URL. Network errors, rate limits and server errors
+// are retried; other errors fail at once.
+func (s *Sender) Send(webhookURL string, payload []byte) error {
+	wait := s.Backoff
+
+	for attempt := 0; ; attempt++ {
+		retryAfter, err := s.post(webhookURL, payload)
+		if err == nil {
+			return nil
+		}
+		if retryAfter < 0 || attempt == maxRetries {
+			return err
+		}
+
+		if retryAfter > 0 {
+			s.Sleep(retryAfter)
+		} else {
+			s.Sleep(wait)
+		}
+		wait *= 2
+	}
+}
+
+// post delivers the payload once. On failure it returns how long the server asked to wait,
+// zero to wait the backoff, or a negative duration if retrying will not help.
+func (s *Sender) post(webhookURL string, payload []byte) (time.Duration, error) {
+	resp, err := s.HTTP.Post(webhookURL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		// Errors of the client include the URL, which is a secret for webhooks
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		return 0, fmt.Errorf("error sending webhook: %v", err)
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	resp.Body.Close()
+
+	if resp.StatusCode < 300 {
+		return 0, nil
+	}
+
+	err = fmt.Errorf("webhook returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		seconds, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+		wait := time.Duration(seconds) * time.Second
+		if wait > maxWait {
+			wait = maxWait
+		}
+		return wait, err
+	case resp.StatusCode >= 500:
+		return 0, err
+	default:
+		return -1, err
+	}
+}
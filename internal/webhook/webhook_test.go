@@ -0,0 +1,143 @@
+package webhook
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestSender returns a sender recording its waits instead of sleeping
+func newTestSender(waits *[]time.Duration) *Sender {
+	return &Sender{
+		HTTP:    http.DefaultClient,
+		Sleep:   func(d time.Duration) { *waits = append(*waits, d) },
+		Backoff: time.Second,
+	}
+}
+
+func TestSendRetries(t *testing.T) {
+	var bodies []string
+	responses := []func(http.ResponseWriter){
+		func(w http.ResponseWriter) { w.WriteHeader(http.StatusBadGateway) },
+		func(w http.ResponseWriter) {
+			w.Header().Set("Retry-After", "5")
+			w.WriteHeader(http.StatusTooManyRequests)
+		},
+		func(w http.ResponseWriter) { w.WriteHeader(http.StatusServiceUnavailable) },
+		func(w http.ResponseWriter) { io.WriteString(w, "ok") },
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Unexpected request %s with content type %q", r.Method, r.Header.Get("Content-Type"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		responses[len(bodies)-1](w)
+	}))
+	defer server.Close()
+
+	var waits []time.Duration
+	if err := newTestSender(&waits).Send(server.URL, []byte(`{"text":"plan"}`)); err != nil {
+		t.Fatalf("Send returned an error: %v", err)
+	}
+
+	if len(bodies) != 4 || bodies[3] != `{"text":"plan"}` {
+		t.Errorf("Expected the payload to be delivered on the fourth attempt, got %v", bodies)
+	}
+	// The backoff doubles, except when the server says how long to wait
+	expected := []time.Duration{time.Second, 5 * time.Second, 4 * time.Second}
+	if len(waits) != len(expected) {
+		t.Fatalf("Expected waits %v, got %v", expected, waits)
+	}
+	for i := range expected {
+		if waits[i] != expected[i] {
+			t.Errorf("Expected waits %v, got %v", expected, waits)
+			break
+		}
+	}
+}
+
+func TestSendCapsRetryAfter(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.Header().Set("Retry-After", "86400")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer server.Close()
+
+	var waits []time.Duration
+	if err := newTestSender(&waits).Send(server.URL, []byte(`{}`)); err != nil {
+		t.Fatalf("Send returned an error: %v", err)
+	}
+	if len(waits) != 1 || waits[0] != maxWait {
+		t.Errorf("Expected one wait capped at %v, got %v", maxWait, waits)
+	}
+}
+
+func TestSendGivesUp(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	var waits []time.Duration
+	if err := newTestSender(&waits).Send(server.URL, []byte("{}")); err == nil {
+		t.Errorf("Expected an error once the retries are used up")
+	}
+	if requests != maxRetries+1 {
+		t.Errorf("Expected %d requests, got %d", maxRetries+1, requests)
+	}
+}
+
+func TestSendClientErrorNotRetried(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, "invalid_blocks")
+	}))
+	defer server.Close()
+
+	var waits []time.Duration
+	err := newTestSender(&waits).Send(server.URL, []byte("{}"))
+	if err == nil || !strings.Contains(err.Error(), "invalid_blocks") {
+		t.Errorf("Expected the error of the server, got %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("Expected a bad request not to be retried, got %d requests", requests)
+	}
+}
+
+func TestSendHidesURL(t *testing.T) {
+	var waits []time.Duration
+	sender := newTestSender(&waits)
+	err := sender.Send("http://127.0.0.1:1/services/T000/B000/secret", []byte("{}"))
+	if err == nil || strings.Contains(err.Error(), "secret") {
+		t.Errorf("Expected an error without the webhook URL, got %v", err)
+	}
+	if len(waits) != maxRetries {
+		t.Errorf("Expected network errors to be retried, got %d waits", len(waits))
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := map[string]string{
+		"https://hooks.slack.com/services/T000/B000/XXXX":                           FormatSlack,
+		"https://acme.webhook.office.com/webhookb2/abc":                             FormatTeams,
+		"https://prod-01.westeurope.logic.azure.com:443/workflows/abc/triggers/run": FormatTeams,
+		"https://chat.example.com/hook":                                             "",
+	}
+	for url, expected := range tests {
+		if got := DetectFormat(url); got != expected {
+			t.Errorf("DetectFormat(%q) = %q, want %q", url, got, expected)
+		}
+	}
+}